@@ -0,0 +1,7 @@
+//go:build debug
+
+package main
+
+// debugBuild is set by building with -tags debug, which makes jlc verify the
+// IR it generates after every pass, to catch bugs in the compiler itself.
+const debugBuild = true
@@ -6,10 +6,14 @@ import (
 	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/antlr4-go/antlr/v4"
-	"github.com/aramyamal/javalette-to-llvm-compiler/gen/parser"
 	"github.com/aramyamal/javalette-to-llvm-compiler/internal/codegen"
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/loader"
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/optimize"
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
 	"github.com/aramyamal/javalette-to-llvm-compiler/internal/typechk"
 )
 
@@ -32,40 +36,104 @@ func (e *errorListener) SyntaxError(
 
 func main() {
 	outputFile := flag.String("o", "", "Output file (default: stdout)")
+	release := flag.Bool("release", false, "Compile assert statements to nothing")
+	flag.BoolVar(release, "DNDEBUG", false, "Same as -release")
+	level := "O0"
+	for _, name := range []string{"O0", "O1", "O2", "Os"} {
+		flag.BoolFunc(name, levelUsage[name], func(value string) error {
+			set, err := strconv.ParseBool(value)
+			if set {
+				level = name
+			}
+			return err
+		})
+	}
+	passes := flag.String(
+		"passes", "",
+		"Comma separated optimization passes to run instead of those of the "+
+			"level, out of "+strings.Join(optimize.PassNames, ", "),
+	)
+	inlineThreshold := flag.Int(
+		"inline-threshold", 25,
+		"Maximum number of instructions of an inlined function, "+
+			"which is 5 instead under -Os",
+	)
+	printAfter := flag.String(
+		"print-after", "",
+		"Comma separated passes after which to print the IR to stderr",
+	)
+	timePasses := flag.Bool(
+		"time-passes", false, "Print the time spent in each pass to stderr",
+	)
+	printWarnings := flag.Bool(
+		"warnings", false, "Print warnings about removed dead code to stderr",
+	)
+	var includeDirs []string
+	flag.Func("I", "Directory to search for imported files", func(dir string) error {
+		includeDirs = append(includeDirs, dir)
+		return nil
+	})
 	flag.Parse()
 	args := flag.Args()
 
-	var err error
-	var stream antlr.CharStream
-	if len(args) > 0 {
-		filepath := args[0]
-		stream, err = antlr.NewFileStream(filepath)
+	passNames, err := optimize.LevelPasses(level)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if *passes != "" {
+		passNames = strings.Split(*passes, ",")
+	}
+	threshold := *inlineThreshold
+	if level == "Os" && !isFlagSet("inline-threshold") {
+		threshold = 5
+	}
+	pipeline, err := optimize.NewPipeline(passNames, threshold)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if *printAfter != "" {
+		err := pipeline.PrintAfter(
+			strings.Split(*printAfter, ","), os.Stderr,
+			func(prgm *tast.Prgm) (string, error) {
+				return generateIR(prgm, *release)
+			},
+		)
 		if err != nil {
-			log.Fatalf("Error reading file %v: %v", filepath, err)
+			log.Fatalln(err)
 		}
+	}
+	if *timePasses {
+		pipeline.TimePasses()
+	}
+	if debugBuild {
+		pipeline.VerifyEach()
+	}
+
+	fileLoader := loader.NewLoader(includeDirs, &errorListener{})
+	var modules []*loader.Module
+	if len(args) > 0 {
+		modules, err = fileLoader.LoadFile(args[0])
 	} else {
-		input, err := io.ReadAll(os.Stdin)
-		if err != nil {
-			log.Fatal("Error processing standard input:", err)
+		input, readErr := io.ReadAll(os.Stdin)
+		if readErr != nil {
+			log.Fatal("Error processing standard input:", readErr)
 		}
-		stream = antlr.NewInputStream(string(input))
+		stream := antlr.NewInputStream(string(input))
+		modules, err = fileLoader.LoadStream(stream, ".")
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR")
+		log.Fatalln(err)
 	}
-
-	errorList := &errorListener{}
-
-	lexer := parser.NewJavaletteLexer(stream)
-	lexer.RemoveErrorListeners()
-	lexer.AddErrorListener(errorList)
-
-	tokens := antlr.NewCommonTokenStream(lexer, 0)
-	parser := parser.NewJavaletteParser(tokens)
-	parser.RemoveErrorListeners()
-	parser.AddErrorListener(&errorListener{})
-
-	tree := parser.Prgm()
 
 	typechk := typechk.NewTypeChecker()
-	tast, err := typechk.Typecheck(tree)
+	prgm, err := typechk.TypecheckModules(modules)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR")
+		log.Fatalln(err)
+	}
+
+	warnings, err := pipeline.RunPrgm(prgm)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "ERROR")
 		log.Fatalln(err)
@@ -84,10 +152,54 @@ func main() {
 	}
 
 	codegen := codegen.NewCodeGenerator(writer)
-	if err := codegen.GenerateCode(tast); err != nil {
+	codegen.SetRelease(*release)
+	for _, pass := range pipeline.ModulePasses() {
+		codegen.AddPass(pass)
+	}
+	if err := codegen.GenerateCode(prgm); err != nil {
 		fmt.Fprintln(os.Stderr, "ERROR")
 		log.Fatalln(err)
 	}
 
 	fmt.Fprintln(os.Stderr, "OK")
+	if *printWarnings {
+		for _, warning := range warnings {
+			fmt.Fprintln(os.Stderr, "warning:", warning)
+		}
+	}
+	if *timePasses {
+		pipeline.WriteTimings(os.Stderr)
+	}
+}
+
+var levelUsage = map[string]string{
+	"O0": "Run no optimization passes (default)",
+	"O1": "Fold constants, remove dead code, allocate local objects on the " +
+		"stack, promote locals to registers and hoist loop invariants",
+	"O2": "Same as -O1, and inline small functions",
+	"Os": "Same as -O2, but only inline the smallest functions",
+}
+
+// isFlagSet reports whether the flag with the given name was set on the
+// command line.
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// generateIR returns the IR generated for prgm as it is, without running any
+// IR passes.
+func generateIR(prgm *tast.Prgm, release bool) (string, error) {
+	var sb strings.Builder
+	cg := codegen.NewCodeGenerator(&sb)
+	cg.SetRelease(release)
+	if err := cg.GenerateCode(prgm); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
 }
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// optLevels are the optimization levels every program in testdata is compiled
+// at; it must print the same output at each of them.
+var optLevels = []string{"-O0", "-O1", "-O2"}
+
+// buildCompiler builds jlc with the debug tag, so that the IR is verified
+// after every pass, and returns the path to the binary.
+func buildCompiler(t *testing.T) string {
+	t.Helper()
+	jlc := filepath.Join(t.TempDir(), "jlc")
+	out, err := exec.Command("go", "build", "-tags", "debug", "-o", jlc, ".").
+		CombinedOutput()
+	if err != nil {
+		t.Fatalf("building jlc: %v\n%s", err, out)
+	}
+	return jlc
+}
+
+// TestPrograms compiles every testdata/*.jl program that has a .out file,
+// links it with the runtime, runs it with lli and compares what it prints to
+// the .out file. A .flags file holds extra jlc flags, a .in file the input of
+// the program and a .c file the definitions of its extern functions.
+func TestPrograms(t *testing.T) {
+	for _, tool := range []string{"llvm-link", "lli", "cc"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s not found", tool)
+		}
+	}
+	jlc := buildCompiler(t)
+	runtime, err := filepath.Abs("../../stdlib/runtime.ll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	programs, err := filepath.Glob("testdata/*.jl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, program := range programs {
+		base := strings.TrimSuffix(program, ".jl")
+		want, err := os.ReadFile(base + ".out")
+		if err != nil {
+			continue
+		}
+		var flags []string
+		if data, err := os.ReadFile(base + ".flags"); err == nil {
+			flags = strings.Fields(string(data))
+		}
+		input, _ := os.ReadFile(base + ".in")
+		t.Run(filepath.Base(base), func(t *testing.T) {
+			dir := t.TempDir()
+			var extra []string
+			if _, err := os.Stat(base + ".c"); err == nil {
+				object := filepath.Join(dir, "extern.o")
+				run(t, "", "cc", "-c", "-fPIC", "-o", object, base+".c")
+				extra = append(extra, "--extra-object="+object)
+			}
+			for _, level := range optLevels {
+				t.Run(level, func(t *testing.T) {
+					ir := filepath.Join(dir, "out.ll")
+					bitcode := filepath.Join(dir, "out.bc")
+					args := append([]string{level, "-o", ir}, flags...)
+					args = append(args, filepath.Base(program))
+					stderr := run(t, "testdata", jlc, args...)
+					if !strings.HasPrefix(stderr, "OK") {
+						t.Fatalf("jlc did not print OK:\n%s", stderr)
+					}
+					run(t, "", "llvm-link", ir, runtime, "-o", bitcode)
+					cmd := exec.Command("lli", append(extra, bitcode)...)
+					cmd.Stdin = bytes.NewReader(input)
+					got, _ := cmd.CombinedOutput()
+					if !bytes.Equal(bytes.TrimSpace(got), bytes.TrimSpace(want)) {
+						t.Errorf("got output:\n%s\nwant:\n%s", got, want)
+					}
+				})
+			}
+		})
+	}
+}
+
+// TestBadPrograms checks that jlc rejects every program in testdata/bad.
+func TestBadPrograms(t *testing.T) {
+	jlc := buildCompiler(t)
+	programs, err := filepath.Glob("testdata/bad/*.jl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, program := range programs {
+		t.Run(filepath.Base(program), func(t *testing.T) {
+			ir := filepath.Join(t.TempDir(), "out.ll")
+			var stderr bytes.Buffer
+			cmd := exec.Command(jlc, "-o", ir, program)
+			cmd.Stderr = &stderr
+			if err := cmd.Run(); err == nil {
+				t.Fatalf("jlc accepted the program")
+			}
+			if !strings.HasPrefix(stderr.String(), "ERROR") {
+				t.Errorf("jlc did not print ERROR:\n%s", stderr.String())
+			}
+		})
+	}
+}
+
+// run runs name with args in dir, failing the test if it does not succeed,
+// and returns what it printed to standard error.
+func run(t *testing.T, dir, name string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("%s: %v\n%s", name, err, stderr.String())
+	}
+	return stderr.String()
+}
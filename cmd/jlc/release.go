@@ -0,0 +1,6 @@
+//go:build !debug
+
+package main
+
+// debugBuild is false unless jlc is built with -tags debug, see debug.go.
+const debugBuild = false
@@ -0,0 +1,18 @@
+int fact(int n) {
+  assert(n >= 0, "fact of a negative number");
+  if (n == 0) {
+    return 1;
+  }
+  return n * fact(n - 1);
+}
+
+int main() {
+  assert(true);
+  printInt(fact(5));
+  int x = 3;
+  assert(x == 3 && fact(3) == 6);
+  printString("asserts passed");
+  printInt(fact(x - 4));
+  printString("not reached");
+  return 0;
+}
@@ -0,0 +1,11 @@
+boolean check() {
+  printString("evaluated");
+  return false;
+}
+
+int main() {
+  assert(check(), "not evaluated in release mode");
+  assert(false);
+  printString("asserts compiled out");
+  return 0;
+}
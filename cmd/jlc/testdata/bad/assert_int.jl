@@ -0,0 +1,4 @@
+int main() {
+  assert(1);
+  return 0;
+}
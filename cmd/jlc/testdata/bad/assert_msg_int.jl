@@ -0,0 +1,4 @@
+int main() {
+  assert(true, 42);
+  return 0;
+}
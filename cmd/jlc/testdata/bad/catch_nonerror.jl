@@ -0,0 +1,13 @@
+struct Point {
+  int x;
+};
+typedef struct Point* P;
+
+int main() {
+  try {
+    printInt(1);
+  } catch (P p) {
+    printInt(2);
+  }
+  return 0;
+}
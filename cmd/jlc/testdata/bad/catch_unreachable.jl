@@ -0,0 +1,15 @@
+struct Sub extends Error {
+};
+typedef struct Error* Err;
+typedef struct Sub* SubP;
+
+int main() {
+  try {
+    printInt(1);
+  } catch (Err e) {
+    printInt(2);
+  } catch (SubP s) {
+    printInt(3);
+  }
+  return 0;
+}
@@ -0,0 +1,5 @@
+int main() {
+  if (true)
+    defer printInt(1);
+  return 0;
+}
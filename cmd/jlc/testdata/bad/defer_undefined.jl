@@ -0,0 +1,5 @@
+int main() {
+  defer printInt(x);
+  int x = 1;
+  return 0;
+}
@@ -0,0 +1,7 @@
+enum Color { Red, Green, Blue }
+
+int main() {
+  Color c = Color.Red;
+  if (c == 0) printInt(1);
+  return 0;
+}
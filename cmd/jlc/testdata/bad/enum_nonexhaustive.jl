@@ -0,0 +1,10 @@
+enum Color { Red, Green, Blue }
+
+int main() {
+  Color c = Color.Red;
+  switch (c) {
+    case Red: printInt(1);
+    case Green: printInt(2);
+  }
+  return 0;
+}
@@ -0,0 +1,7 @@
+struct Error {
+  string message;
+};
+
+int main() {
+  return 0;
+}
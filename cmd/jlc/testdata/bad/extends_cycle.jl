@@ -0,0 +1,10 @@
+struct A extends B {
+  int x;
+};
+struct B extends A {
+  int y;
+};
+
+int main() {
+  return 0;
+}
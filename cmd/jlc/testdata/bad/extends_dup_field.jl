@@ -0,0 +1,7 @@
+struct Sub extends Error {
+  string message;
+};
+
+int main() {
+  return 0;
+}
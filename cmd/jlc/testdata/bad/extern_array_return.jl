@@ -0,0 +1,2 @@
+extern int[] make(int n);
+int main() { return 0; }
@@ -0,0 +1,2 @@
+extern void printInt(int x);
+int main() { return 0; }
@@ -0,0 +1,2 @@
+extern int apply(fn(int) -> int g);
+int main() { return 0; }
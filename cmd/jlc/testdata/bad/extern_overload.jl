@@ -0,0 +1,3 @@
+extern int f(int x);
+int f(double x) { return 1; }
+int main() { return 0; }
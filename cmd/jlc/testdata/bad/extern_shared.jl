@@ -0,0 +1,8 @@
+import "mods/ext_user.jl";
+
+extern int abs(int n);
+
+int main() {
+  printInt(halfSpread(4) + abs(-1));
+  return 0;
+}
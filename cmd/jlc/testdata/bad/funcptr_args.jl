@@ -0,0 +1,6 @@
+int add(int a, int b) { return a + b; }
+int main() {
+  fn(int, int) -> int f = add;
+  printInt(f(2));
+  return 0;
+}
@@ -0,0 +1,6 @@
+int add(int a, int b) { return a + b; }
+int main() {
+  fn(int, int) -> int f = add;
+  if (f < add) printInt(1);
+  return 0;
+}
@@ -0,0 +1,2 @@
+T max<T>(T a, T b) { if (a > b) return a; return b; }
+int main() { printDouble(max(1, 2.0)); return 0; }
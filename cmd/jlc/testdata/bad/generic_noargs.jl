@@ -0,0 +1,2 @@
+struct Box<T> { T v; };
+int main() { Box* b; return 0; }
@@ -0,0 +1,7 @@
+import "../mods/reuse/inner.jl";
+import "mods/other.jl";
+
+int main() {
+  printInt(helper());
+  return 0;
+}
@@ -0,0 +1,2 @@
+import "import_cycle_b.jl";
+int main() { return 0; }
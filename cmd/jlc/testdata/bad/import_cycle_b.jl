@@ -0,0 +1 @@
+import "import_cycle_a.jl";
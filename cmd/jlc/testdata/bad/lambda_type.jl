@@ -0,0 +1,4 @@
+int main() {
+  fn(int) -> int f = (double x) -> x;
+  return 0;
+}
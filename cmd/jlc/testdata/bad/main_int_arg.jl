@@ -0,0 +1 @@
+int main(int argc) { return 0; }
@@ -0,0 +1 @@
+int main(string[] a, string[] b) { return 0; }
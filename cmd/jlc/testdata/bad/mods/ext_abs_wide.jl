@@ -0,0 +1,5 @@
+extern int abs(int n, int m);
+
+int spread(int a) {
+  return abs(a, a);
+}
@@ -0,0 +1,5 @@
+import "ext_abs_wide.jl";
+
+int halfSpread(int a) {
+  return spread(a) / 2;
+}
@@ -0,0 +1,3 @@
+int helper() {
+  return 2;
+}
@@ -0,0 +1,3 @@
+void g(int a, double b) {}
+void g(double a, int b) {}
+int main() { g(1, 1); return 0; }
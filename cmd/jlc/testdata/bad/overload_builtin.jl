@@ -0,0 +1,2 @@
+void printInt(double d) {}
+int main() { return 0; }
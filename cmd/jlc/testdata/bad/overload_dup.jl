@@ -0,0 +1,3 @@
+int g(int a) { return a; }
+double g(int b) { return 1.0; }
+int main() { return 0; }
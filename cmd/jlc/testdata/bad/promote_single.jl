@@ -0,0 +1,6 @@
+double half(double x) { return x / 2.0; }
+
+int main() {
+  printDouble(half(3));
+  return 0;
+}
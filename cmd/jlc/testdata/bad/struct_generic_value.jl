@@ -0,0 +1,6 @@
+struct Box<T> { T v; Box<T> b; };
+
+int main() {
+  Box<int> b;
+  return 0;
+}
@@ -0,0 +1,6 @@
+struct A { int v; B b; };
+struct B { A a; };
+
+int main() {
+  return 0;
+}
@@ -0,0 +1,5 @@
+struct N { int v; struct N n; };
+
+int main() {
+  return 0;
+}
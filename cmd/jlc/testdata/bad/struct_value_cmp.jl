@@ -0,0 +1,6 @@
+struct Point { int x; int y; };
+
+int main() {
+  Point p; Point q; if (p == q) printInt(1);
+  return 0;
+}
@@ -0,0 +1,6 @@
+struct Point { int x; int y; };
+
+int main() {
+  struct Foo f;
+  return 0;
+}
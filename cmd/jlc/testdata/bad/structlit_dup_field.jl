@@ -0,0 +1,6 @@
+struct Point { int x; int y; };
+
+int main() {
+  Point p = Point{x: 1, x: 2};
+  return 0;
+}
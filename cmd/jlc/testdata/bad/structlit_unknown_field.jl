@@ -0,0 +1,6 @@
+struct Point { int x; int y; };
+
+int main() {
+  Point p = Point{z: 1};
+  return 0;
+}
@@ -0,0 +1,4 @@
+int main() {
+  throw 1;
+  return 0;
+}
@@ -0,0 +1,4 @@
+int main() {
+  (int a, int b, int c) = (1, 2);
+  return 0;
+}
@@ -0,0 +1,4 @@
+int main() {
+  (int a, int b) = 3;
+  return 0;
+}
@@ -0,0 +1,7 @@
+(int, double) f() {
+  return (1, 2);
+}
+
+int main() {
+  return 0;
+}
@@ -0,0 +1,6 @@
+typedef B A;
+typedef A[] B;
+
+int main() {
+  return 0;
+}
@@ -0,0 +1,6 @@
+typedef double Meters;
+int main() {
+  Meters m = 1.0;
+  string s = m;
+  return 0;
+}
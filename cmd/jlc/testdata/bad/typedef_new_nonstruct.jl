@@ -0,0 +1,6 @@
+typedef double Meters;
+
+int main() {
+  Meters m = new Meters;
+  return 0;
+}
@@ -0,0 +1,6 @@
+typedef int Count;
+typedef double Count;
+
+int main() {
+  return 0;
+}
@@ -0,0 +1,5 @@
+typedef void Nothing;
+
+int main() {
+  return 0;
+}
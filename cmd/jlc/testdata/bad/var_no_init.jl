@@ -0,0 +1,4 @@
+int main() {
+  var x;
+  return 0;
+}
@@ -0,0 +1,5 @@
+int main() {
+  var x = 1;
+  x = 2.0;
+  return 0;
+}
@@ -0,0 +1,4 @@
+int main() {
+  var x = x;
+  return 0;
+}
@@ -0,0 +1,4 @@
+int main() {
+  var x = printInt(1);
+  return 0;
+}
@@ -0,0 +1,25 @@
+typedef struct Node_t *Node;
+struct Node_t { int val; Node next; };
+
+int fact(int n) { if (n <= 1) return 1; else return n * fact(n-1); }
+
+int main() {
+  printInt(fact(5));
+  double d = 2.5 * 2.0;
+  printDouble(d);
+  int[] a = new int[4];
+  int i = 0;
+  while (i < a.length) { a[i] = i*i; i++; }
+  for (int x : a) printInt(x);
+  Node n = new Node_t;
+  n->val = 7;
+  n->next = (Node) null;
+  printInt(n->val);
+  int[][] m = new int[2][3];
+  m[1][2] = 5;
+  printInt(m[1][2] + m[0].length);
+  printString("hello");
+  boolean b = true && !false || 1 > 2;
+  if (b) printInt(1); else printInt(0);
+  return 0;
+}
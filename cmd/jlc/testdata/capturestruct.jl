@@ -0,0 +1,19 @@
+struct Point {
+  int x;
+  int y;
+};
+
+fn(int) -> int capture() {
+  Point p = Point{x: 1, y: 2};
+  fn(int) -> int f = (int k) -> p.x * 10 + p.y + k;
+  p.x = 5;
+  printInt(f(3));
+  return f;
+}
+
+int main() {
+  fn(int) -> int g = capture();
+  int[] filler = new int[8];
+  printInt(g(4));
+  return 0;
+}
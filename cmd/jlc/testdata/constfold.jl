@@ -0,0 +1,45 @@
+int main() {
+  printInt(2 * 60 * 60);
+  printInt(2147483647 + 1);
+  printInt(-2147483647 - 2);
+  printInt(65536 * 65536 + 7);
+  printInt(17 / 5);
+  printInt(-17 / 5);
+  printInt(-17 % 5);
+  printDouble(1.5 * 4.0 - 0.5);
+  printDouble(-(2.0 / 8.0));
+  if (!true) {
+    printString("never");
+  }
+  if (3 < 4 && 2.5 >= 2.5 && (true != false)) {
+    printString("yes");
+  }
+  int seconds = 60;
+  int minutes = seconds * 60;
+  printInt(minutes * 24);
+  int counter = 1;
+  counter++;
+  printInt(counter + minutes);
+  int shadow = 5;
+  {
+    int shadow = 7;
+    shadow = shadow + 1;
+    printInt(shadow);
+  }
+  printInt(shadow);
+  double half = 1.0 / 2.0;
+  printDouble(half + half);
+  boolean flag = 1 == 1;
+  if (flag || false) {
+    printString("flag");
+  }
+  int x = 0;
+  int y = 5;
+  while (x < y) {
+    x = x + 2;
+  }
+  printInt(x);
+  var f = (int n) -> n * seconds;
+  printInt(f(2));
+  return 0;
+}
@@ -0,0 +1,44 @@
+int sign(int x) {
+  if (x < 0) {
+    return -1;
+    printString("after return");
+  } else if (x == 0) {
+    return 0;
+  }
+  return 1;
+}
+
+void loud(int n) {
+  int i = 0;
+  while (false) {
+    printString("never loops");
+  }
+  while (i < n) {
+    printInt(i);
+    i++;
+  }
+}
+
+boolean called() {
+  printString("called");
+  return true;
+}
+
+int main() {
+  boolean debug = false;
+  if (debug) {
+    printString("debugging");
+  }
+  if (!debug) {
+    int shown = 3;
+    printInt(shown);
+  } else {
+    printString("hidden");
+  }
+  int shown = 4;
+  printInt(shown);
+  printInt(sign(-5) + sign(0) * 10 + sign(8) * 100);
+  loud(2);
+  return 0;
+  printString("after main");
+}
@@ -0,0 +1,56 @@
+void say(string s) {
+  printString(s);
+}
+
+int early(int n) {
+  defer say("early: outer cleanup");
+  int i = 0;
+  while (i < n) {
+    defer printInt(i);
+    if (i == 2) {
+      defer say("early: returning");
+      return i * 10;
+    }
+    i++;
+  }
+  say("early: loop done");
+  return -1;
+}
+
+void nested() {
+  defer say("nested: first deferred, runs last");
+  defer say("nested: second deferred, runs first");
+  {
+    defer say("nested: inner block exit");
+    say("nested: inner block");
+  }
+  say("nested: body done");
+}
+
+int counter(int x) {
+  int count = x;
+  defer say("counter: deferred after return value");
+  defer count++;
+  return count;
+}
+
+int main() {
+  printInt(early(5));
+  printInt(early(1));
+  nested();
+  printInt(counter(4));
+  switch (3) {
+    case 3:
+      defer say("case cleanup");
+      say("in case");
+  }
+  try {
+    defer say("try cleanup");
+    say("in try");
+  } catch (Error* e) {
+    say("not reached");
+  }
+  fn() -> void f = () -> say("lambda");
+  defer f();
+  return 0;
+}
@@ -0,0 +1,65 @@
+typedef struct Oops* OopsP;
+struct Oops extends Error {
+  int code;
+};
+
+void thrower(int code) {
+  OopsP e = new Oops;
+  e->code = code;
+  throw e;
+}
+
+void mid() {
+  defer printString("mid defer");
+  thrower(7);
+  printString("not reached");
+}
+
+int outer(int k) {
+  int n = k;
+  defer printInt(n);
+  {
+    defer printString("inner block");
+    if (k > 0) {
+      mid();
+    }
+    n = n + 1;
+  }
+  defer printString("late defer");
+  n = n + 10;
+  return n;
+}
+
+void safe() {
+  defer printString("safe defer");
+  printString("safe body");
+}
+
+int main() {
+  try {
+    mid();
+  } catch (OopsP e) {
+    printInt(e->code);
+  }
+  try {
+    printInt(outer(0));
+    printInt(outer(1));
+  } catch (OopsP e) {
+    printInt(e->code + 100);
+  }
+  safe();
+  int i = 0;
+  while (i < 3) {
+    try {
+      defer printInt(i);
+      if (i == 1) {
+        thrower(i);
+      }
+      mid();
+    } catch (OopsP e) {
+      printString("caught");
+    }
+    i++;
+  }
+  return 0;
+}
@@ -0,0 +1,54 @@
+struct Oops extends Error { int code; };
+typedef struct Oops* OopsP;
+typedef struct Error* Err;
+
+void scoped() {
+  int x = 1;
+  defer printInt(x);
+  {
+    int x = 2;
+    return;
+  }
+}
+
+void shadowLater() {
+  int x = 10;
+  {
+    defer printInt(x);
+    int x = 20;
+    printInt(x);
+  }
+}
+
+void thrower(int n) {
+  defer printString("thrower done");
+  {
+    defer printInt(n);
+    OopsP o = new Oops;
+      throw o;
+  }
+}
+
+int main() {
+  scoped();
+  shadowLater();
+  try {
+    defer printString("try done");
+    thrower(7);
+  } catch (OopsP e) {
+    printString("caught");
+  }
+  try {
+    defer printString("outer");
+    try {
+      defer printString("inner");
+      OopsP o = new Oops;
+      throw o;
+    } catch (OopsP e) {
+      printString("caught inner");
+    }
+  } catch (Err e) {
+    printString("wrong");
+  }
+  return 0;
+}
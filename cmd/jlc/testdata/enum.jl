@@ -0,0 +1,56 @@
+enum Color { Red, Green, Blue }
+
+enum Dir { North, East, South, West };
+
+int score(Color c) {
+  switch (c) {
+    case Red: return 1;
+    case Green, Color.Blue:
+      int x = 10;
+      return x;
+  }
+}
+
+Dir turn(Dir d) {
+  switch (d) {
+    case North: return Dir.East;
+    case East: return Dir.South;
+    case South: return Dir.West;
+    default: return Dir.North;
+  }
+}
+
+string kind(int n) {
+  string s = "other";
+  switch (n) {
+    case 0: s = "zero";
+    case 1, 2, 3: s = "small";
+    case -1: s = "minus one";
+  }
+  return s;
+}
+
+int main() {
+  Color c = Color.Green;
+  printString(c.name);
+  printInt(score(c));
+  printInt(score(Color.Red));
+  if (c == Color.Green) printString("green");
+  if (c != Color.Blue) printString("not blue");
+  Dir d = Dir.North;
+  int i = 0;
+  while (i < 5) {
+    printString(d.name);
+    d = turn(d);
+    i++;
+  }
+  printString(kind(0));
+  printString(kind(2));
+  printString(kind(-1));
+  printString(kind(7));
+  Color[] cs = new Color[2];
+  cs[1] = Color.Blue;
+  printString(cs[0].name);
+  printString(cs[1].name);
+  return 0;
+}
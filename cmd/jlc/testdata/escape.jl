@@ -0,0 +1,126 @@
+typedef struct Point_t *Point;
+
+struct Point_t {
+    int x;
+    int y;
+};
+
+typedef struct Node_t *Node;
+
+struct Node_t {
+    int val;
+    Node next;
+};
+
+struct Pair {
+    int a;
+    int b;
+};
+
+typedef struct Box_t *Box;
+
+struct Box_t {
+    Pair pair;
+};
+
+int dist(int x1, int y1, int x2, int y2) {
+    Point d = new Point_t;
+    d->x = x2 - x1;
+    d->y = y2 - y1;
+    if (d->x < 0) {
+        d->x = -d->x;
+    }
+    if (d->y < 0) {
+        d->y = -d->y;
+    }
+    return d->x + d->y;
+}
+
+Point makePoint(int x, int y) {
+    Point p = new Point_t;
+    p->x = x;
+    p->y = y;
+    return p;
+}
+
+Node push(Node list, int val) {
+    Node n = new Node_t;
+    n->val = val;
+    n->next = list;
+    return n;
+}
+
+int sumDigits(int n) {
+    int[] digits = new int[10];
+    int i = 0;
+    while (n > 0) {
+        digits[i] = n % 10;
+        n = n / 10;
+        i++;
+    }
+    int sum = 0;
+    for (int d : digits) {
+        sum = sum + d;
+    }
+    return sum + digits.length;
+}
+
+int loopAllocs(int n) {
+    int total = 0;
+    int i = 0;
+    while (i < n) {
+        Point p = new Point_t;
+        total = total + p->x;
+        p->x = i;
+        total = total + p->x;
+        int[] xs = new int[3];
+        total = total + xs[1];
+        xs[1] = 5;
+        i++;
+    }
+    return total;
+}
+
+int stored(Node list) {
+    Point p = new Point_t;
+    p->x = 4;
+    Point q = p;
+    return q->x + list->val;
+}
+
+int boxed() {
+    Box b = new Box_t;
+    b->pair.a = 3;
+    Pair copy = b->pair;
+    copy.a = 9;
+    return b->pair.a + copy.a;
+}
+
+int countDown(int n, int acc) {
+    int[] xs = new int[3];
+    xs[0] = n;
+    if (n == 0) {
+        return acc + xs.length;
+    }
+    return countDown(n - 1, acc + xs.length + xs[0]);
+}
+
+int main() {
+    printInt(dist(1, 2, 4, -2));
+    Point p = makePoint(3, 4);
+    printInt(p->x * p->y);
+    Node list = push(push((Node) null, 1), 2);
+    printInt(list->val + list->next->val);
+    printInt(sumDigits(98765));
+    printInt(loopAllocs(100000));
+    printInt(stored(list));
+    printInt(boxed());
+    printInt(countDown(10, 0));
+    Point same = new Point_t;
+    if (same == p) {
+        printString("same");
+    } else {
+        printString("different");
+    }
+    return 0;
+}
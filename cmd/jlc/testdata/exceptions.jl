@@ -0,0 +1,113 @@
+typedef struct Error* Err;
+
+struct NotFound extends Error {
+  int key;
+};
+typedef struct NotFound* NotFoundP;
+
+struct Missing extends NotFound {
+  string where;
+};
+typedef struct Missing* MissingP;
+
+struct Invalid extends Error {
+};
+typedef struct Invalid* InvalidP;
+
+int lookup(int key) {
+  if (key < 0) {
+    InvalidP e = new Invalid;
+    e->message = "negative key";
+    throw e;
+  }
+  if (key > 10) {
+    MissingP m = new Missing;
+    m->message = "missing key";
+    m->key = key;
+    m->where = "table";
+    throw m;
+  }
+  if (key > 5) {
+    NotFoundP n = new NotFound;
+    n->message = "key not found";
+    n->key = key;
+    throw n;
+  }
+  return key * 2;
+}
+
+// unwinds through deeper frames
+int deep(int n, int key) {
+  if (n == 0) {
+    return lookup(key);
+  }
+  return deep(n - 1, key) + 1;
+}
+
+int tryLookup(int key) {
+  try {
+    return lookup(key);
+  } catch (NotFoundP e) {
+    printString(e->message);
+    return -e->key;
+  }
+}
+
+void rethrow() {
+  try {
+    lookup(-1);
+  } catch (Err e) {
+    printString("rethrowing");
+    throw e;
+  }
+}
+
+int main() {
+  printInt(tryLookup(3));
+  printInt(tryLookup(7));
+  printInt(tryLookup(12));
+
+  try {
+    printInt(deep(10, 2));
+    printInt(deep(10, 20));
+    printString("not reached");
+  } catch (MissingP m) {
+    printString(m->where);
+    printInt(m->key);
+  } catch (Err e) {
+    printString("not reached");
+  }
+
+  int count = 0;
+  while (count < 3) {
+    try {
+      count++;
+      tryLookup(-count);
+    } catch (InvalidP e) {
+      printString(e->message);
+    }
+  }
+  printInt(count);
+
+  try {
+    try {
+      rethrow();
+    } catch (NotFoundP e) {
+      printString("not reached");
+    }
+  } catch (InvalidP e) {
+    printString("caught rethrown invalid");
+  }
+
+  try {
+    Err e = new Error;
+    e->message = "plain error";
+    throw e;
+  } catch (Err e) {
+    printString(e->message);
+  }
+
+  lookup(100);
+  printString("not reached");
+  return 0;
+}
@@ -0,0 +1,27 @@
+#include <stdint.h>
+
+int32_t sum_ints(int32_t *xs, int32_t n) {
+  int32_t s = 0;
+  for (int32_t i = 0; i < n; i++) s += xs[i];
+  return s;
+}
+
+void scale(double *xs, int32_t n, double k) {
+  for (int32_t i = 0; i < n; i++) xs[i] *= k;
+}
+
+int is_even(int32_t x) { return x % 2 == 0 ? 7 : 0; }
+
+int32_t negate_if(int flag, int32_t x) { return flag ? -x : x; }
+
+struct point { int32_t x; int32_t y; };
+
+int32_t manhattan(struct point *p) { return p->x + p->y; }
+
+const char *greeting(void) { return "hello from C"; }
+
+int32_t count_chars(const char **strs, int32_t n) {
+  int32_t c = 0;
+  for (int32_t i = 0; i < n; i++) for (const char *s = strs[i]; *s; s++) c++;
+  return c;
+}
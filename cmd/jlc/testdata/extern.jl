@@ -0,0 +1,46 @@
+extern double sqrt(double x);
+extern int atoi(string s);
+extern int sum_ints(int[] xs, int n);
+extern void scale(double[] xs, int n, double k);
+extern boolean is_even(int x);
+extern int negate_if(boolean flag, int x);
+extern string greeting();
+extern int count_chars(string[] strs, int n);
+
+struct Point {
+  int x;
+  int y;
+};
+
+typedef struct Point* P;
+
+extern int manhattan(P p);
+
+int main() {
+  printDouble(sqrt(16.0));
+  printInt(atoi("123") + 1);
+  int[] xs = new int[4];
+  for (int x : xs) {}
+  xs[0] = 1; xs[1] = 2; xs[2] = 3; xs[3] = 4;
+  printInt(sum_ints(xs, xs.length));
+  double[] ds = new double[2];
+  ds[0] = 1.5; ds[1] = 2.0;
+  scale(ds, 2, 2.0);
+  printDouble(ds[0] + ds[1]);
+  if (is_even(4)) printString("4 even");
+  if (!is_even(3)) printString("3 odd");
+  printInt(negate_if(true, 5));
+  printInt(negate_if(false, 5));
+  P p = new Point;
+  p->x = 3; p->y = 4;
+  printInt(manhattan(p));
+  printString(greeting());
+  string[] ss = new string[2];
+  ss[0] = "ab"; ss[1] = "cde";
+  printInt(count_chars(ss, 2));
+  fn(double) -> double f = sqrt;
+  printDouble(f(9.0));
+  fn(int) -> boolean g = is_even;
+  if (g(10)) printString("10 even");
+  return 0;
+}
@@ -0,0 +1,8 @@
+import "mods/ext_abs.jl";
+
+extern int abs(int value);
+
+int main() {
+  printInt(distance(3, 10) + abs(-5));
+  return 0;
+}
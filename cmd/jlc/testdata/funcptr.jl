@@ -0,0 +1,24 @@
+int add(int a, int b) { return a + b; }
+int mul(int a, int b) { return a * b; }
+
+int apply(fn(int, int) -> int f, int x, int y) {
+  return f(x, y);
+}
+
+fn(int, int) -> int pick(boolean m) {
+  if (m) return mul;
+  return add;
+}
+
+int main() {
+  fn(int, int) -> int f = add;
+  printInt(f(2, 3));
+  f = mul;
+  printInt(apply(f, 4, 5));
+  printInt(pick(true)(6, 7));
+  if (f == mul) printString("eq");
+  if (f != add) printString("ne");
+  fn(int) -> void p = printInt;
+  p(42);
+  return 0;
+}
@@ -0,0 +1,59 @@
+struct List<T> {
+  T val;
+  List<T>* next;
+};
+
+struct Pair<A, B> {
+  A fst;
+  B snd;
+};
+
+T max<T>(T a, T b) {
+  if (a > b) return a;
+  return b;
+}
+
+List<T>* cons<T>(T x, List<T>* xs) {
+  List<T>* l = new List<T>;
+  l->val = x;
+  l->next = xs;
+  return l;
+}
+
+int length<T>(List<T>* xs) {
+  int n = 0;
+  while (xs != (List<T>*) null) {
+    n++;
+    xs = xs->next;
+  }
+  return n;
+}
+
+B second<A, B>(Pair<A, B>* p) { return p->snd; }
+
+T[] fill<T>(T x, int n) {
+  T[] xs = new T[n];
+  int i = 0;
+  while (i < n) { xs[i] = x; i++; }
+  return xs;
+}
+
+typedef struct List<double> * DoubleList;
+
+int main() {
+  printInt(max(3, 7));
+  printDouble(max(2.5, 1.0));
+  List<int>* xs = cons(1, cons(2, cons(3, (List<int>*) null)));
+  printInt(length(xs));
+  printInt(xs->next->val);
+  DoubleList ds = cons(1.5, (DoubleList) null);
+  printDouble(ds->val);
+  printInt(length(ds));
+  Pair<int, string>* p = new Pair<int, string>;
+  p->fst = 4;
+  p->snd = "snd";
+  printString(second(p));
+  int[] fs = fill(9, 2);
+  printInt(fs[1]);
+  return 0;
+}
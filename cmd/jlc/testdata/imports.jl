@@ -0,0 +1,6 @@
+import "mods/main_lib.jl";
+
+int main() {
+  printInt(twice(21));
+  return 0;
+}
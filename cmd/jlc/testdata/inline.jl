@@ -0,0 +1,78 @@
+typedef struct Point_t *Point;
+
+struct Point_t {
+    int x;
+    int y;
+};
+
+int getX(Point p) {
+    return p->x;
+}
+
+int max(int a, int b) {
+    if (a > b) {
+        return a;
+    }
+    return b;
+}
+
+int abs(int a) {
+    if (a < 0) {
+        return -a;
+    }
+    return a;
+}
+
+inline int clamp(int v, int lo, int hi) {
+    return max(lo, -max(-v, -hi));
+}
+
+noinline int square(int a) {
+    return a * a;
+}
+
+int fact(int n) {
+    if (n <= 1) {
+        return 1;
+    }
+    return n * fact(n - 1);
+}
+
+boolean isEven(int n) {
+    if (n == 0) {
+        return true;
+    }
+    return isOdd(n - 1);
+}
+
+boolean isOdd(int n) {
+    if (n == 0) {
+        return false;
+    }
+    return isEven(n - 1);
+}
+
+void report(int v) {
+    printString("value");
+    printInt(v);
+}
+
+int main() {
+    Point p = new Point_t;
+    p->x = -7;
+    p->y = 3;
+    int i = 0;
+    int sum = 0;
+    while (i < 10) {
+        sum = sum + max(abs(getX(p) + i), square(i));
+        i++;
+    }
+    printInt(sum);
+    printInt(clamp(42, 0, 10));
+    printInt(clamp(-5, 0, 10));
+    printInt(fact(6));
+    if (isEven(10) && isOdd(7)) {
+        report(max(p->y, getX(p)));
+    }
+    return 0;
+}
@@ -0,0 +1,39 @@
+int apply(fn(int) -> int f, int x) { return f(x); }
+
+fn(int) -> int adder(int k) {
+  return (int x) -> x + k;
+}
+
+fn(int) -> fn(int) -> int curry() {
+  return (int a) -> (int b) -> a * b;
+}
+
+int[] map(fn(int) -> int f, int[] xs) {
+  int[] ys = new int[xs.length];
+  int i = 0;
+  while (i < xs.length) {
+    ys[i] = f(xs[i]);
+    i++;
+  }
+  return ys;
+}
+
+int main() {
+  int k = 10;
+  fn(int) -> int addk = (int x) -> x + k;
+  k = 100;
+  printInt(addk(1));
+  printInt(apply((int x) -> x * x, 7));
+  fn(int) -> int add5 = adder(5);
+  printInt(add5(3));
+  printInt(curry()(6)(7));
+  int[] xs = new int[3];
+  xs[0] = 1; xs[1] = 2; xs[2] = 3;
+  for (int y : map(adder(k), xs)) printInt(y);
+  fn() -> void hello = () -> printString("hi");
+  hello();
+  double scale = 2.0;
+  fn(double) -> double f = (double d) -> d * scale;
+  printDouble(f(1.5));
+  return 0;
+}
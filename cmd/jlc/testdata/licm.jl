@@ -0,0 +1,85 @@
+int sum(int[] xs) {
+    int s = 0;
+    int i = 0;
+    while (i < xs.length) {
+        s = s + xs[i];
+        i++;
+    }
+    return s;
+}
+
+int sumMatrix(int[][] m, int scale) {
+    int s = 0;
+    int i = 0;
+    while (i < m.length) {
+        int j = 0;
+        while (j < m[i].length) {
+            s = s + m[i][j] * (scale * 2 + 1);
+            j++;
+        }
+        i++;
+    }
+    return s;
+}
+
+int countEmpty(int[] xs, int n) {
+    int count = 0;
+    int i = 0;
+    while (i < n) {
+        if (n > 100) {
+            count = count + xs.length;
+        }
+        count++;
+        i++;
+    }
+    return count;
+}
+
+double average(double[] xs) {
+    double total = 0.0;
+    for (double x : xs) {
+        total = total + x / 2.0;
+    }
+    return total * 2.0 / itod(xs.length);
+}
+
+double itod(int n) {
+    double d = 0.0;
+    int i = 0;
+    while (i < n) {
+        d = d + 1.0;
+        i++;
+    }
+    return d;
+}
+
+int main() {
+    int[] xs = new int[10];
+    int i = 0;
+    while (i < xs.length) {
+        xs[i] = i * i;
+        i++;
+    }
+    printInt(sum(xs));
+
+    int[][] m = new int[3][4];
+    i = 0;
+    while (i < m.length) {
+        int j = 0;
+        while (j < m[i].length) {
+            m[i][j] = i + j;
+            j++;
+        }
+        i++;
+    }
+    printInt(sumMatrix(m, 1));
+
+    int[] none;
+    printInt(countEmpty(none, 5));
+
+    double[] ds = new double[4];
+    ds[0] = 1.0;
+    ds[3] = 3.0;
+    printDouble(average(ds));
+    return 0;
+}
@@ -0,0 +1,16 @@
+struct Node { int v; Node* next; };
+struct Box<T> { T val; };
+
+int main() {
+  Node* p = new Node;
+  p->v = 4;
+  Node* q = p;
+  Box<Node> b;
+  Box<int>* bp = new Box<int>;
+  bp->val = 3;
+  int x = 2;
+  int y = 3;
+  printInt(x * y);
+  printInt(q->v + bp->val);
+  return 0;
+}
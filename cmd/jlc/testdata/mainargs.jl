@@ -0,0 +1,6 @@
+int main(string[] args) {
+  printInt(args.length);
+  for (string a : args) printString(a);
+  if (args.length > 0) return main(new string[0]) + 1;
+  return 0;
+}
@@ -0,0 +1,84 @@
+int fib(int n) {
+  int a = 0, b = 1;
+  while (n > 0) {
+    int t = a + b;
+    a = b;
+    b = t;
+    n--;
+  }
+  return a;
+}
+
+double harmonic(int n) {
+  double sum = 0.0;
+  int i = 1;
+  while (i <= n) {
+    sum = sum + 1.0 / itod(i);
+    i++;
+  }
+  return sum;
+}
+
+int collatz(int n) {
+  int steps = 0;
+  while (n != 1) {
+    if (n % 2 == 0) {
+      n = n / 2;
+    } else {
+      n = 3 * n + 1;
+    }
+    steps++;
+  }
+  return steps;
+}
+
+boolean between(int x, int lo, int hi) {
+  boolean ok = x >= lo && x <= hi;
+  return ok;
+}
+
+int early(int x) {
+  if (x > 10) {
+    return 1;
+  }
+  int y = x;
+  if (y < 0) {
+    y = -y;
+  }
+  return y;
+}
+
+int main() {
+  printInt(fib(20));
+  printDouble(harmonic(4));
+  printInt(collatz(27));
+  if (between(5, 1, 10)) {
+    printString("in");
+  }
+  if (!between(11, 1, 10)) {
+    printString("out");
+  }
+  printInt(early(50));
+  printInt(early(-7));
+  int[] arr = new int[5];
+  int j = 0;
+  for (int v : arr) {
+    arr[j] = j * j;
+    j++;
+  }
+  int total = 0;
+  for (int v : arr) {
+    total = total + v;
+  }
+  printInt(total);
+  return 0;
+}
+
+double itod(int i) {
+  double d = 0.0;
+  while (i > 0) {
+    d = d + 1.0;
+    i--;
+  }
+  return d;
+}
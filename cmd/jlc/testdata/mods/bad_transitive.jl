@@ -0,0 +1,6 @@
+import "lib/geometry.jl";
+
+int main() {
+  printInt(width(new Rect));
+  return 0;
+}
@@ -0,0 +1,2 @@
+import "cyc_b.jl";
+int main() { return 0; }
@@ -0,0 +1,2 @@
+import "cyc_a.jl";
+int f() { return 1; }
@@ -0,0 +1,3 @@
+import "inc/shapes.jl";
+int width(int x) { return x; }
+int main() { return 0; }
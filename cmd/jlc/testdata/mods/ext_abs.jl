@@ -0,0 +1,5 @@
+extern int abs(int n);
+
+int distance(int a, int b) {
+  return abs(a - b);
+}
@@ -0,0 +1,10 @@
+struct Rect {
+  int w;
+  int h;
+};
+
+typedef struct Rect* R;
+
+int width(R r) {
+  return r->w;
+}
@@ -0,0 +1,29 @@
+import "shapes.jl";
+
+struct Vec {
+  int x;
+  int y;
+};
+
+typedef struct Vec* V;
+
+enum Axis { X, Y }
+
+int dot(V a, V b) {
+  return a->x * b->x + a->y * b->y;
+}
+
+int area(R r) {
+  return width(r) * r->h;
+}
+
+T pick<T>(boolean first, T a, T b) {
+  if (first) return a;
+  return b;
+}
+
+int unitWidth<T>(T tag) {
+  R r = new Rect;
+  r->w = 9;
+  return width(r);
+}
@@ -0,0 +1,17 @@
+import "lib/geometry.jl";
+import "shapes.jl";
+
+int main() {
+  V a = new Vec;
+  a->x = 2; a->y = 3;
+  V b = new Vec;
+  b->x = 4; b->y = 5;
+  printInt(dot(a, b));
+  R r = new Rect;
+  r->w = 6; r->h = 7;
+  printInt(area(r));
+  printInt(pick(false, 1, 2));
+  Axis x = Axis.Y;
+  printString(x.name);
+  return 0;
+}
@@ -0,0 +1,7 @@
+import "lib/geometry.jl";
+
+int main() {
+  printInt(unitWidth(true));
+  printInt(pick(true, 5, 6));
+  return 0;
+}
@@ -0,0 +1,11 @@
+int helper() {
+  return 10;
+}
+
+T first<T>(T a, T b) {
+  return b;
+}
+
+int inner() {
+  return helper() + first(1, 2);
+}
@@ -0,0 +1,5 @@
+import "inner.jl";
+
+int outer() {
+  return inner() * 100;
+}
@@ -0,0 +1,21 @@
+typedef struct Holder_t* Holder;
+struct Holder_t { int[] arr; int[][] grid; };
+
+int main() {
+  int[][] m = new int[3][2];
+  m[1] = new int[5];
+  m[1][4] = 9;
+  printInt(m[1].length + m[1][4]);
+  Holder h = new Holder_t;
+  h->arr = new int[2];
+  h->arr[1] = 3;
+  h->grid = new int[2][2];
+  h->grid[0] = new int[4];
+  h->grid[0][3] = 6;
+  printInt(h->arr[1] + h->grid[0].length + h->grid[0][3]);
+  int[][][] cube = new int[2][2][2];
+  cube[1][0] = new int[7];
+  cube[1] = new int[3][1];
+  printInt(cube[1].length + cube[0][1].length);
+  return 0;
+}
@@ -0,0 +1,22 @@
+void show(int x) { printString("int"); printInt(x); }
+void show(double x) { printString("double"); printDouble(x); }
+void show(string s) { printString(s); }
+int add(int a, int b) { return a + b; }
+double add(double a, double b) { return a + b; }
+int f() { return 1; }
+int f(int x) { return x + 1; }
+double mix(double a, int b) { return a; }
+double mix(double a, double b) { return a + b; }
+
+int main() {
+  show(1);
+  show(2.5);
+  show("s");
+  printInt(add(1, 2));
+  printDouble(add(1.5, 2.0));
+  printDouble(add(1, 2.0));
+  printInt(f() + f(5));
+  printDouble(mix(1.0, 2));
+  printDouble(mix(3, 2));
+  return 0;
+}
@@ -0,0 +1,33 @@
+struct Point {
+  int x;
+  int y;
+};
+
+Point make(int k) {
+  Point p = Point{x: k, y: k * 2};
+  return p;
+}
+
+Point shifted(Point p, int d) {
+  p.x = p.x + d;
+  return p;
+}
+
+int main() {
+  Point a = make(3);
+  Point b = shifted(a, 10);
+  printInt(a.x + a.y);
+  printInt(b.x);
+  fn(int) -> Point f = (int k) -> make(k + 1);
+  Point c = f(4);
+  c.y = 0;
+  printInt(c.x + c.y + make(1).y);
+  int total = 0;
+  int i = 0;
+  while (i < 1000) {
+    total = total + make(i).x - shifted(make(i), 1).x;
+    i++;
+  }
+  printInt(total);
+  return 0;
+}
@@ -0,0 +1,16 @@
+import "mods/reuse/outer.jl";
+
+int helper() {
+  return 1;
+}
+
+T first<T>(T a, T b) {
+  return a;
+}
+
+int main() {
+  printInt(helper());
+  printInt(first(3, 4));
+  printInt(outer());
+  return 0;
+}
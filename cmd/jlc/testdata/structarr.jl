@@ -0,0 +1,30 @@
+struct Point { int x; int y; };
+
+struct Line { Point a; Point b; };
+
+int main() {
+  Point[] ps = new struct Point[3];
+  ps[0].x = 1;
+  ps[1].x = 5;
+  ps[2].y = 7;
+  printInt(ps[1].x);
+  int sum = 0;
+  for (Point p : ps) {
+    p.x = p.x + 100;
+    sum = sum + p.x + p.y;
+  }
+  printInt(sum);
+  printInt(ps[0].x);
+  Point q = ps[2];
+  q.y = 1;
+  printInt(ps[2].y);
+  ps[0] = q;
+  printInt(ps[0].y);
+  Line[] ls = new Line[2];
+  ls[1].b.y = 9;
+  printInt(ls[1].b.y);
+  Point[][] grid = new Point[2][2];
+  grid[1][1].x = 4;
+  printInt(grid[1][1].x + grid[0][1].x);
+  return 0;
+}
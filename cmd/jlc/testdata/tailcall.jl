@@ -0,0 +1,77 @@
+typedef struct Node_t *Node;
+
+struct Node_t {
+    int val;
+    Node next;
+};
+
+int length(Node n, int acc) {
+    if (n == (Node) null) {
+        return acc;
+    }
+    return length(n->next, acc + 1);
+}
+
+int sumTo(int n, int acc) {
+    if (n == 0) {
+        return acc;
+    }
+    int next = acc + n;
+    return sumTo(n - 1, next);
+}
+
+int gcd(int a, int b) {
+    if (b == 0) {
+        return a;
+    }
+    return gcd(b, a % b);
+}
+
+boolean contains(int[] xs, int x, int i) {
+    if (i == xs.length) {
+        return false;
+    } else if (xs[i] == x) {
+        return true;
+    }
+    return contains(xs, x, i + 1);
+}
+
+Node build(int n, Node list) {
+    if (n == 0) {
+        return list;
+    }
+    Node node = new Node_t;
+    node->val = n;
+    node->next = list;
+    return build(n - 1, node);
+}
+
+int unwind(int n) {
+    defer printInt(n);
+    if (n == 0) {
+        return 0;
+    }
+    return unwind(n - 1);
+}
+
+int fact(int n) {
+    if (n <= 1) {
+        return 1;
+    }
+    return n * fact(n - 1);
+}
+
+int main() {
+    Node list = build(1000000, (Node) null);
+    printInt(length(list, 0));
+    printInt(sumTo(1000000, 0));
+    printInt(gcd(1071, 462));
+    int[] xs = new int[5];
+    xs[3] = 9;
+    if (contains(xs, 9, 0) && !contains(xs, 8, 0)) {
+        printString("found");
+    }
+    unwind(2);
+    printInt(fact(10));
+    return 0;
+}
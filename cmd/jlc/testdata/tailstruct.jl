@@ -0,0 +1,28 @@
+struct P {
+  int v;
+};
+
+int g(P a, P b, int n) {
+  if (n == 0) {
+    return a.v;
+  }
+  return g(b, a, n - 1);
+}
+
+int h(P a, int n) {
+  if (n == 0) {
+    return a.v;
+  }
+  P next = P{v: a.v + n};
+  return h(next, n - 1);
+}
+
+int main() {
+  P a = P{v: 1};
+  P b = P{v: 2};
+  printInt(g(a, b, 3));
+  printInt(g(a, b, 4));
+  printInt(h(a, 4));
+  printInt(a.v);
+  return 0;
+}
@@ -0,0 +1,24 @@
+struct Odd extends Error {
+};
+typedef struct Odd* OddP;
+
+void check(int i) {
+  if (i % 2 == 1) {
+    throw new Odd;
+  }
+}
+
+int main() {
+  int i = 0;
+  int caught = 0;
+  while (i < 1000000) {
+    try {
+      check(i);
+    } catch (OddP e) {
+      caught++;
+    }
+    i++;
+  }
+  printInt(caught);
+  return 0;
+}
@@ -0,0 +1,66 @@
+(int, int) divmod(int a, int b) {
+  return (a / b, a % b);
+}
+
+(string, double, boolean) describe(int n) {
+  return ("value", 1.5, n > 2);
+}
+
+typedef struct Point* P;
+struct Point {
+  int x;
+  int y;
+};
+
+(P, int[]) make(int n) {
+  P p = new Point;
+  p->x = n;
+  p->y = n * n;
+  int[] a = new int[n];
+  return (p, a);
+}
+
+(int, int) swap((int, int) pair) {
+  (int a, int b) = pair;
+  return (b, a);
+}
+
+((int, int), double) nested() {
+  return (divmod(17, 5), 0.5);
+}
+
+int main() {
+  (int q, int r) = divmod(17, 5);
+  printInt(q);
+  printInt(r);
+
+  (string s, double d, boolean big) = describe(3);
+  printString(s);
+  printDouble(d);
+  if (big) {
+    printString("big");
+  }
+
+  (int, int) pair = swap(divmod(7, 2));
+  (int first, int second) = pair;
+  printInt(first);
+  printInt(second);
+
+  pair = (10, 20);
+  (int x, int y) = pair;
+  printInt(x + y);
+
+  (P p, int[] arr) = make(4);
+  printInt(p->y);
+  printInt(arr.length);
+
+  ((int, int) inner, double half) = nested();
+  (int iq, int ir) = inner;
+  printInt(iq * 10 + ir);
+  printDouble(half);
+
+  (int, double) zero;
+  (int z, double zd) = zero;
+  printInt(z);
+  return 0;
+}
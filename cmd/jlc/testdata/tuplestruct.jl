@@ -0,0 +1,26 @@
+struct Point {
+  int x;
+  int y;
+};
+
+(Point, int) pair(int k) {
+  Point p = Point{x: k, y: k + 1};
+  (Point, int) t = (p, k * 2);
+  p.x = 99;
+  return t;
+}
+
+int main() {
+  Point q = Point{x: 1, y: 2};
+  (Point, int) t = (q, 7);
+  q.x = 10;
+  (Point p, int k) = t;
+  p.x = 42;
+  (Point p2, int k2) = t;
+  printInt(p2.x);
+  printInt(q.x + k2);
+  (Point r, int m) = pair(3);
+  int[] filler = new int[8];
+  printInt(r.x + r.y + m);
+  return 0;
+}
@@ -0,0 +1,67 @@
+typedef Row[] Matrix;
+typedef int[] Row;
+typedef double Meters;
+typedef Meters Distance;
+typedef struct Node_t* Node;
+typedef fn(int) -> int IntOp;
+typedef (int, Meters) Pair;
+typedef struct Point Pt;
+
+struct Node_t {
+  int value;
+  Node next;
+};
+
+struct Point {
+  Meters x;
+  Meters y;
+};
+
+Meters double_it(Meters m) {
+  return m * 2.0;
+}
+
+Matrix identity(int n) {
+  Matrix m = new int[n][n];
+  int i = 0;
+  while (i < n) {
+    m[i][i] = 1;
+    i++;
+  }
+  return m;
+}
+
+int sum(Row r) {
+  int s = 0;
+  for (int x : r) {
+    s = s + x;
+  }
+  return s;
+}
+
+int main() {
+  Distance d = 1.5;
+  Meters m = double_it(d) + 0.5;
+  printDouble(m);
+  Matrix id = identity(3);
+  printInt(id.length);
+  printInt(id[1][1] + id[1][0]);
+  Row r = id[2];
+  printInt(sum(r) + sum(id[0]));
+  Node n = new Node_t;
+  n->value = 7;
+  n->next = new Node_t;
+  n->next->value = 8;
+  printInt(n->value + n->next->value);
+  IntOp inc = (int x) -> x + 1;
+  printInt(inc(41));
+  Pair p = (1, 2.5);
+  (int a, Meters b) = p;
+  printDouble(b);
+  Pt pt = Pt{x: 1.0, y: 2.0};
+  printDouble(pt.x + pt.y);
+  if (m > d) {
+    printString("further");
+  }
+  return 0;
+}
@@ -0,0 +1,63 @@
+typedef boolean Flag;
+typedef int Count;
+typedef int[] Counts;
+typedef string Text;
+typedef struct Cell_t* Cell;
+typedef fn(Count) -> Count Step;
+
+struct Cell_t {
+  Count n;
+  Cell next;
+};
+
+Flag positive(Count c) {
+  return c > 0;
+}
+
+Count twice(Count c) {
+  return c * 2;
+}
+
+Cell push(Cell head, Count n) {
+  Cell c = new Cell_t;
+  c->n = n;
+  c->next = head;
+  return c;
+}
+
+int main() {
+  Flag f = positive(3);
+  if (f && !positive(-1)) {
+    printString("flags");
+  }
+  Count i = 0;
+  Counts cs = new int[4];
+  while (i < cs.length) {
+    cs[i] = twice(i);
+    i++;
+  }
+  Count total = 0;
+  for (Count c : cs) {
+    total = total + c;
+  }
+  printInt(total);
+  printInt(-cs[3]);
+  switch (cs[1]) {
+    case 2: printString("two");
+    default: printString("other");
+  }
+  Cell list = (Cell) null;
+  list = push(push(list, 4), 5);
+  Count sum = 0;
+  while (list != (Cell) null) {
+    sum = sum + list->n;
+    list = list->next;
+  }
+  printInt(sum);
+  Step s = twice;
+  printInt(s(s(3)));
+  Text t = "text";
+  assert(f, t);
+  printString(t);
+  return 0;
+}
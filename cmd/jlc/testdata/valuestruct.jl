@@ -0,0 +1,62 @@
+struct Point {
+  int x;
+  int y;
+};
+
+struct Rect {
+  Point min;
+  Point max;
+  string name;
+};
+
+typedef struct Rect* RectPtr;
+
+void bump(Point p) {
+  p.x = p.x + 100;
+  printInt(p.x);
+}
+
+Point mid(Point a, Point b) {
+  Point m = Point{x: (a.x + b.x) / 2, y: (a.y + b.y) / 2};
+  return m;
+}
+
+int area(struct Rect r) {
+  return (r.max.x - r.min.x) * (r.max.y - r.min.y);
+}
+
+int main() {
+  struct Point p;
+  printInt(p.x);
+  p.x = 3;
+  p.y = 4;
+  Point q = p;
+  q.x = 10;
+  printInt(p.x);
+  printInt(q.x);
+  bump(p);
+  printInt(p.x);
+  p = q;
+  p.y = 7;
+  printInt(q.y);
+  printInt(p.y);
+  Point m = mid(Point{x: 0, y: 0}, Point{x: 4, y: 8});
+  printInt(m.x);
+  printInt(m.y);
+  Rect r = Rect{max: Point{x: 2, y: 3}, name: "r"};
+  printInt(area(r));
+  r.min.x = 1;
+  printInt(area(r));
+  printString(r.name);
+  Point c = r.max;
+  c.x = 99;
+  printInt(r.max.x);
+  RectPtr rp = new Rect;
+  rp->max = r.max;
+  r.max.y = 50;
+  printInt(rp->max.y);
+  rp->max.x = 5;
+  printInt(rp->max.x);
+  printInt(mid(p, q).y);
+  return 0;
+}
@@ -0,0 +1,46 @@
+typedef struct Node_t* Node;
+
+struct Node_t {
+  int value;
+};
+
+struct Point {
+  int x;
+  int y;
+};
+
+int twice(int x) {
+  return 2 * x;
+}
+
+int main() {
+  var n = 3, m = 2;
+  var grid = new Node[n][m];
+  printInt(grid.length * grid[0].length);
+  grid[1][1] = new Node_t;
+  grid[1][1]->value = 9;
+  var node = grid[1][1];
+  printInt(node->value);
+  var half = 0.5;
+  printDouble(half * 3.0);
+  var greeting = "hello";
+  printString(greeting);
+  var done = n > m;
+  if (done) {
+    printString("done");
+  }
+  var f = (int x) -> twice(x) + 1;
+  printInt(f(20));
+  var pair = (n, half);
+  (int a, double b) = pair;
+  printInt(a);
+  var p = Point{x: 1, y: 2};
+  var q = p;
+  q.x = 5;
+  printInt(p.x + q.x);
+  var none = (Node) null;
+  if (none == (Node) null) {
+    printString("null");
+  }
+  return 0;
+}
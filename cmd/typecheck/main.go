@@ -8,40 +8,47 @@ import (
 	"os"
 
 	"github.com/antlr4-go/antlr/v4"
-	"github.com/aramyamal/javalette-to-llvm-compiler/gen/parser"
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/loader"
 	"github.com/aramyamal/javalette-to-llvm-compiler/internal/typechk"
 )
 
 func main() {
+	var includeDirs []string
+	flag.Func("I", "Directory to search for imported files", func(dir string) error {
+		includeDirs = append(includeDirs, dir)
+		return nil
+	})
 	flag.Parse()
 	args := flag.Args()
 
+	fileLoader := loader.NewLoader(
+		includeDirs,
+		antlr.NewConsoleErrorListener(),
+		antlr.NewDiagnosticErrorListener(true),
+	)
+	var modules []*loader.Module
 	var err error
-	var stream antlr.CharStream
 	if len(args) > 0 {
-		filepath := args[0]
-		stream, err = antlr.NewFileStream(filepath)
-		if err != nil {
-			log.Fatalf("Error reading file %v: %v", filepath, err)
-		}
+		modules, err = fileLoader.LoadFile(args[0])
 	} else {
-		input, err := io.ReadAll(os.Stdin)
-		if err != nil {
-			log.Fatal("Error processing standard input:", err)
+		input, readErr := io.ReadAll(os.Stdin)
+		if readErr != nil {
+			log.Fatal("Error processing standard input:", readErr)
 		}
-		stream = antlr.NewInputStream(string(input))
+		stream := antlr.NewInputStream(string(input))
+		modules, err = fileLoader.LoadStream(stream, ".")
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR")
+		log.Fatalln(err)
 	}
 
-	lexer := parser.NewJavaletteLexer(stream)
-	tokens := antlr.NewCommonTokenStream(lexer, 0)
-	parser := parser.NewJavaletteParser(tokens)
-	parser.AddErrorListener(antlr.NewDiagnosticErrorListener(true))
-	tree := parser.Prgm()
-
-	fmt.Println(tree.ToStringTree(parser.RuleNames, parser))
+	for _, module := range modules {
+		fmt.Println(module.Tree.ToStringTree(module.Parser.RuleNames, module.Parser))
+	}
 
 	typechk := typechk.NewTypeChecker()
-	_, err = typechk.Typecheck(tree)
+	_, err = typechk.TypecheckModules(modules)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "ERROR")
 		log.Fatalln(err)
@@ -5,6 +5,7 @@ package codegen
 
 import (
 	"io"
+	"strings"
 
 	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
 	"github.com/aramyamal/javalette-to-llvm-compiler/pkg/llvmgen"
@@ -19,6 +20,19 @@ type CodeGenerator struct {
 	declTypes   map[string]struct{}
 	declGlobals map[string]struct{}
 	structs     map[string]*llvmgen.StructType
+	pending     []func() error // functions to emit after the current one
+	overloaded  map[string]struct{}
+	externs     map[string]*tast.ExternDef
+	mainArgs    *tast.ArrayType    // type of the parameter of main, if any
+	errorKinds  map[string]int     // kinds of the structs extending Error
+	errorTypes  []*tast.StructType // structs extending Error by kind
+	tryFrames   []llvmgen.Reg      // try frames entered by current function
+	tryDepths   []int              // context depths of the try blocks
+	tailCall    *tailCallTarget    // loop target of self tail calls, if any
+	hoistAllocs bool               // whether to move allocas to the entry block
+	guardDefers bool               // whether deferred expressions get frames
+	release     bool               // whether assertions are compiled out
+	passes      []func(*llvmgen.Module) error
 }
 
 // NewCodeGenerator creates and returns a new CodeGenerator instance that writes
@@ -34,9 +48,24 @@ func NewCodeGenerator(w io.Writer) *CodeGenerator {
 		declTypes:   make(map[string]struct{}),
 		declGlobals: make(map[string]struct{}),
 		structs:     make(map[string]*llvmgen.StructType),
+		overloaded:  make(map[string]struct{}),
+		externs:     make(map[string]*tast.ExternDef),
+		errorKinds:  make(map[string]int),
 	}
 }
 
+// SetRelease sets whether code is generated for a release build, in which
+// assert statements compile to nothing.
+func (cg *CodeGenerator) SetRelease(release bool) {
+	cg.release = release
+}
+
+// AddPass adds pass to the passes run over the generated module before it is
+// written, in the order they are added.
+func (cg *CodeGenerator) AddPass(pass func(*llvmgen.Module) error) {
+	cg.passes = append(cg.passes, pass)
+}
+
 // GenerateCode performs LLVM code generation for the given TAST prgm
 // representing a Javalette program. The input shoud be a pointer to the root of
 // the TAST (*tast.Prgm). If an error is encountered during traversal,
@@ -49,6 +78,11 @@ func (cg *CodeGenerator) GenerateCode(prgm *tast.Prgm) error {
 	cg.emitFuncDecl(llvmgen.I32, "readInt")
 	cg.emitFuncDecl(llvmgen.Double, "readDouble")
 
+	cg.findOverloads(prgm.Defs)
+	cg.findExterns(prgm.Defs)
+	cg.findMainArgs(prgm.Defs)
+	cg.findErrorKinds(prgm.Defs)
+
 	cg.env.EnterContext()
 	defer cg.env.ExitContext()
 
@@ -57,13 +91,28 @@ func (cg *CodeGenerator) GenerateCode(prgm *tast.Prgm) error {
 
 		cg.ng.resetNames()
 
-		cg.write.Newline()
-
 		if err := cg.compileDef(def); err != nil {
 			return err
 		}
 
 		cg.env.ExitContext()
+
+		if err := cg.emitPending(); err != nil {
+			return err
+		}
+	}
+
+	if cg.mainArgs != nil {
+		cg.ng.resetNames()
+		if err := cg.emitMainWrapper(); err != nil {
+			return err
+		}
+	}
+
+	for _, pass := range cg.passes {
+		if err := pass(cg.write.Module()); err != nil {
+			return err
+		}
 	}
 
 	if err := cg.write.WriteAll(); err != nil {
@@ -73,6 +122,60 @@ func (cg *CodeGenerator) GenerateCode(prgm *tast.Prgm) error {
 	return nil
 }
 
+// emitPending emits the functions queued while compiling the previous
+// function, such as lifted lambda bodies, each with fresh names and scopes.
+func (cg *CodeGenerator) emitPending() error {
+	for len(cg.pending) > 0 {
+		emit := cg.pending[0]
+		cg.pending = cg.pending[1:]
+
+		outerEnv := cg.env
+		cg.env = NewCodegenEnv()
+		cg.ng.resetNames()
+
+		err := emit()
+		cg.env = outerEnv
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findOverloads records the names of functions defined more than once, whose
+// definitions are told apart by mangling their parameter types into the name.
+func (cg *CodeGenerator) findOverloads(defs []tast.Def) {
+	defined := make(map[string]struct{})
+	for _, def := range defs {
+		if d, ok := def.(*tast.FuncDef); ok {
+			if _, exists := defined[d.Id]; exists {
+				cg.overloaded[d.Id] = struct{}{}
+			}
+			defined[d.Id] = struct{}{}
+		}
+	}
+}
+
+// funcName returns the LLVM name of the function name taking params.
+func (cg *CodeGenerator) funcName(
+	name string, params []tast.Type,
+) llvmgen.Global {
+	if name == "main" && cg.mainArgs != nil {
+		return mainArgsName
+	}
+	if _, ok := cg.overloaded[name]; !ok {
+		return llvmgen.Global(name)
+	}
+	if len(params) == 0 {
+		return llvmgen.Global(name + ".void")
+	}
+	mangled := make([]string, len(params))
+	for i, param := range params {
+		mangled[i] = tast.Mangle(param)
+	}
+	return llvmgen.Global(name + "." + strings.Join(mangled, "."))
+}
+
 func (cg *CodeGenerator) addGlobal(name string) bool {
 	if _, ok := cg.declGlobals[name]; !ok {
 		cg.declGlobals[name] = struct{}{}
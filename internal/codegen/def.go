@@ -16,6 +16,10 @@ func (cg *CodeGenerator) compileDef(def tast.Def) error {
 		return cg.compileStructDef(d)
 	case *tast.TypedefDef:
 		return nil
+	case *tast.EnumDef:
+		return nil // enums are lowered to i32 constants
+	case *tast.ExternDef:
+		return cg.compileExternDef(d)
 	default:
 		return fmt.Errorf(
 			"compileDef: unhandled def type %T at %d:%d near '%s'",
@@ -29,19 +33,115 @@ func (cg *CodeGenerator) compileFuncDef(d *tast.FuncDef) error {
 	if err != nil {
 		return err
 	}
-	cg.write.StartDefine(cg.toLlvmRetType(d.Type()), llvmgen.Global(d.Id), params...)
+	paramTypes := make([]tast.Type, len(d.Args))
+	for i, arg := range d.Args {
+		paramTypes[i] = arg.Type()
+	}
+	var attrs []string
+	switch d.Inline {
+	case tast.InlineAlways:
+		attrs = []string{"alwaysinline"}
+	case tast.InlineNever:
+		attrs = []string{"noinline"}
+	}
+	name := cg.funcName(d.Id, paramTypes)
+	cg.write.StartDefineAttrs(cg.toLlvmReturnType(d.Type()), name, attrs, params...)
 	cg.write.Label("entry")
-	for _, param := range params {
-		cg.emitVarAlloc(string(param.Name), param.Type, param.Name)
+	paramPtrs := make([]llvmgen.Reg, len(params))
+	for i, param := range params {
+		paramPtrs[i], _ = cg.emitVarAlloc(string(param.Name), param.Type, param.Name)
+	}
+
+	// self tail calls store their arguments to the parameters and jump back to
+	// the start of the body instead of growing the stack
+	if cg.hasSelfTailCall(name, d.Stms) {
+		cg.tailCall = &tailCallTarget{
+			name:   name,
+			params: paramPtrs,
+			label:  cg.ng.nextLab(),
+		}
+		cg.write.Br(cg.tailCall.label)
+		cg.write.Label(cg.tailCall.label)
+		// locals declared in the body would otherwise be allocated again on
+		// every jump back to its start
+		cg.hoistAllocs = true
 	}
+	// deferred expressions also run when a callee throws, which takes a frame
+	// catching the thrown value
+	cg.guardDefers = cg.callsMayThrow(d.Stms)
+	defer func() {
+		cg.tailCall = nil
+		cg.hoistAllocs = false
+		cg.guardDefers = false
+	}()
+
 	for _, stm := range d.Stms {
 		if err := cg.compileStm(stm); err != nil {
 			return err
 		}
 	}
+	if cg.hoistAllocs {
+		if err := cg.write.HoistAllocas(); err != nil {
+			return err
+		}
+	}
 	return cg.write.EndDefine()
 }
 
+// tailCallTarget is the loop replacing the self tail calls of a function.
+type tailCallTarget struct {
+	name   llvmgen.Global // name of the function
+	params []llvmgen.Reg  // pointers to the parameters of the function
+	label  string         // label of the start of the body
+}
+
+// hasSelfTailCall reports whether stms return a call to the function name.
+func (cg *CodeGenerator) hasSelfTailCall(name llvmgen.Global, stms []tast.Stm) bool {
+	for _, stm := range stms {
+		switch s := stm.(type) {
+		case *tast.ReturnStm:
+			if call, ok := s.Exp.(*tast.FuncExp); ok &&
+				cg.funcName(call.Id, call.Params) == name {
+				return true
+			}
+		case *tast.BlockStm:
+			if cg.hasSelfTailCall(name, s.Stms) {
+				return true
+			}
+		case *tast.IfStm:
+			if cg.hasSelfTailCall(name, []tast.Stm{s.ThenStm}) ||
+				(s.ElseStm != nil &&
+					cg.hasSelfTailCall(name, []tast.Stm{s.ElseStm})) {
+				return true
+			}
+		case *tast.WhileStm:
+			if cg.hasSelfTailCall(name, []tast.Stm{s.Stm}) {
+				return true
+			}
+		case *tast.ForEachStm:
+			if cg.hasSelfTailCall(name, []tast.Stm{s.Stm}) {
+				return true
+			}
+		case *tast.SwitchStm:
+			for _, c := range s.Cases {
+				if cg.hasSelfTailCall(name, c.Stms) {
+					return true
+				}
+			}
+			if s.Default != nil && cg.hasSelfTailCall(name, s.Default.Stms) {
+				return true
+			}
+		case *tast.TryStm:
+			for _, c := range s.Catches {
+				if cg.hasSelfTailCall(name, c.Stms) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 func (cg *CodeGenerator) compileStructDef(d *tast.StructDef) error {
 	structType, ok := d.Type().(*tast.StructType)
 	if !ok {
@@ -53,22 +153,16 @@ func (cg *CodeGenerator) compileStructDef(d *tast.StructDef) error {
 		)
 	}
 
-	fields := structType.Fields()
-	fieldLlvmTypes := make([]llvmgen.Type, len(fields))
-	for i, fieldName := range fields {
-		fieldInfo, ok := structType.FieldInfo(fieldName)
-		if !ok {
-			return fmt.Errorf(
-				"internal compiler error in compileStructDef: "+
-					"unable to access struct field %s from %T",
-				fieldName, structType,
-			)
-		}
-		fieldLlvmTypes[i] = cg.toLlvmType(fieldInfo.Type)
+	// the fields are laid out by toLlvmType, which every use of the struct goes
+	// through
+	llvmType, ok := cg.toLlvmType(structType).(*llvmgen.StructType)
+	if !ok {
+		return fmt.Errorf(
+			"internal compiler error in compileStructDef: "+
+				"struct %s has no LLVM struct type", structType.Name,
+		)
 	}
-	return cg.emitTypeDecl(llvmgen.StructDef(
-		structType.Name, fieldLlvmTypes...,
-	))
+	return cg.emitTypeDecl(llvmType)
 }
 
 func (cg *CodeGenerator) extractParams(args []tast.Arg) ([]llvmgen.FuncParam, error) {
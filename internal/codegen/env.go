@@ -1,19 +1,118 @@
 package codegen
 
-import "github.com/aramyamal/javalette-to-llvm-compiler/pkg/llvmgen"
+import (
+	"maps"
+
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+	"github.com/aramyamal/javalette-to-llvm-compiler/pkg/llvmgen"
+)
 
 type CodegenContext map[string]llvmgen.Reg
 
 type CodegenEnv struct {
 	contexts []CodegenContext
+	deferred [][]deferredExp // deferred expressions of each context
+}
+
+// deferredExp is an expression deferred until its context exits, which is
+// compiled with the variables visible where it was deferred. The frame guarding
+// it, if any, runs it when a value thrown by a callee leaves the context.
+type deferredExp struct {
+	exp      tast.Exp
+	contexts []CodegenContext
+	frame    llvmgen.Reg
 }
 
 func (e *CodegenEnv) EnterContext() {
 	e.contexts = append(e.contexts, make(CodegenContext))
+	e.deferred = append(e.deferred, nil)
 }
 
 func (e *CodegenEnv) ExitContext() {
 	e.contexts = e.contexts[:len(e.contexts)-1]
+	e.deferred = e.deferred[:len(e.deferred)-1]
+}
+
+// Defer adds exp to the cleanup stack of the current context. The variables
+// currently visible are recorded, since exp may be compiled where other
+// variables shadow them.
+func (e *CodegenEnv) Defer(exp tast.Exp) {
+	contexts := make([]CodegenContext, len(e.contexts))
+	for i, ctx := range e.contexts {
+		contexts[i] = maps.Clone(ctx)
+	}
+	e.deferred[len(e.deferred)-1] = append(
+		e.deferred[len(e.deferred)-1],
+		deferredExp{exp: exp, contexts: contexts},
+	)
+}
+
+// GuardDeferred records that frame guards the expression last deferred in the
+// current context, and returns it.
+func (e *CodegenEnv) GuardDeferred(frame llvmgen.Reg) deferredExp {
+	deferred := e.deferred[len(e.deferred)-1]
+	deferred[len(deferred)-1].frame = frame
+	return deferred[len(deferred)-1]
+}
+
+// OutermostGuard returns the first entered frame guarding a deferred
+// expression, and the depth of the context it was deferred in.
+func (e *CodegenEnv) OutermostGuard() (llvmgen.Reg, int, bool) {
+	for depth, deferred := range e.deferred {
+		for _, d := range deferred {
+			if d.frame != "" {
+				return d.frame, depth, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// Deferred returns the expressions deferred in the current context in the
+// order they run when it exits, the last deferred first.
+func (e *CodegenEnv) Deferred() []deferredExp {
+	return reversed(e.deferred[len(e.deferred)-1])
+}
+
+// AllDeferred returns the expressions deferred in all contexts in the order
+// they run when returning from the function, innermost context first.
+func (e *CodegenEnv) AllDeferred() []deferredExp {
+	return e.DeferredSince(0)
+}
+
+// DeferredSince returns the expressions deferred in the contexts entered at
+// or after depth, in the order they run when leaving them, innermost context
+// first.
+func (e *CodegenEnv) DeferredSince(depth int) []deferredExp {
+	var all []deferredExp
+	for i := len(e.deferred) - 1; i >= depth; i-- {
+		all = append(all, reversed(e.deferred[i])...)
+	}
+	return all
+}
+
+// Depth returns the number of contexts currently entered.
+func (e *CodegenEnv) Depth() int {
+	return len(e.contexts)
+}
+
+// withContexts runs f with the variables of contexts visible instead of the
+// current ones.
+func (e *CodegenEnv) withContexts(
+	contexts []CodegenContext, f func() error,
+) error {
+	outer := e.contexts
+	e.contexts = contexts
+	defer func() { e.contexts = outer }()
+	return f()
+}
+
+func reversed(exps []deferredExp) []deferredExp {
+	rev := make([]deferredExp, len(exps))
+	for i, exp := range exps {
+		rev[len(exps)-1-i] = exp
+	}
+	return rev
 }
 
 func (e *CodegenEnv) LookupVar(name string) (llvmgen.Reg, bool) {
@@ -54,6 +153,7 @@ func (e CodegenEnv) Peek() (*CodegenContext, bool) {
 func NewCodegenEnv() *CodegenEnv {
 	return &CodegenEnv{
 		contexts: []CodegenContext{make(CodegenContext)},
+		deferred: [][]deferredExp{nil},
 	}
 }
 
@@ -11,6 +11,8 @@ func (cg *CodeGenerator) compileExp(exp tast.Exp) (llvmgen.Value, error) {
 	switch e := exp.(type) {
 	case *tast.ParenExp:
 		return cg.compileExp(e.Exp)
+	case *tast.IntToDoubleExp:
+		return cg.compileIntToDoubleExp(e)
 	case *tast.NullPtrExp:
 		return llvmgen.Null(), nil
 	case *tast.BoolExp:
@@ -27,6 +29,20 @@ func (cg *CodeGenerator) compileExp(exp tast.Exp) (llvmgen.Value, error) {
 		return cg.compileIdentExp(e)
 	case *tast.FuncExp:
 		return cg.compileFuncExp(e)
+	case *tast.FuncRefExp:
+		return cg.compileFuncRefExp(e)
+	case *tast.CallExp:
+		return cg.compileCallExp(e)
+	case *tast.EnumExp:
+		return llvmgen.LitInt(e.Value), nil
+	case *tast.EnumNameExp:
+		return cg.compileEnumNameExp(e)
+	case *tast.LambdaExp:
+		return cg.compileLambdaExp(e)
+	case *tast.StructLitExp:
+		return cg.compileStructLitExp(e)
+	case *tast.TupleExp:
+		return cg.compileTupleExp(e)
 	case *tast.ArrIndexExp:
 		return cg.compileArrIndexExp(e)
 	case *tast.FieldExp:
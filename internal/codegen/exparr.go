@@ -29,7 +29,13 @@ func (cg *CodeGenerator) compileNewArrExp(
 		)
 	}
 
-	arrStructPtr, err := cg.allocArray(arrStructType, indices, 0)
+	var arrStructPtr llvmgen.Value
+	var err error
+	if length, ok := e.Exps[0].(*tast.IntExp); ok && e.OnStack {
+		arrStructPtr, err = cg.allocStackArray(arrStructType, length.Value)
+	} else {
+		arrStructPtr, err = cg.allocArray(arrStructType, indices, 0)
+	}
 	if err != nil {
 		return nil, fmt.Errorf(
 			"%w at %d:%d near %s", err, e.Line(), e.Col(), e.Text(),
@@ -55,13 +61,9 @@ func (cg *CodeGenerator) compileArrIndexExp(
 				" %s", err, e.Line(), e.Col(), e.Text(),
 		)
 	}
-	// if the element is an array/struct elemptr is a pointer to a pointer to
-	// the array struct
-	if structType, ok := elemType.(*llvmgen.StructType); ok {
-		// arrPtr is pointer to actual struct
-		arrPtr := cg.ng.nextReg()
-		cg.write.Load(arrPtr, structType.Ptr(), structType.Ptr().Ptr(), elemPtr)
-		return arrPtr, nil
+	// struct values are stored inline, so the element pointer is their value
+	if _, ok := elemType.(*llvmgen.StructType); ok {
+		return elemPtr, nil
 	}
 
 	// otherwise load the primitive type value
@@ -101,6 +103,10 @@ func (cg *CodeGenerator) compileFieldExp(e *tast.FieldExp) (
 			"internal compiler error in compileFieldExp %w", err,
 		)
 	}
+	// struct values are stored inline, so the field pointer is their value
+	if isValueStruct(e.Type()) {
+		return fieldPtr, nil
+	}
 	fieldValue := cg.ng.nextReg()
 	cg.write.Load(fieldValue, fieldType, fieldType.Ptr(), fieldPtr)
 	return fieldValue, nil
@@ -110,7 +116,13 @@ func (cg *CodeGenerator) compileFieldLExp(e *tast.FieldExp) (
 	llvmgen.Reg, error,
 ) {
 	fieldPtr, _, err := cg.emitFieldPtr(
-		e, func() (llvmgen.Value, error) { return cg.compileLExp(e.Exp) },
+		e, func() (llvmgen.Value, error) {
+			// the value of a struct value is already a pointer to it
+			if isValueStruct(e.Exp.Type()) {
+				return cg.compileExp(e.Exp)
+			}
+			return cg.compileLExp(e.Exp)
+		},
 	)
 	if err != nil {
 		return "", fmt.Errorf(
@@ -128,7 +140,7 @@ func (cg *CodeGenerator) emitFieldPtr(
 	if err != nil {
 		return "", nil, err
 	}
-	fieldProv, ok := e.Exp.Type().(tast.FieldProvider)
+	fieldProv, ok := UnwrapTypedef(e.Exp.Type()).(tast.FieldProvider)
 	if !ok {
 		return "", nil, fmt.Errorf(
 			"expected field provider type at %d:%d near %s",
@@ -164,11 +176,14 @@ func (cg *CodeGenerator) emitArrayTypeDecls(typ llvmgen.Type) error {
 	if err := cg.emitTypeDecl(structType); err != nil {
 		return err
 	}
-	// if the second field is a pointer
+	// arrays of arrays point to pointers to the inner array structs, while
+	// the struct values of arrays of structs are declared with the struct
 	if ptrType, ok := structType.Fields[1].(llvmgen.PtrType); ok {
-		// if pointer points to element that is a struct do recursive call
-		if _, isStruct := ptrType.Elem.(*llvmgen.StructType); isStruct {
-			return cg.emitArrayTypeDecls(ptrType.Elem)
+		if innerPtr, ok := ptrType.Elem.(llvmgen.PtrType); ok {
+			inner, isStruct := innerPtr.Elem.(*llvmgen.StructType)
+			if isStruct && inner.Array {
+				return cg.emitArrayTypeDecls(inner)
+			}
 		}
 	}
 	return nil
@@ -309,8 +324,16 @@ func (cg *CodeGenerator) allocArray(
 			elemPtr, elemType, elemType.Ptr(), dataTypedPtr,
 			idxVal,
 		)
-		// recursively allocate next dimension
-		elemStruct, ok := elemType.(*llvmgen.StructType)
+		// recursively allocate next dimension, whose elements are pointers
+		// to the inner array structs
+		elemStructPtr, ok := elemType.(llvmgen.PtrType)
+		if !ok {
+			return nil, fmt.Errorf(
+				"internal compiler error at allocArray: " +
+					"expected pointer type for inner array",
+			)
+		}
+		elemStruct, ok := elemStructPtr.Elem.(*llvmgen.StructType)
 		if !ok {
 			return nil, fmt.Errorf(
 				"internal compiler error at allocArray:" +
@@ -364,6 +387,67 @@ func (cg *CodeGenerator) emitSizeOf(typ llvmgen.Type) (llvmgen.Value, error) {
 	return sizeReg, nil
 }
 
+// allocStackArray allocates an array of a single dimension with the given
+// length, and its data, on the stack of the current function.
+func (cg *CodeGenerator) allocStackArray(
+	arrStructType *llvmgen.StructType,
+	length int,
+) (llvmgen.Value, error) {
+	if err := cg.emitArrayTypeDecls(arrStructType); err != nil {
+		return nil, err
+	}
+	ptrType, ok := arrStructType.Fields[1].(llvmgen.PtrType)
+	if !ok {
+		return nil, fmt.Errorf(
+			"internal compiler error in allocStackArray: expected pointer type"+
+				" for array data field (field 1), but got %s",
+			arrStructType.Fields[1].String(),
+		)
+	}
+	elemType := ptrType.Elem
+
+	dataType := llvmgen.Array(elemType, length)
+	data := cg.emitStackAlloc(dataType)
+	dataTypedPtr := cg.ng.nextReg()
+	cg.write.GetElementPtr(
+		dataTypedPtr, dataType, dataType.Ptr(), data,
+		llvmgen.LitInt(0), llvmgen.LitInt(0),
+	)
+	arrStructPtr := cg.emitStackAlloc(arrStructType)
+
+	// set length field (field 0)
+	lenFieldPtr := cg.ng.nextReg()
+	cg.write.GetElementPtr(
+		lenFieldPtr, arrStructType, arrStructType.Ptr(), arrStructPtr,
+		llvmgen.LitInt(0), llvmgen.LitInt(0),
+	)
+	cg.write.Store(
+		llvmgen.I32, llvmgen.LitInt(length), llvmgen.I32.Ptr(), lenFieldPtr,
+	)
+
+	// set pointer field (field 1)
+	ptrFieldPtr := cg.ng.nextReg()
+	cg.write.GetElementPtr(
+		ptrFieldPtr, arrStructType, arrStructType.Ptr(), arrStructPtr,
+		llvmgen.LitInt(0), llvmgen.LitInt(1),
+	)
+	cg.write.Store(
+		elemType.Ptr(), dataTypedPtr, elemType.Ptr().Ptr(), ptrFieldPtr,
+	)
+	return arrStructPtr, nil
+}
+
+// emitStackAlloc allocates a zero initialized value of typ on the stack of the
+// current function. The alloca is moved to the entry block when the function
+// ends, so that allocating in a loop reuses the same memory.
+func (cg *CodeGenerator) emitStackAlloc(typ llvmgen.Type) llvmgen.Reg {
+	ptr := cg.ng.nextReg()
+	cg.write.Alloca(ptr, typ)
+	cg.write.Store(typ, llvmgen.ZeroInitializer(), typ.Ptr(), ptr)
+	cg.hoistAllocs = true
+	return ptr
+}
+
 func (cg *CodeGenerator) emitCalloc(
 	numElems llvmgen.Value,
 	elemSize llvmgen.Value,
@@ -380,7 +464,7 @@ func (cg *CodeGenerator) emitCalloc(
 	// allocate zero intialized memeory with calloc for the data
 	raw := cg.ng.nextReg()
 	cg.write.Call(
-		raw, llvmgen.I8.Ptr(), "calloc",
+		raw, llvmgen.I8.Ptr(), llvmgen.Global("calloc"),
 		llvmgen.Arg(llvmgen.I64, numElems),
 		llvmgen.Arg(llvmgen.I64, elemSize),
 	)
@@ -399,7 +483,7 @@ func (cg *CodeGenerator) emitUninitStruct(
 
 	// handle array types
 	if llvmStructType, isStruct := llvmType.(*llvmgen.StructType); isStruct {
-		if _, isTastArray := typ.(*tast.ArrayType); isTastArray {
+		if _, isTastArray := UnwrapTypedef(typ).(*tast.ArrayType); isTastArray {
 			// lllocate an empty array (length 0, data null)
 			arrPtr, err := cg.allocArray(llvmStructType, []llvmgen.Value{}, 0)
 			if err != nil {
@@ -484,12 +568,19 @@ func (cg *CodeGenerator) emitArrElemPtr(
 		}
 
 		// otherwise load the next array struct pointer
+		nextArrayType, ok := ptrType.Elem.(llvmgen.PtrType)
+		if !ok {
+			return "", nil, fmt.Errorf(
+				"expected pointer type for array element at dimension %d, "+
+					"got %s", i+1, ptrType.Elem.String(),
+			)
+		}
 		nextArrayPtr := cg.ng.nextReg()
-		cg.write.Load(nextArrayPtr, elementType, elementType.Ptr(), elemPtr)
+		cg.write.Load(nextArrayPtr, nextArrayType, ptrType, elemPtr)
 
 		// update for next iteration
 		currentPtr = nextArrayPtr
-		currentType = elementType.Elem
+		currentType = nextArrayType.Elem
 	}
 	return "", nil, fmt.Errorf("no index expressions in array access")
 }
@@ -2,6 +2,7 @@ package codegen
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
 	"github.com/aramyamal/javalette-to-llvm-compiler/pkg/llvmgen"
@@ -23,6 +24,76 @@ func (cg *CodeGenerator) compileStringExp(e *tast.StringExp) (
 	return des, nil
 }
 
+// emitCString returns a pointer to a global constant holding text, such as a
+// file name or source code, escaping the characters that cannot appear in an
+// LLVM string literal.
+func (cg *CodeGenerator) emitCString(text string) llvmgen.Value {
+	var escaped strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c < ' ' || c > '~' || c == '"' || c == '\\' {
+			fmt.Fprintf(&escaped, "\\%02X", c)
+		} else {
+			escaped.WriteByte(c)
+		}
+	}
+	typ := llvmgen.Array(llvmgen.I8, len(text)+1)
+	glbVar, _, alreadyWritten := cg.ng.getOrAddString(escaped.String())
+	if !alreadyWritten {
+		cg.write.InternalConstant(
+			glbVar, typ, llvmgen.LitString(escaped.String()),
+		)
+	}
+	return llvmgen.GEP(
+		typ, typ.Ptr(), glbVar, llvmgen.LitInt(0), llvmgen.LitInt(0),
+	)
+}
+
+func (cg *CodeGenerator) compileEnumNameExp(e *tast.EnumNameExp) (
+	llvmgen.Value, error,
+) {
+	enumType, ok := UnwrapTypedef(e.Exp.Type()).(*tast.EnumType)
+	if !ok {
+		return nil, fmt.Errorf(
+			"internal compiler error in compileEnumNameExp: expected enum "+
+				"type but got %s at %d:%d near '%s'",
+			e.Exp.Type(), e.Line(), e.Col(), e.Text(),
+		)
+	}
+	value, err := cg.compileExp(e.Exp)
+	if err != nil {
+		return nil, err
+	}
+
+	// the names of the constants are stored in a table indexed by value
+	tableType := llvmgen.Array(llvmgen.I8.Ptr(), len(enumType.Constants))
+	tableName := llvmgen.Global(enumType.Name + ".names")
+	if !cg.addGlobal(string(tableName)) {
+		names := make([]llvmgen.Value, len(enumType.Constants))
+		for i, constant := range enumType.Constants {
+			glbVar, strLen, alreadyWritten := cg.ng.getOrAddString(constant)
+			strType := llvmgen.Array(llvmgen.I8, strLen)
+			if !alreadyWritten {
+				cg.write.InternalConstant(glbVar, strType, llvmgen.LitString(constant))
+			}
+			names[i] = llvmgen.GEP(
+				strType, strType.Ptr(), glbVar, llvmgen.LitInt(0), llvmgen.LitInt(0),
+			)
+		}
+		cg.write.InternalConstant(
+			tableName, tableType, llvmgen.ArrayOf(llvmgen.I8.Ptr(), names...),
+		)
+	}
+
+	namePtr := cg.ng.nextReg()
+	cg.write.GetElementPtr(
+		namePtr, tableType, tableType.Ptr(), tableName, llvmgen.LitInt(0), value,
+	)
+	des := cg.ng.nextReg()
+	cg.write.Load(des, llvmgen.I8.Ptr(), llvmgen.I8.Ptr().Ptr(), namePtr)
+	return des, nil
+}
+
 func (cg *CodeGenerator) compileIdentExp(e *tast.IdentExp) (
 	llvmgen.Value, error,
 ) {
@@ -46,6 +117,18 @@ func (cg *CodeGenerator) compileIdentExp(e *tast.IdentExp) (
 	return des, nil
 }
 
+func (cg *CodeGenerator) compileIntToDoubleExp(
+	e *tast.IntToDoubleExp,
+) (llvmgen.Value, error) {
+	value, err := cg.compileExp(e.Exp)
+	if err != nil {
+		return nil, err
+	}
+	des := cg.ng.nextReg()
+	cg.write.SIToFP(des, llvmgen.I32, value, llvmgen.Double)
+	return des, nil
+}
+
 func (cg *CodeGenerator) compileIdentLExp(e *tast.IdentExp) (
 	llvmgen.Reg, error,
 ) {
@@ -68,10 +151,11 @@ func (cg *CodeGenerator) compileFuncExp(e *tast.FuncExp) (
 	if err != nil {
 		return nil, err
 	}
-
-	des := cg.ng.nextReg()
-	cg.write.Call(des, cg.toLlvmRetType(e.Type()), llvmgen.Global(e.Id), args...)
-	return des, nil
+	des, err := cg.emitDirectCall(e.Id, e.Params, e.Type(), args)
+	if err != nil {
+		return nil, err
+	}
+	return cg.emitStructResult(e.Type(), des)
 }
 
 func (cg *CodeGenerator) compileFuncLExp(e *tast.FuncExp) (
@@ -91,10 +175,7 @@ func (cg *CodeGenerator) compileFuncLExp(e *tast.FuncExp) (
 	if err != nil {
 		return "", err
 	}
-
-	des := cg.ng.nextReg()
-	cg.write.Call(des, cg.toLlvmRetType(e.Type()), llvmgen.Global(e.Id), args...)
-	return des, nil
+	return cg.emitDirectCall(e.Id, e.Params, e.Type(), args)
 }
 
 func (cg *CodeGenerator) emitFuncArgs(exps []tast.Exp) (
@@ -106,6 +187,11 @@ func (cg *CodeGenerator) emitFuncArgs(exps []tast.Exp) (
 		if err != nil {
 			return nil, err
 		}
+		// struct values are passed as a pointer to a copy
+		value, err = cg.emitStructCopy(exp, value)
+		if err != nil {
+			return nil, err
+		}
 		args = append(args, llvmgen.Arg(cg.toLlvmRetType(exp.Type()), value))
 	}
 	return args, nil
@@ -394,6 +480,22 @@ func (cg *CodeGenerator) compileOrExp(e *tast.OrExp) (llvmgen.Value, error) {
 func (cg *CodeGenerator) compileAssignExp(
 	e *tast.AssignExp,
 ) (llvmgen.Value, error) {
+	// struct values are assigned by copying into the storage of the lhs
+	if isValueStruct(e.Type()) {
+		lhsPtr, err := cg.compileExp(e.ExpLhs)
+		if err != nil {
+			return nil, err
+		}
+		value, err := cg.compileExp(e.Exp)
+		if err != nil {
+			return nil, err
+		}
+		if err := cg.emitStore(e.Type(), value, lhsPtr); err != nil {
+			return nil, err
+		}
+		return lhsPtr, nil
+	}
+
 	lhsPtr, err := cg.compileLExp(e.ExpLhs)
 	if err != nil {
 		return nil, err
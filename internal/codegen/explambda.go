@@ -0,0 +1,311 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+	"github.com/aramyamal/javalette-to-llvm-compiler/pkg/llvmgen"
+)
+
+// closureTypeName is the name of the LLVM struct holding a function value. The
+// dot keeps it from colliding with user defined struct names.
+const closureTypeName = "fn.closure"
+
+// envParam is the hidden first parameter of every function called through a
+// function value, pointing to the environment record of the closure.
+const envParam = ".env"
+
+// closureType returns the struct type of function values, a pair of the
+// function pointer and the environment pointer, both stored as i8*.
+func (cg *CodeGenerator) closureType() *llvmgen.StructType {
+	closureType, exists := cg.structs[closureTypeName]
+	if !exists {
+		closureType = llvmgen.StructDef(
+			closureTypeName,
+			llvmgen.I8.Ptr(), // function pointer
+			llvmgen.I8.Ptr(), // environment pointer
+		)
+		cg.structs[closureTypeName] = closureType
+		cg.emitTypeDecl(closureType)
+	}
+	return closureType
+}
+
+// toLlvmFuncType returns the LLVM type of the function stored in a closure of
+// type t, which takes the environment pointer before the declared parameters.
+func (cg *CodeGenerator) toLlvmFuncType(t *tast.FuncType) llvmgen.FuncType {
+	params := []llvmgen.Type{llvmgen.I8.Ptr()}
+	for _, param := range t.Params {
+		params = append(params, cg.toLlvmRetType(param))
+	}
+	return llvmgen.Func(cg.toLlvmReturnType(t.Returns), params...)
+}
+
+// lambdaEnvType returns the struct type of the environment record holding the
+// captured variables of e. Captured struct values are held by value, so that
+// the closure keeps a copy like it does of other captured values.
+func (cg *CodeGenerator) lambdaEnvType(e *tast.LambdaExp) *llvmgen.StructType {
+	fields := make([]llvmgen.Type, len(e.Captures))
+	for i, capture := range e.Captures {
+		if isValueStruct(capture.Type()) {
+			fields[i] = cg.toLlvmType(capture.Type())
+		} else {
+			fields[i] = cg.toLlvmRetType(capture.Type())
+		}
+	}
+	envType := llvmgen.StructDef(e.Id+".env", fields...)
+	cg.emitTypeDecl(envType)
+	return envType
+}
+
+func (cg *CodeGenerator) compileFuncRefExp(
+	e *tast.FuncRefExp,
+) (llvmgen.Value, error) {
+	funcType, ok := e.Type().(*tast.FuncType)
+	if !ok {
+		return nil, fmt.Errorf(
+			"internal compiler error in compileFuncRefExp: expected function "+
+				"type but got %s at %d:%d near '%s'",
+			e.Type(), e.Line(), e.Col(), e.Text(),
+		)
+	}
+
+	// every named function used as a value shares one constant closure that
+	// points to a thunk dropping the environment pointer
+	funcName := cg.funcName(e.Id, funcType.Params)
+	closureName := llvmgen.Global(string(funcName) + ".closure")
+	if !cg.addGlobal(string(closureName)) {
+		thunkName := llvmgen.Global(string(funcName) + ".thunk")
+		closureType := cg.closureType()
+		cg.write.InternalConstant(closureName, closureType, llvmgen.Struct(
+			closureType,
+			llvmgen.Bitcast(
+				thunkName, cg.toLlvmFuncType(funcType).Ptr(), llvmgen.I8.Ptr(),
+			),
+			llvmgen.Null(),
+		))
+		cg.pending = append(cg.pending, func() error {
+			return cg.emitThunk(e.Id, thunkName, funcType)
+		})
+	}
+	return closureName, nil
+}
+
+// emitThunk emits a function taking an environment pointer followed by the
+// parameters of funcType, that forwards the call to the named function.
+func (cg *CodeGenerator) emitThunk(
+	name string, thunkName llvmgen.Global, funcType *tast.FuncType,
+) error {
+	params := []llvmgen.FuncParam{llvmgen.Param(llvmgen.I8.Ptr(), envParam)}
+	var args []llvmgen.FuncArg
+	for i, param := range funcType.Params {
+		typ := cg.toLlvmRetType(param)
+		name := fmt.Sprintf(".p%d", i)
+		params = append(params, llvmgen.Param(typ, name))
+		args = append(args, llvmgen.Arg(typ, llvmgen.Reg(name)))
+	}
+
+	returns := cg.toLlvmReturnType(funcType.Returns)
+	cg.write.StartDefine(returns, thunkName, params...)
+	cg.write.Label("entry")
+	des, err := cg.emitDirectCall(name, funcType.Params, funcType.Returns, args)
+	if err != nil {
+		return err
+	}
+	cg.write.Ret(returns, des)
+	return cg.write.EndDefine()
+}
+
+func (cg *CodeGenerator) compileCallExp(e *tast.CallExp) (
+	llvmgen.Value, error,
+) {
+	funcType, ok := UnwrapTypedef(e.Exp.Type()).(*tast.FuncType)
+	if !ok {
+		return nil, fmt.Errorf(
+			"internal compiler error in compileCallExp: calling value of "+
+				"non-function type %s at %d:%d near '%s'",
+			e.Exp.Type(), e.Line(), e.Col(), e.Text(),
+		)
+	}
+
+	closure, err := cg.compileExp(e.Exp)
+	if err != nil {
+		return nil, err
+	}
+
+	// unpack function and environment pointers from the closure
+	closureType := cg.closureType()
+	fnField := cg.ng.nextReg()
+	cg.write.GetElementPtr(
+		fnField, closureType, closureType.Ptr(), closure,
+		llvmgen.LitInt(0), llvmgen.LitInt(0),
+	)
+	rawFn := cg.ng.nextReg()
+	cg.write.Load(rawFn, llvmgen.I8.Ptr(), llvmgen.I8.Ptr().Ptr(), fnField)
+	envField := cg.ng.nextReg()
+	cg.write.GetElementPtr(
+		envField, closureType, closureType.Ptr(), closure,
+		llvmgen.LitInt(0), llvmgen.LitInt(1),
+	)
+	env := cg.ng.nextReg()
+	cg.write.Load(env, llvmgen.I8.Ptr(), llvmgen.I8.Ptr().Ptr(), envField)
+
+	fn := cg.ng.nextReg()
+	cg.write.Bitcast(
+		fn, llvmgen.I8.Ptr(), rawFn, cg.toLlvmFuncType(funcType).Ptr(),
+	)
+
+	args, err := cg.emitFuncArgs(e.Exps)
+	if err != nil {
+		return nil, err
+	}
+	args = append([]llvmgen.FuncArg{llvmgen.Arg(llvmgen.I8.Ptr(), env)}, args...)
+
+	des := cg.ng.nextReg()
+	cg.write.Call(des, cg.toLlvmReturnType(e.Type()), fn, args...)
+	return cg.emitStructResult(e.Type(), des)
+}
+
+func (cg *CodeGenerator) compileLambdaExp(
+	e *tast.LambdaExp,
+) (llvmgen.Value, error) {
+	funcType, ok := e.Type().(*tast.FuncType)
+	if !ok {
+		return nil, fmt.Errorf(
+			"internal compiler error in compileLambdaExp: expected function "+
+				"type but got %s at %d:%d near '%s'",
+			e.Type(), e.Line(), e.Col(), e.Text(),
+		)
+	}
+
+	// copy the captured variables into a heap allocated environment record
+	var env llvmgen.Value = llvmgen.Null()
+	if len(e.Captures) > 0 {
+		envType := cg.lambdaEnvType(e)
+		envSize, _ := cg.emitSizeOf(envType)
+		envPtr, err := cg.emitCalloc(llvmgen.LitInt(1), envSize, envType)
+		if err != nil {
+			return nil, err
+		}
+		for i, capture := range e.Captures {
+			value, err := cg.compileIdentExp(capture)
+			if err != nil {
+				return nil, err
+			}
+			fieldPtr := cg.ng.nextReg()
+			cg.write.GetElementPtr(
+				fieldPtr, envType, envType.Ptr(), envPtr,
+				llvmgen.LitInt(0), llvmgen.LitInt(i),
+			)
+			if err := cg.emitStore(capture.Type(), value, fieldPtr); err != nil {
+				return nil, err
+			}
+		}
+		rawEnv := cg.ng.nextReg()
+		cg.write.Bitcast(rawEnv, envType.Ptr(), envPtr, llvmgen.I8.Ptr())
+		env = rawEnv
+	}
+
+	// allocate the closure and fill in the function and environment pointers
+	closureType := cg.closureType()
+	closureSize, _ := cg.emitSizeOf(closureType)
+	closure, err := cg.emitCalloc(llvmgen.LitInt(1), closureSize, closureType)
+	if err != nil {
+		return nil, err
+	}
+	fnField := cg.ng.nextReg()
+	cg.write.GetElementPtr(
+		fnField, closureType, closureType.Ptr(), closure,
+		llvmgen.LitInt(0), llvmgen.LitInt(0),
+	)
+	cg.write.Store(
+		llvmgen.I8.Ptr(),
+		llvmgen.Bitcast(
+			llvmgen.Global(e.Id),
+			cg.toLlvmFuncType(funcType).Ptr(),
+			llvmgen.I8.Ptr(),
+		),
+		llvmgen.I8.Ptr().Ptr(), fnField,
+	)
+	envField := cg.ng.nextReg()
+	cg.write.GetElementPtr(
+		envField, closureType, closureType.Ptr(), closure,
+		llvmgen.LitInt(0), llvmgen.LitInt(1),
+	)
+	cg.write.Store(llvmgen.I8.Ptr(), env, llvmgen.I8.Ptr().Ptr(), envField)
+
+	// a lambda compiled more than once, as in a deferred expression, is only
+	// lifted once
+	if !cg.addGlobal(e.Id) {
+		cg.pending = append(cg.pending, func() error {
+			return cg.emitLambdaBody(e, funcType)
+		})
+	}
+	return closure, nil
+}
+
+// emitLambdaBody emits the lifted function of e, where captured variables are
+// accessed through the environment record passed as the first parameter.
+func (cg *CodeGenerator) emitLambdaBody(
+	e *tast.LambdaExp, funcType *tast.FuncType,
+) error {
+	params, err := cg.extractParams(e.Args)
+	if err != nil {
+		return err
+	}
+
+	returns := cg.toLlvmReturnType(funcType.Returns)
+	cg.write.StartDefine(
+		returns,
+		llvmgen.Global(e.Id),
+		append(
+			[]llvmgen.FuncParam{llvmgen.Param(llvmgen.I8.Ptr(), envParam)},
+			params...,
+		)...,
+	)
+	cg.write.Label("entry")
+	for _, param := range params {
+		cg.emitVarAlloc(string(param.Name), param.Type, param.Name)
+	}
+
+	// bind captured variables to their fields in the environment record
+	if len(e.Captures) > 0 {
+		envType := cg.lambdaEnvType(e)
+		envPtr := cg.ng.nextReg()
+		cg.write.Bitcast(
+			envPtr, llvmgen.I8.Ptr(), llvmgen.Reg(envParam), envType.Ptr(),
+		)
+		for i, capture := range e.Captures {
+			fieldPtr := cg.ng.nextReg()
+			cg.write.GetElementPtr(
+				fieldPtr, envType, envType.Ptr(), envPtr,
+				llvmgen.LitInt(0), llvmgen.LitInt(i),
+			)
+			if !isValueStruct(capture.Type()) {
+				cg.env.ExtendVar(capture.Id, fieldPtr)
+				continue
+			}
+			// struct variables hold a pointer to their storage, which is the
+			// copy in the environment record
+			ptrType := cg.toLlvmType(capture.Type()).Ptr()
+			if _, err := cg.emitVarAlloc(
+				capture.Id, ptrType, fieldPtr,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	body, err := cg.compileExp(e.Exp)
+	if err != nil {
+		return err
+	}
+	if isValueStruct(e.Exp.Type()) {
+		body, err = cg.emitStructReturn(e.Exp, body)
+		if err != nil {
+			return err
+		}
+	}
+	cg.write.Ret(returns, body)
+	return cg.write.EndDefine()
+}
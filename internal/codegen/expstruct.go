@@ -29,20 +29,32 @@ func (cg *CodeGenerator) compileNewStructExp(
 		)
 	}
 
-	structSize, err := cg.emitSizeOf(structType)
-	if err != nil {
-		return nil, fmt.Errorf(
-			"internal compiler error in compileNewStructExp: %w at %d:%d at %s",
-			err, e.Line(), e.Col(), e.Text(),
-		)
+	var structPtr llvmgen.Value
+	if e.OnStack {
+		structPtr = cg.emitStackAlloc(structType)
+	} else {
+		structSize, err := cg.emitSizeOf(structType)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"internal compiler error in compileNewStructExp: %w at %d:%d at %s",
+				err, e.Line(), e.Col(), e.Text(),
+			)
+		}
+
+		structPtr, err = cg.emitCalloc(llvmgen.LitInt(1), structSize, structType)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"internal compiler error in compileNewStructExp: %w at %d:%d at %s",
+				err, e.Line(), e.Col(), e.Text(),
+			)
+		}
 	}
 
-	structPtr, err := cg.emitCalloc(llvmgen.LitInt(1), structSize, structType)
-	if err != nil {
-		return nil, fmt.Errorf(
-			"internal compiler error in compileNewStructExp: %w at %d:%d at %s",
-			err, e.Line(), e.Col(), e.Text(),
-		)
+	// instances of structs extending Error record their kind
+	if _, isError := cg.errorKinds[structType.Name]; isError {
+		if err := cg.emitErrorKind(structType, structPtr); err != nil {
+			return nil, err
+		}
 	}
 
 	return structPtr, nil
@@ -56,6 +68,10 @@ func (cg *CodeGenerator) compileDerefExp(
 		return nil, fmt.Errorf("compileDerefExp: %w", err)
 	}
 
+	// struct values are stored inline, so the field pointer is their value
+	if isValueStruct(e.Type()) {
+		return fieldPtr, nil
+	}
 	typ := cg.toLlvmRetType(e.Type())
 
 	value := cg.ng.nextReg()
@@ -87,7 +103,7 @@ func (cg *CodeGenerator) compileDerefLExp(
 		)
 	}
 
-	ptrTastType, ok1 := e.Exp.Type().(*tast.PointerType)
+	ptrTastType, ok1 := UnwrapTypedef(e.Exp.Type()).(*tast.PointerType)
 	if !ok1 {
 		return "", fmt.Errorf(
 			"compileDerefLExp: expected pointer TAST type at %d:%d near '%s'",
@@ -117,3 +133,164 @@ func (cg *CodeGenerator) compileDerefLExp(
 
 	return fieldPtr, nil
 }
+
+// isValueStruct reports whether typ is a struct value rather than a pointer to
+// one. Struct values are represented by a pointer to their storage, which is
+// copied whenever the value is, so that no two variables share it.
+func isValueStruct(typ tast.Type) bool {
+	_, ok := UnwrapTypedef(typ).(*tast.StructType)
+	return ok
+}
+
+// isFreshStruct reports whether the struct value of exp is stored where
+// nothing else refers to it, so that it does not need to be copied.
+func isFreshStruct(exp tast.Exp) bool {
+	switch exp.(type) {
+	case *tast.StructLitExp, *tast.FuncExp, *tast.CallExp:
+		return true
+	default:
+		return false
+	}
+}
+
+func (cg *CodeGenerator) compileStructLitExp(
+	e *tast.StructLitExp,
+) (llvmgen.Value, error) {
+	structType, ok := cg.toLlvmType(e.Type()).(*llvmgen.StructType)
+	if !ok {
+		return nil, fmt.Errorf(
+			"internal compiler error in compileStructLitExp: "+
+				"expected llvm struct type at %d:%d near %s",
+			e.Line(), e.Col(), e.Text(),
+		)
+	}
+	fieldProv, ok := e.Type().(tast.FieldProvider)
+	if !ok {
+		return nil, fmt.Errorf(
+			"internal compiler error in compileStructLitExp: "+
+				"type does not provide fields at %d:%d near %s",
+			e.Line(), e.Col(), e.Text(),
+		)
+	}
+
+	// the fields not initialized by the literal are zero
+	structPtr := cg.ng.nextReg()
+	cg.write.Alloca(structPtr, structType)
+	cg.write.Store(
+		structType, structType.ZeroValue(), structType.Ptr(), structPtr,
+	)
+	if _, isError := cg.errorKinds[structType.Name]; isError {
+		if err := cg.emitErrorKind(structType, structPtr); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, fieldName := range e.Fields {
+		fieldInfo, ok := fieldProv.FieldInfo(fieldName)
+		if !ok {
+			return nil, fmt.Errorf(
+				"internal compiler error in compileStructLitExp: "+
+					"field %s not found at %d:%d near %s",
+				fieldName, e.Line(), e.Col(), e.Text(),
+			)
+		}
+		value, err := cg.compileExp(e.Exps[i])
+		if err != nil {
+			return nil, err
+		}
+		fieldPtr := cg.ng.nextReg()
+		cg.write.GetElementPtr(
+			fieldPtr, structType, structType.Ptr(), structPtr,
+			llvmgen.LitInt(0), llvmgen.LitInt(fieldInfo.Idx),
+		)
+		if err := cg.emitStore(fieldInfo.Type, value, fieldPtr); err != nil {
+			return nil, err
+		}
+	}
+	return structPtr, nil
+}
+
+// emitStore stores value of type typ at ptr, copying the contents of struct
+// values instead of the pointer to them.
+func (cg *CodeGenerator) emitStore(
+	typ tast.Type, value llvmgen.Value, ptr llvmgen.Value,
+) error {
+	des, ok := ptr.(llvmgen.Reg)
+	if !ok {
+		return fmt.Errorf(
+			"internal compiler error in emitStore: expected register but got "+
+				"%s", ptr,
+		)
+	}
+	if !isValueStruct(typ) {
+		llvmType := cg.toLlvmRetType(typ)
+		return cg.write.Store(llvmType, value, llvmType.Ptr(), des)
+	}
+	src, ok := value.(llvmgen.Reg)
+	if !ok {
+		return fmt.Errorf(
+			"internal compiler error in emitStore: expected pointer to struct "+
+				"value but got %s", value,
+		)
+	}
+	structType := cg.toLlvmType(typ)
+	contents := cg.ng.nextReg()
+	cg.write.Load(contents, structType, structType.Ptr(), src)
+	return cg.write.Store(structType, contents, structType.Ptr(), des)
+}
+
+// emitStructCopy copies the struct value of exp into new storage on the stack,
+// unless it is fresh.
+func (cg *CodeGenerator) emitStructCopy(
+	exp tast.Exp, value llvmgen.Value,
+) (llvmgen.Value, error) {
+	if !isValueStruct(exp.Type()) || isFreshStruct(exp) {
+		return value, nil
+	}
+	structType := cg.toLlvmType(exp.Type())
+	structPtr := cg.ng.nextReg()
+	cg.write.Alloca(structPtr, structType)
+	if err := cg.emitStore(exp.Type(), value, structPtr); err != nil {
+		return nil, err
+	}
+	return structPtr, nil
+}
+
+// emitStructReturn loads the contents of the returned struct value, which is
+// returned by value since its storage may be in the frame of the returning
+// function.
+func (cg *CodeGenerator) emitStructReturn(
+	exp tast.Exp, value llvmgen.Value,
+) (llvmgen.Value, error) {
+	structPtr, ok := value.(llvmgen.Reg)
+	if !ok {
+		return nil, fmt.Errorf(
+			"internal compiler error in emitStructReturn: expected pointer to "+
+				"struct value but got %s at %d:%d near '%s'",
+			value, exp.Line(), exp.Col(), exp.Text(),
+		)
+	}
+	structType := cg.toLlvmType(exp.Type())
+	contents := cg.ng.nextReg()
+	cg.write.Load(contents, structType, structType.Ptr(), structPtr)
+	return contents, nil
+}
+
+// emitStructResult stores the struct value of type typ returned by a call in
+// storage of its own, to represent it by a pointer like other struct values.
+func (cg *CodeGenerator) emitStructResult(
+	typ tast.Type, value llvmgen.Value,
+) (llvmgen.Value, error) {
+	if !isValueStruct(typ) {
+		return value, nil
+	}
+	structType := cg.toLlvmType(typ)
+	structPtr := cg.ng.nextReg()
+	cg.write.Alloca(structPtr, structType)
+	if err := cg.write.Store(
+		structType, value, structType.Ptr(), structPtr,
+	); err != nil {
+		return nil, err
+	}
+	return structPtr, nil
+}
@@ -0,0 +1,67 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+	"github.com/aramyamal/javalette-to-llvm-compiler/pkg/llvmgen"
+)
+
+// compileTupleExp builds a tuple as an LLVM literal struct value, one element
+// at a time. Struct values are copied into the tuple.
+func (cg *CodeGenerator) compileTupleExp(e *tast.TupleExp) (llvmgen.Value, error) {
+	tupleType := cg.toLlvmType(e.Type())
+	var tuple llvmgen.Value = llvmgen.Undef()
+	for i, elem := range e.Elems {
+		value, err := cg.compileExp(elem)
+		if err != nil {
+			return nil, err
+		}
+		elemType := cg.toLlvmRetType(elem.Type())
+		if isValueStruct(elem.Type()) {
+			structPtr, ok := value.(llvmgen.Reg)
+			if !ok {
+				return nil, fmt.Errorf(
+					"internal compiler error in compileTupleExp: expected "+
+						"pointer to struct value but got %s at %d:%d near '%s'",
+					value, elem.Line(), elem.Col(), elem.Text(),
+				)
+			}
+			elemType = cg.toLlvmType(elem.Type())
+			contents := cg.ng.nextReg()
+			cg.write.Load(contents, elemType, elemType.Ptr(), structPtr)
+			value = contents
+		}
+		des := cg.ng.nextReg()
+		cg.write.InsertValue(des, tupleType, tuple, elemType, value, i)
+		tuple = des
+	}
+	return tuple, nil
+}
+
+// compileTupleDeclStm declares a variable initialized with each element of the
+// destructured tuple. Struct values are copied out of the tuple into storage
+// of their own.
+func (cg *CodeGenerator) compileTupleDeclStm(s *tast.TupleDeclStm) error {
+	tuple, err := cg.compileExp(s.Exp)
+	if err != nil {
+		return err
+	}
+	tupleType := cg.toLlvmType(s.Exp.Type())
+	for i, id := range s.Ids {
+		elem := cg.ng.nextReg()
+		cg.write.ExtractValue(elem, tupleType, tuple, i)
+		var init llvmgen.Value = elem
+		if isValueStruct(s.Types[i]) {
+			structType := cg.toLlvmType(s.Types[i])
+			structPtr := cg.ng.nextReg()
+			cg.write.Alloca(structPtr, structType)
+			cg.write.Store(structType, elem, structType.Ptr(), structPtr)
+			init = structPtr
+		}
+		if _, err := cg.emitVarAlloc(id, cg.toLlvmRetType(s.Types[i]), init); err != nil {
+			return err
+		}
+	}
+	return nil
+}
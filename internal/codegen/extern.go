@@ -0,0 +1,120 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+	"github.com/aramyamal/javalette-to-llvm-compiler/pkg/llvmgen"
+)
+
+// findExterns records the extern function declarations, whose calls marshal
+// their arguments and return values to C types.
+func (cg *CodeGenerator) findExterns(defs []tast.Def) {
+	for _, def := range defs {
+		if d, ok := def.(*tast.ExternDef); ok {
+			cg.externs[d.Id] = d
+		}
+	}
+}
+
+// toCType returns the LLVM type a value of type typ is passed to C as, as
+// described by typechk.isExternType.
+func (cg *CodeGenerator) toCType(typ tast.Type) llvmgen.Type {
+	if arr, ok := UnwrapTypedef(typ).(*tast.ArrayType); ok {
+		return cg.toLlvmRetType(arr.Elem).Ptr()
+	}
+	if UnwrapTypedef(typ) == tast.Bool {
+		return llvmgen.I32
+	}
+	return cg.toLlvmRetType(typ)
+}
+
+func (cg *CodeGenerator) compileExternDef(d *tast.ExternDef) error {
+	var params []llvmgen.Type
+	for _, arg := range d.Args {
+		params = append(params, cg.toCType(arg.Type()))
+	}
+	return cg.emitFuncDecl(cg.toCType(d.Type()), d.Id, params...)
+}
+
+// emitDirectCall emits a call to the named function taking params and
+// returning returns, marshalling the arguments and the return value if the
+// function is extern.
+func (cg *CodeGenerator) emitDirectCall(
+	name string,
+	params []tast.Type,
+	returns tast.Type,
+	args []llvmgen.FuncArg,
+) (llvmgen.Reg, error) {
+	extern, isExtern := cg.externs[name]
+	if !isExtern {
+		des := cg.ng.nextReg()
+		err := cg.write.Call(
+			des, cg.toLlvmReturnType(returns), cg.funcName(name, params), args...,
+		)
+		return des, err
+	}
+	if len(args) != len(extern.Args) {
+		return "", fmt.Errorf(
+			"internal compiler error in emitDirectCall: extern function '%s' "+
+				"called with %d arguments but takes %d",
+			name, len(args), len(extern.Args),
+		)
+	}
+
+	cArgs := make([]llvmgen.FuncArg, len(args))
+	for i, arg := range args {
+		cArg, err := cg.marshalExternArg(extern.Args[i].Type(), arg)
+		if err != nil {
+			return "", err
+		}
+		cArgs[i] = cArg
+	}
+
+	des := cg.ng.nextReg()
+	if err := cg.write.Call(
+		des, cg.toCType(returns), llvmgen.Global(name), cArgs...,
+	); err != nil {
+		return "", err
+	}
+	if UnwrapTypedef(returns) != tast.Bool {
+		return des, nil
+	}
+
+	// booleans are returned as C ints, where any nonzero value is true
+	result := cg.ng.nextReg()
+	err := cg.write.CmpNe(result, llvmgen.I32, des, llvmgen.LitInt(0))
+	return result, err
+}
+
+// marshalExternArg converts arg of type typ to the C type it is passed as.
+func (cg *CodeGenerator) marshalExternArg(
+	typ tast.Type, arg llvmgen.FuncArg,
+) (llvmgen.FuncArg, error) {
+	// arrays are passed as a pointer to their first element
+	if _, ok := UnwrapTypedef(typ).(*tast.ArrayType); ok {
+		arrType, ok := cg.toLlvmType(typ).(*llvmgen.StructType)
+		if !ok {
+			return llvmgen.FuncArg{}, fmt.Errorf(
+				"internal compiler error in marshalExternArg: array type %s "+
+					"is not lowered to a struct", typ,
+			)
+		}
+		dataType := cg.toCType(typ)
+		dataPtr := cg.ng.nextReg()
+		cg.write.GetElementPtr(
+			dataPtr, arrType, arrType.Ptr(), arg.Value,
+			llvmgen.LitInt(0), llvmgen.LitInt(1),
+		)
+		data := cg.ng.nextReg()
+		cg.write.Load(data, dataType, dataType.Ptr(), dataPtr)
+		return llvmgen.Arg(dataType, data), nil
+	}
+
+	if UnwrapTypedef(typ) == tast.Bool {
+		value := cg.ng.nextReg()
+		err := cg.write.ZExt(value, llvmgen.I1, arg.Value, llvmgen.I32)
+		return llvmgen.Arg(llvmgen.I32, value), err
+	}
+	return arg, nil
+}
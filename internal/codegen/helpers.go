@@ -11,12 +11,22 @@ import (
 )
 
 func (cg *CodeGenerator) toLlvmRetType(typ tast.Type) llvmgen.Type {
-	if _, isFieldProvider := typ.(tast.FieldProvider); isFieldProvider {
+	if _, isFieldProvider := UnwrapTypedef(typ).(tast.FieldProvider); isFieldProvider {
 		return cg.toLlvmType(typ).Ptr()
 	}
 	return cg.toLlvmType(typ)
 }
 
+// toLlvmReturnType returns the LLVM type returned by functions returning typ.
+// Struct values are returned by value, since their storage may be in the frame
+// of the returning function.
+func (cg *CodeGenerator) toLlvmReturnType(typ tast.Type) llvmgen.Type {
+	if isValueStruct(typ) {
+		return cg.toLlvmType(typ)
+	}
+	return cg.toLlvmRetType(typ)
+}
+
 func (cg *CodeGenerator) toLlvmType(typ tast.Type) llvmgen.Type {
 
 	switch t := typ.(type) {
@@ -41,6 +51,10 @@ func (cg *CodeGenerator) toLlvmType(typ tast.Type) llvmgen.Type {
 				))
 			}
 			fieldLlvmTypes[i] = cg.toLlvmType(fieldInfo.Type)
+			// array fields hold pointers to the array structs
+			if _, isArray := UnwrapTypedef(fieldInfo.Type).(*tast.ArrayType); isArray {
+				fieldLlvmTypes[i] = fieldLlvmTypes[i].Ptr()
+			}
 		}
 		structType.Fields = fieldLlvmTypes
 		return structType
@@ -51,14 +65,38 @@ func (cg *CodeGenerator) toLlvmType(typ tast.Type) llvmgen.Type {
 	case *tast.PointerType:
 		return cg.toLlvmType(t.Elem).Ptr()
 
+	case *tast.FuncType:
+		return cg.closureType().Ptr()
+
+	case *tast.EnumType:
+		return llvmgen.I32
+
+	case *tast.TupleType:
+		// struct values are held by value, so that tuples own a copy of them
+		elemTypes := make([]llvmgen.Type, len(t.Elems))
+		for i, elem := range t.Elems {
+			if isValueStruct(elem) {
+				elemTypes[i] = cg.toLlvmType(elem)
+			} else {
+				elemTypes[i] = cg.toLlvmRetType(elem)
+			}
+		}
+		return llvmgen.LiteralStruct(elemTypes...)
+
 	case *tast.ArrayType:
 		elemType := cg.toLlvmType(t.Elem)
+		// arrays of arrays hold pointers to the inner array structs
+		if _, isArray := UnwrapTypedef(t.Elem).(*tast.ArrayType); isArray {
+			elemType = elemType.Ptr()
+		}
 		name := arrayName(elemType)
-		return llvmgen.StructDef(
+		arrayType := llvmgen.StructDef(
 			name,           // generated name
 			llvmgen.I32,    // length field
 			elemType.Ptr(), // pointer to data
 		)
+		arrayType.Array = true
+		return arrayType
 	}
 
 	switch typ {
@@ -0,0 +1,87 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+	"github.com/aramyamal/javalette-to-llvm-compiler/pkg/llvmgen"
+)
+
+// mainArgsName is the LLVM name of a main function taking the command-line
+// arguments, which is called from a C compatible main wrapper.
+const mainArgsName llvmgen.Global = "main.args"
+
+// findMainArgs records the type of the parameter of main, if it takes the
+// command-line arguments.
+func (cg *CodeGenerator) findMainArgs(defs []tast.Def) {
+	for _, def := range defs {
+		d, ok := def.(*tast.FuncDef)
+		if !ok || d.Id != "main" || len(d.Args) != 1 {
+			continue
+		}
+		if arr, ok := UnwrapTypedef(d.Args[0].Type()).(*tast.ArrayType); ok {
+			cg.mainArgs = arr
+		}
+	}
+}
+
+// emitMainWrapper emits the C main function, which passes the command-line
+// arguments without the program name to the main function of the program as
+// an array of strings. The array shares its data with argv.
+func (cg *CodeGenerator) emitMainWrapper() error {
+	arrType, ok := cg.toLlvmType(cg.mainArgs).(*llvmgen.StructType)
+	if !ok {
+		return fmt.Errorf(
+			"internal compiler error in emitMainWrapper: expected llvm struct " +
+				"type for array of arguments",
+		)
+	}
+	if err := cg.emitArrayTypeDecls(arrType); err != nil {
+		return err
+	}
+	strPtrType := llvmgen.I8.Ptr().Ptr()
+
+	cg.write.StartDefine(
+		llvmgen.I32, "main",
+		llvmgen.Param(llvmgen.I32, "argc"),
+		llvmgen.Param(strPtrType, "argv"),
+	)
+	cg.write.Label("entry")
+
+	arrSize, _ := cg.emitSizeOf(arrType)
+	arrPtr, err := cg.emitCalloc(llvmgen.LitInt(1), arrSize, arrType)
+	if err != nil {
+		return err
+	}
+
+	// length field holds the number of arguments after the program name
+	length := cg.ng.nextReg()
+	cg.write.Sub(length, llvmgen.I32, llvmgen.Reg("argc"), llvmgen.LitInt(1))
+	lenFieldPtr := cg.ng.nextReg()
+	cg.write.GetElementPtr(
+		lenFieldPtr, arrType, arrType.Ptr(), arrPtr,
+		llvmgen.LitInt(0), llvmgen.LitInt(0),
+	)
+	cg.write.Store(llvmgen.I32, length, llvmgen.I32.Ptr(), lenFieldPtr)
+
+	// data field points to the argument after the program name
+	data := cg.ng.nextReg()
+	cg.write.GetElementPtr(
+		data, llvmgen.I8.Ptr(), strPtrType, llvmgen.Reg("argv"),
+		llvmgen.LitInt(1),
+	)
+	dataFieldPtr := cg.ng.nextReg()
+	cg.write.GetElementPtr(
+		dataFieldPtr, arrType, arrType.Ptr(), arrPtr,
+		llvmgen.LitInt(0), llvmgen.LitInt(1),
+	)
+	cg.write.Store(strPtrType, data, strPtrType.Ptr(), dataFieldPtr)
+
+	result := cg.ng.nextReg()
+	cg.write.Call(
+		result, llvmgen.I32, mainArgsName,
+		llvmgen.Arg(arrType.Ptr(), arrPtr),
+	)
+	cg.write.Ret(llvmgen.I32, result)
+	return cg.write.EndDefine()
+}
@@ -13,9 +13,15 @@ func (cg *CodeGenerator) compileStm(stm tast.Stm) error {
 		return cg.compileExpStm(s)
 	case *tast.DeclsStm:
 		return cg.compileDeclsStm(s)
+	case *tast.TupleDeclStm:
+		return cg.compileTupleDeclStm(s)
 	case *tast.ReturnStm:
 		return cg.compileReturnStm(s)
 	case *tast.VoidReturnStm:
+		if err := cg.emitDeferred(cg.env.AllDeferred()); err != nil {
+			return err
+		}
+		cg.emitExitTryFrames()
 		return cg.write.Ret(llvmgen.Void)
 	case *tast.ForEachStm:
 		return cg.compileForEachStm(s)
@@ -25,6 +31,20 @@ func (cg *CodeGenerator) compileStm(stm tast.Stm) error {
 		return cg.compileBlockStm(s)
 	case *tast.IfStm:
 		return cg.compileIfStm(s)
+	case *tast.SwitchStm:
+		return cg.compileSwitchStm(s)
+	case *tast.ThrowStm:
+		return cg.compileThrowStm(s)
+	case *tast.TryStm:
+		return cg.compileTryStm(s)
+	case *tast.AssertStm:
+		return cg.compileAssertStm(s)
+	case *tast.DeferStm:
+		cg.env.Defer(s.Exp)
+		if cg.guardDefers {
+			return cg.emitDeferGuard()
+		}
+		return nil
 	case *tast.BlankStm:
 		return nil
 	default:
@@ -34,3 +54,26 @@ func (cg *CodeGenerator) compileStm(stm tast.Stm) error {
 		)
 	}
 }
+
+// emitDeferred evaluates the deferred expressions exps, which are compiled
+// again on every exit path of their block with the variables visible where
+// they were deferred.
+func (cg *CodeGenerator) emitDeferred(exps []deferredExp) error {
+	for _, d := range exps {
+		// the frame guarding the expression is exited first, so that values
+		// thrown by the expression itself do not run it again
+		if d.frame != "" {
+			cg.write.Call(
+				"", llvmgen.Void, llvmgen.Global("jl.exitTry"),
+				llvmgen.Arg(tryFrameType.Ptr(), d.frame),
+			)
+		}
+		if err := cg.env.withContexts(d.contexts, func() error {
+			_, err := cg.compileExp(d.exp)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
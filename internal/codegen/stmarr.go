@@ -54,11 +54,25 @@ func (cg *CodeGenerator) compileForEachStm(s *tast.ForEachStm) error {
 
 	// declare/allocate variable
 	elemType := cg.toLlvmType(s.Type)
-	// for arrays, the type is a pointer
-	if _, isArray := s.Type.(*tast.ArrayType); isArray {
+	varType := elemType
+	var initValue llvmgen.Value = elemType.ZeroValue()
+	// for arrays, the type is a pointer, and struct values are copied to
+	// storage of the variable
+	if _, isArray := UnwrapTypedef(s.Type).(*tast.ArrayType); isArray {
 		elemType = elemType.Ptr()
+		varType = elemType
+		initValue = elemType.ZeroValue()
+	} else if isValueStruct(s.Type) {
+		varType = elemType.Ptr()
+		initValue, err = cg.compileStructLitExp(tast.NewStructLitExp(
+			nil, nil, UnwrapTypedef(s.Type).(*tast.StructType),
+			s.Line(), s.Col(), s.Text(),
+		))
+		if err != nil {
+			return err
+		}
 	}
-	variablePtr, err := cg.emitVarAlloc(s.Id, elemType, elemType.ZeroValue())
+	variablePtr, err := cg.emitVarAlloc(s.Id, varType, initValue)
 	if err != nil {
 		return err
 	}
@@ -100,13 +114,15 @@ func (cg *CodeGenerator) compileForEachStm(s *tast.ForEachStm) error {
 		idxVal,
 	)
 
-	variableValue := cg.ng.nextReg()
 	if _, isStruct := elemType.(*llvmgen.StructType); isStruct {
-		// for arrays/structs, elemPtr is a pointer to a pointer to the struct,
-		// so load the pointer from elemPtr
-		cg.write.Load(variableValue, ptrType, ptrType.Ptr(), elemPtr)
-		cg.write.Store(ptrType, variableValue, ptrType.Ptr(), variablePtr)
+		// struct values are stored inline and copied to the loop variable
+		structPtr := cg.ng.nextReg()
+		cg.write.Load(structPtr, varType, varType.Ptr(), variablePtr)
+		if err := cg.emitStore(s.Type, elemPtr, structPtr); err != nil {
+			return err
+		}
 	} else {
+		variableValue := cg.ng.nextReg()
 		// for primitive types, load the value
 		cg.write.Load(variableValue, elemType, elemType.Ptr(), elemPtr)
 		cg.write.Store(elemType, variableValue, elemType.Ptr(), variablePtr)
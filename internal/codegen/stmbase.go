@@ -19,8 +19,10 @@ func (cg *CodeGenerator) compileDeclsStm(s *tast.DeclsStm) error {
 		typ := item.Type()
 		llvmType := cg.toLlvmType(typ)
 
-		// for arrays, the type is a pointer
-		if _, isArray := item.Type().(*tast.ArrayType); isArray {
+		// for arrays and struct values, the type is a pointer
+		if _, isArray := UnwrapTypedef(typ).(*tast.ArrayType); isArray {
+			llvmType = llvmType.Ptr()
+		} else if isValueStruct(typ) {
 			llvmType = llvmType.Ptr()
 		}
 
@@ -30,11 +32,19 @@ func (cg *CodeGenerator) compileDeclsStm(s *tast.DeclsStm) error {
 			var err error
 
 			// handle array initialiation separately
-			if _, isArray := typ.(*tast.ArrayType); isArray {
+			if _, isArray := UnwrapTypedef(typ).(*tast.ArrayType); isArray {
 				initValue, err = cg.emitUninitStruct(typ)
 				if err != nil {
 					return err
 				}
+			} else if isValueStruct(typ) {
+				initValue, err = cg.compileStructLitExp(tast.NewStructLitExp(
+					nil, nil, UnwrapTypedef(typ).(*tast.StructType),
+					i.Line(), i.Col(), i.Text(),
+				))
+				if err != nil {
+					return err
+				}
 			} else {
 				initValue = llvmType.ZeroValue()
 			}
@@ -47,6 +57,10 @@ func (cg *CodeGenerator) compileDeclsStm(s *tast.DeclsStm) error {
 			if err != nil {
 				return err
 			}
+			value, err = cg.emitStructCopy(i.Exp, value)
+			if err != nil {
+				return err
+			}
 
 			if _, err := cg.emitVarAlloc(i.Id, llvmType, value); err != nil {
 				return err
@@ -57,12 +71,26 @@ func (cg *CodeGenerator) compileDeclsStm(s *tast.DeclsStm) error {
 }
 
 func (cg *CodeGenerator) compileReturnStm(s *tast.ReturnStm) error {
+	if call, ok := cg.selfTailCall(s); ok {
+		return cg.emitSelfTailCall(call)
+	}
 	reg, err := cg.compileExp(s.Exp)
 	if err != nil {
 		return err
 	}
+	if isValueStruct(s.Exp.Type()) {
+		reg, err = cg.emitStructReturn(s.Exp, reg)
+		if err != nil {
+			return err
+		}
+	}
 
-	err = cg.write.Ret(cg.toLlvmRetType(s.Type), reg)
+	// the returned value is evaluated before the deferred expressions run
+	if err := cg.emitDeferred(cg.env.AllDeferred()); err != nil {
+		return err
+	}
+	cg.emitExitTryFrames()
+	err = cg.write.Ret(cg.toLlvmReturnType(s.Type), reg)
 	if err != nil {
 		return fmt.Errorf(
 			"internal compiler error in compileReturnStm: %w at %d:%d near %s",
@@ -72,6 +100,50 @@ func (cg *CodeGenerator) compileReturnStm(s *tast.ReturnStm) error {
 	return nil
 }
 
+// selfTailCall returns the call s returns if it is a call to the current
+// function that can reuse its stack frame, which is not the case when deferred
+// expressions or exiting a try frame must run after the call returns.
+func (cg *CodeGenerator) selfTailCall(s *tast.ReturnStm) (*tast.FuncExp, bool) {
+	call, ok := s.Exp.(*tast.FuncExp)
+	if !ok || cg.tailCall == nil ||
+		cg.funcName(call.Id, call.Params) != cg.tailCall.name ||
+		isValueStruct(call.Type()) ||
+		len(cg.env.AllDeferred()) > 0 || len(cg.tryFrames) > 0 {
+		return nil, false
+	}
+	return call, true
+}
+
+// emitSelfTailCall compiles the self tail call to a jump back to the start of
+// the function, after storing the arguments of call to its parameters.
+func (cg *CodeGenerator) emitSelfTailCall(call *tast.FuncExp) error {
+	// all arguments are evaluated before any parameter is overwritten, since
+	// they may read the parameters
+	args, err := cg.emitFuncArgs(call.Exps)
+	if err != nil {
+		return err
+	}
+	// the copies of struct arguments are reused by every jump, so passing
+	// them would let the next jump overwrite a parameter before reading it;
+	// they are copied again, after all arguments are read, to storage only the
+	// parameters refer to
+	for i, exp := range call.Exps {
+		if !isValueStruct(exp.Type()) {
+			continue
+		}
+		structPtr := cg.ng.nextReg()
+		cg.write.Alloca(structPtr, cg.toLlvmType(exp.Type()))
+		if err := cg.emitStore(exp.Type(), args[i].Value, structPtr); err != nil {
+			return err
+		}
+		args[i].Value = structPtr
+	}
+	for i, arg := range args {
+		cg.write.Store(arg.Type, arg.Value, arg.Type.Ptr(), cg.tailCall.params[i])
+	}
+	return cg.write.Br(cg.tailCall.label)
+}
+
 func (cg *CodeGenerator) compileWhileStm(s *tast.WhileStm) error {
 	conditionLab := cg.ng.nextLab()
 	bodyLab := cg.ng.nextLab()
@@ -105,7 +177,10 @@ func (cg *CodeGenerator) compileBlockStm(s *tast.BlockStm) error {
 			return err
 		}
 	}
-	return nil
+	if tast.GuaranteesReturn(s) {
+		return nil
+	}
+	return cg.emitDeferred(cg.env.Deferred())
 }
 
 func (cg *CodeGenerator) compileIfStm(s *tast.IfStm) error {
@@ -153,3 +228,46 @@ func (cg *CodeGenerator) compileIfStm(s *tast.IfStm) error {
 
 	return nil
 }
+
+// compileAssertStm compiles an assertion to a call to the runtime failure
+// handler when its condition is false. In release mode neither the condition
+// nor the message is evaluated.
+func (cg *CodeGenerator) compileAssertStm(s *tast.AssertStm) error {
+	if cg.release {
+		return nil
+	}
+	cond, err := cg.compileExp(s.Exp)
+	if err != nil {
+		return err
+	}
+	failLabel := cg.ng.nextLab()
+	endLabel := cg.ng.nextLab()
+	cg.write.BrIf(llvmgen.I1, cond, endLabel, failLabel)
+
+	cg.write.Label(failLabel)
+	var message llvmgen.Value = llvmgen.Null()
+	if s.Message != nil {
+		if message, err = cg.compileExp(s.Message); err != nil {
+			return err
+		}
+	}
+	if _, ok := cg.declGlobals["jl.assertFail"]; !ok {
+		cg.declGlobals["jl.assertFail"] = struct{}{}
+		cg.write.DeclareAttrs(
+			llvmgen.Void, "jl.assertFail", []string{"noreturn"},
+			llvmgen.I8.Ptr(), llvmgen.I32, llvmgen.I32, llvmgen.I8.Ptr(),
+			llvmgen.I8.Ptr(),
+		)
+	}
+	cg.write.Call(
+		"", llvmgen.Void, llvmgen.Global("jl.assertFail"),
+		llvmgen.Arg(llvmgen.I8.Ptr(), cg.emitCString(s.File)),
+		llvmgen.Arg(llvmgen.I32, llvmgen.LitInt(s.Line())),
+		llvmgen.Arg(llvmgen.I32, llvmgen.LitInt(s.Col())),
+		llvmgen.Arg(llvmgen.I8.Ptr(), cg.emitCString(s.Exp.Text())),
+		llvmgen.Arg(llvmgen.I8.Ptr(), message),
+	)
+	cg.write.Unreachable()
+
+	return cg.write.Label(endLabel)
+}
@@ -0,0 +1,77 @@
+package codegen
+
+import (
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+	"github.com/aramyamal/javalette-to-llvm-compiler/pkg/llvmgen"
+)
+
+func (cg *CodeGenerator) compileSwitchStm(s *tast.SwitchStm) error {
+	value, err := cg.compileExp(s.Exp)
+	if err != nil {
+		return err
+	}
+
+	caseLabels := make([]string, len(s.Cases))
+	var switchCases []llvmgen.SwitchCase
+	for i, c := range s.Cases {
+		caseLabels[i] = cg.ng.nextLab()
+		for _, caseValue := range c.Values {
+			switchCases = append(
+				switchCases, llvmgen.Case(llvmgen.LitInt(caseValue), caseLabels[i]),
+			)
+		}
+	}
+	defaultLabel := cg.ng.nextLab()
+	endLabel := cg.ng.nextLab()
+
+	llvmType := cg.toLlvmType(s.Exp.Type())
+	if err := cg.write.Switch(
+		llvmType, value, defaultLabel, switchCases...,
+	); err != nil {
+		return err
+	}
+
+	for i, c := range s.Cases {
+		cg.write.Label(caseLabels[i])
+		if err := cg.compileCaseStms(c.Stms, endLabel); err != nil {
+			return err
+		}
+	}
+
+	// an exhaustive switch without a default case can never reach it
+	cg.write.Label(defaultLabel)
+	if s.Default != nil {
+		if err := cg.compileCaseStms(s.Default.Stms, endLabel); err != nil {
+			return err
+		}
+	} else if s.Exhaustive {
+		cg.write.Unreachable()
+	} else {
+		cg.write.Br(endLabel)
+	}
+
+	// only emit the end label if at least one case does not return
+	if !tast.GuaranteesReturn(s) {
+		cg.write.Label(endLabel)
+	}
+	return nil
+}
+
+// compileCaseStms compiles the statements of a switch case in their own scope,
+// branching to endLabel after them unless they return.
+func (cg *CodeGenerator) compileCaseStms(stms []tast.Stm, endLabel string) error {
+	cg.env.EnterContext()
+	defer cg.env.ExitContext()
+	for _, stm := range stms {
+		if err := cg.compileStm(stm); err != nil {
+			return err
+		}
+		if tast.GuaranteesReturn(stm) {
+			return nil
+		}
+	}
+	if err := cg.emitDeferred(cg.env.Deferred()); err != nil {
+		return err
+	}
+	return cg.write.Br(endLabel)
+}
@@ -0,0 +1,436 @@
+package codegen
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+	"github.com/aramyamal/javalette-to-llvm-compiler/pkg/llvmgen"
+)
+
+// tryFrameType is the runtime record of an entered try statement, holding a
+// jmp_buf large enough for the supported targets followed by a pointer to the
+// enclosing frame.
+var tryFrameType = llvmgen.StructDef(
+	"jl.frame", llvmgen.Array(llvmgen.I64, 64), llvmgen.I8.Ptr(),
+)
+
+// findErrorKinds numbers the structs extending Error, whose kind is stored in
+// the hidden first field of every allocated instance to find the catch
+// clauses handling it.
+func (cg *CodeGenerator) findErrorKinds(defs []tast.Def) {
+	for _, def := range defs {
+		structDef, ok := def.(*tast.StructDef)
+		if !ok {
+			continue
+		}
+		structType, ok := structDef.Type().(*tast.StructType)
+		if ok && structType.IsSubtypeOf(tast.ErrorName) {
+			cg.errorKinds[structType.Name] = len(cg.errorTypes)
+			cg.errorTypes = append(cg.errorTypes, structType)
+		}
+	}
+}
+
+// emitTryDecls declares the runtime functions maintaining the chain of
+// entered try frames.
+func (cg *CodeGenerator) emitTryDecls() error {
+	if _, ok := cg.declGlobals["jl.throw"]; ok {
+		return nil
+	}
+	if err := cg.emitTypeDecl(tryFrameType); err != nil {
+		return err
+	}
+	cg.emitFuncDecl(llvmgen.Void, "jl.enterTry", tryFrameType.Ptr())
+	cg.emitFuncDecl(llvmgen.Void, "jl.exitTry", tryFrameType.Ptr())
+	cg.emitFuncDecl(llvmgen.I8.Ptr(), "jl.caught")
+	cg.declGlobals["jl.throw"] = struct{}{}
+	cg.declGlobals["_setjmp"] = struct{}{}
+	cg.write.DeclareAttrs(
+		llvmgen.Void, "jl.throw", []string{"noreturn"}, llvmgen.I8.Ptr(),
+	)
+	return cg.write.DeclareAttrs(
+		llvmgen.I32, "_setjmp", []string{"returns_twice"}, llvmgen.I8.Ptr(),
+	)
+}
+
+func (cg *CodeGenerator) compileThrowStm(s *tast.ThrowStm) error {
+	if err := cg.emitTryDecls(); err != nil {
+		return err
+	}
+	value, err := cg.compileExp(s.Exp)
+	if err != nil {
+		return err
+	}
+
+	// the thrown value is evaluated before the deferred expressions of the
+	// blocks left run, up to the innermost try block of the function. Those
+	// guarded by a frame are run by its handler instead
+	depth := 0
+	if len(cg.tryDepths) > 0 {
+		depth = cg.tryDepths[len(cg.tryDepths)-1]
+	}
+	var unguarded []deferredExp
+	for _, d := range cg.env.DeferredSince(depth) {
+		if d.frame == "" {
+			unguarded = append(unguarded, d)
+		}
+	}
+	if err := cg.emitDeferred(unguarded); err != nil {
+		return err
+	}
+	raw := cg.ng.nextReg()
+	cg.write.Bitcast(raw, cg.toLlvmType(s.Exp.Type()), value, llvmgen.I8.Ptr())
+	cg.write.Call(
+		"", llvmgen.Void, llvmgen.Global("jl.throw"),
+		llvmgen.Arg(llvmgen.I8.Ptr(), raw),
+	)
+	return cg.write.Unreachable()
+}
+
+func (cg *CodeGenerator) compileTryStm(s *tast.TryStm) error {
+	if err := cg.emitTryDecls(); err != nil {
+		return err
+	}
+
+	// enter a new frame, to which setjmp returns nonzero when a value is
+	// thrown inside the try block
+	frame := cg.ng.nextReg()
+	cg.write.Alloca(frame, tryFrameType)
+	// the frame is moved to the entry block when the function ends, so that
+	// a try in a loop does not grow the stack on every iteration
+	cg.hoistAllocs = true
+	cg.write.Call(
+		"", llvmgen.Void, llvmgen.Global("jl.enterTry"),
+		llvmgen.Arg(tryFrameType.Ptr(), frame),
+	)
+	buf := cg.ng.nextReg()
+	cg.write.Bitcast(buf, tryFrameType.Ptr(), frame, llvmgen.I8.Ptr())
+	jumped := cg.ng.nextReg()
+	cg.write.Call(
+		jumped, llvmgen.I32, llvmgen.Global("_setjmp"),
+		llvmgen.Arg(llvmgen.I8.Ptr(), buf),
+	)
+	thrown := cg.ng.nextReg()
+	cg.write.CmpNe(thrown, llvmgen.I32, jumped, llvmgen.LitInt(0))
+
+	bodyLabel := cg.ng.nextLab()
+	dispatchLabel := cg.ng.nextLab()
+	endLabel := cg.ng.nextLab()
+	cg.write.BrIf(llvmgen.I1, thrown, dispatchLabel, bodyLabel)
+
+	// try block, exiting the frame unless it returns
+	cg.write.Label(bodyLabel)
+	cg.tryFrames = append(cg.tryFrames, frame)
+	cg.tryDepths = append(cg.tryDepths, cg.env.Depth())
+	cg.env.EnterContext()
+	for _, stm := range s.Stms {
+		if err := cg.compileStm(stm); err != nil {
+			return err
+		}
+		if tast.GuaranteesReturn(stm) {
+			break
+		}
+	}
+	returns := slices.ContainsFunc(s.Stms, tast.GuaranteesReturn)
+	if !returns {
+		if err := cg.emitDeferred(cg.env.Deferred()); err != nil {
+			return err
+		}
+	}
+	cg.env.ExitContext()
+	cg.tryFrames = cg.tryFrames[:len(cg.tryFrames)-1]
+	cg.tryDepths = cg.tryDepths[:len(cg.tryDepths)-1]
+	if !returns {
+		cg.write.Call(
+			"", llvmgen.Void, llvmgen.Global("jl.exitTry"),
+			llvmgen.Arg(tryFrameType.Ptr(), frame),
+		)
+		cg.write.Br(endLabel)
+	}
+
+	// the frame is exited before dispatching, so that values thrown by the
+	// clauses or not handled by them propagate to the enclosing frame
+	cg.write.Label(dispatchLabel)
+	cg.write.Call(
+		"", llvmgen.Void, llvmgen.Global("jl.exitTry"),
+		llvmgen.Arg(tryFrameType.Ptr(), frame),
+	)
+	exception := cg.ng.nextReg()
+	cg.write.Call(exception, llvmgen.I8.Ptr(), llvmgen.Global("jl.caught"))
+	errorType := cg.toLlvmType(tast.RegisterError())
+	errorPtr := cg.ng.nextReg()
+	cg.write.Bitcast(errorPtr, llvmgen.I8.Ptr(), exception, errorType.Ptr())
+	kindPtr := cg.ng.nextReg()
+	cg.write.GetElementPtr(
+		kindPtr, errorType, errorType.Ptr(), errorPtr,
+		llvmgen.LitInt(0), llvmgen.LitInt(0),
+	)
+	kind := cg.ng.nextReg()
+	cg.write.Load(kind, llvmgen.I32, llvmgen.I32.Ptr(), kindPtr)
+
+	// each kind is handled by the first clause catching it
+	clauseLabels := make([]string, len(s.Catches))
+	var switchCases []llvmgen.SwitchCase
+	handled := make(map[int]struct{})
+	for i, c := range s.Catches {
+		clauseLabels[i] = cg.ng.nextLab()
+		for errorKind, errorType := range cg.errorTypes {
+			if _, exists := handled[errorKind]; exists {
+				continue
+			}
+			if errorType.IsSubtypeOf(c.Error.Name) {
+				handled[errorKind] = struct{}{}
+				switchCases = append(switchCases, llvmgen.Case(
+					llvmgen.LitInt(errorKind), clauseLabels[i],
+				))
+			}
+		}
+	}
+	rethrowLabel := cg.ng.nextLab()
+	cg.write.Switch(llvmgen.I32, kind, rethrowLabel, switchCases...)
+
+	for i, c := range s.Catches {
+		cg.write.Label(clauseLabels[i])
+		cg.env.EnterContext()
+		llvmType := cg.toLlvmType(c.Type)
+		caught := cg.ng.nextReg()
+		cg.write.Bitcast(caught, llvmgen.I8.Ptr(), exception, llvmType)
+		if _, err := cg.emitVarAlloc(c.Id, llvmType, caught); err != nil {
+			return err
+		}
+		if err := cg.compileCaseStms(c.Stms, endLabel); err != nil {
+			return err
+		}
+		cg.env.ExitContext()
+	}
+
+	cg.write.Label(rethrowLabel)
+	cg.write.Call(
+		"", llvmgen.Void, llvmgen.Global("jl.throw"),
+		llvmgen.Arg(llvmgen.I8.Ptr(), exception),
+	)
+	cg.write.Unreachable()
+
+	// only emit the end label if the try block or a clause does not return
+	if !tast.GuaranteesReturn(s) {
+		cg.write.Label(endLabel)
+	}
+	return nil
+}
+
+// emitExitTryFrames exits the try frames and the frames guarding deferred
+// expressions entered by the current function before it returns, by exiting
+// the outermost one.
+func (cg *CodeGenerator) emitExitTryFrames() {
+	guard, guardDepth, guarded := cg.env.OutermostGuard()
+	if len(cg.tryFrames) == 0 && !guarded {
+		return
+	}
+	// a deferred expression guarded in a context enclosing the outermost try
+	// block was deferred before entering it
+	outermost := guard
+	if len(cg.tryFrames) > 0 && (!guarded || guardDepth >= cg.tryDepths[0]) {
+		outermost = cg.tryFrames[0]
+	}
+	cg.write.Call(
+		"", llvmgen.Void, llvmgen.Global("jl.exitTry"),
+		llvmgen.Arg(tryFrameType.Ptr(), outermost),
+	)
+}
+
+// emitErrorKind stores the kind of the struct extending Error pointed to by
+// structPtr in its hidden first field.
+func (cg *CodeGenerator) emitErrorKind(
+	structType *llvmgen.StructType, structPtr llvmgen.Value,
+) error {
+	errorKind, ok := cg.errorKinds[structType.Name]
+	if !ok {
+		return fmt.Errorf(
+			"internal compiler error in emitErrorKind: unknown error '%s'",
+			structType.Name,
+		)
+	}
+	kindPtr := cg.ng.nextReg()
+	cg.write.GetElementPtr(
+		kindPtr, structType, structType.Ptr(), structPtr,
+		llvmgen.LitInt(0), llvmgen.LitInt(0),
+	)
+	return cg.write.Store(
+		llvmgen.I32, llvmgen.LitInt(errorKind), llvmgen.I32.Ptr(), kindPtr,
+	)
+}
+
+// emitDeferGuard enters a frame guarding the expression just deferred, so that
+// it also runs when a value thrown by a callee leaves its block. The value is
+// thrown again once the expression has run, to run the expressions deferred
+// before it and reach the frames of the callers.
+func (cg *CodeGenerator) emitDeferGuard() error {
+	if err := cg.emitTryDecls(); err != nil {
+		return err
+	}
+	frame := cg.ng.nextReg()
+	cg.write.Alloca(frame, tryFrameType)
+	cg.hoistAllocs = true
+	cg.write.Call(
+		"", llvmgen.Void, llvmgen.Global("jl.enterTry"),
+		llvmgen.Arg(tryFrameType.Ptr(), frame),
+	)
+	buf := cg.ng.nextReg()
+	cg.write.Bitcast(buf, tryFrameType.Ptr(), frame, llvmgen.I8.Ptr())
+	jumped := cg.ng.nextReg()
+	cg.write.Call(
+		jumped, llvmgen.I32, llvmgen.Global("_setjmp"),
+		llvmgen.Arg(llvmgen.I8.Ptr(), buf),
+	)
+	thrown := cg.ng.nextReg()
+	cg.write.CmpNe(thrown, llvmgen.I32, jumped, llvmgen.LitInt(0))
+	handlerLabel := cg.ng.nextLab()
+	contLabel := cg.ng.nextLab()
+	cg.write.BrIf(llvmgen.I1, thrown, handlerLabel, contLabel)
+
+	cg.write.Label(handlerLabel)
+	deferred := cg.env.GuardDeferred(frame)
+	if err := cg.emitDeferred([]deferredExp{deferred}); err != nil {
+		return err
+	}
+	exception := cg.ng.nextReg()
+	cg.write.Call(exception, llvmgen.I8.Ptr(), llvmgen.Global("jl.caught"))
+	cg.write.Call(
+		"", llvmgen.Void, llvmgen.Global("jl.throw"),
+		llvmgen.Arg(llvmgen.I8.Ptr(), exception),
+	)
+	cg.write.Unreachable()
+
+	cg.write.Label(contLabel)
+	return nil
+}
+
+// callsMayThrow reports whether stms call a function that may throw, which
+// are all functions but the extern and standard ones. Lambdas only call
+// functions when they are called themselves.
+func (cg *CodeGenerator) callsMayThrow(stms []tast.Stm) bool {
+	return slices.ContainsFunc(stms, cg.stmCallsMayThrow)
+}
+
+func (cg *CodeGenerator) stmCallsMayThrow(stm tast.Stm) bool {
+	switch s := stm.(type) {
+	case *tast.ExpStm:
+		return cg.expCallsMayThrow(s.Exp)
+	case *tast.DeclsStm:
+		for _, item := range s.Items {
+			if i, ok := item.(*tast.InitItem); ok && cg.expCallsMayThrow(i.Exp) {
+				return true
+			}
+		}
+	case *tast.TupleDeclStm:
+		return cg.expCallsMayThrow(s.Exp)
+	case *tast.ReturnStm:
+		return cg.expCallsMayThrow(s.Exp)
+	case *tast.ForEachStm:
+		return cg.expCallsMayThrow(s.Exp) || cg.stmCallsMayThrow(s.Stm)
+	case *tast.WhileStm:
+		return cg.expCallsMayThrow(s.Exp) || cg.stmCallsMayThrow(s.Stm)
+	case *tast.BlockStm:
+		return cg.callsMayThrow(s.Stms)
+	case *tast.IfStm:
+		return cg.expCallsMayThrow(s.Exp) || cg.stmCallsMayThrow(s.ThenStm) ||
+			(s.ElseStm != nil && cg.stmCallsMayThrow(s.ElseStm))
+	case *tast.SwitchStm:
+		if cg.expCallsMayThrow(s.Exp) {
+			return true
+		}
+		for _, c := range s.Cases {
+			if cg.callsMayThrow(c.Stms) {
+				return true
+			}
+		}
+		return s.Default != nil && cg.callsMayThrow(s.Default.Stms)
+	case *tast.ThrowStm:
+		return cg.expCallsMayThrow(s.Exp)
+	case *tast.TryStm:
+		if cg.callsMayThrow(s.Stms) {
+			return true
+		}
+		for _, c := range s.Catches {
+			if cg.callsMayThrow(c.Stms) {
+				return true
+			}
+		}
+	case *tast.AssertStm:
+		return cg.expCallsMayThrow(s.Exp) ||
+			(s.Message != nil && cg.expCallsMayThrow(s.Message))
+	case *tast.DeferStm:
+		return cg.expCallsMayThrow(s.Exp)
+	}
+	return false
+}
+
+func (cg *CodeGenerator) expCallsMayThrow(exp tast.Exp) bool {
+	switch e := exp.(type) {
+	case *tast.FuncExp:
+		if !cg.isStdOrExtern(e.Id) {
+			return true
+		}
+		return slices.ContainsFunc(e.Exps, cg.expCallsMayThrow)
+	case *tast.CallExp:
+		return true
+	case *tast.ParenExp:
+		return cg.expCallsMayThrow(e.Exp)
+	case *tast.IntToDoubleExp:
+		return cg.expCallsMayThrow(e.Exp)
+	case *tast.NegExp:
+		return cg.expCallsMayThrow(e.Exp)
+	case *tast.NotExp:
+		return cg.expCallsMayThrow(e.Exp)
+	case *tast.PostExp:
+		return cg.expCallsMayThrow(e.Exp)
+	case *tast.PreExp:
+		return cg.expCallsMayThrow(e.Exp)
+	case *tast.DerefExp:
+		return cg.expCallsMayThrow(e.Exp)
+	case *tast.FieldExp:
+		return cg.expCallsMayThrow(e.Exp)
+	case *tast.EnumNameExp:
+		return cg.expCallsMayThrow(e.Exp)
+	case *tast.MulExp:
+		return cg.expCallsMayThrow(e.LeftExp) || cg.expCallsMayThrow(e.RightExp)
+	case *tast.AddExp:
+		return cg.expCallsMayThrow(e.LeftExp) || cg.expCallsMayThrow(e.RightExp)
+	case *tast.CmpExp:
+		return cg.expCallsMayThrow(e.LeftExp) || cg.expCallsMayThrow(e.RightExp)
+	case *tast.AndExp:
+		return cg.expCallsMayThrow(e.LeftExp) || cg.expCallsMayThrow(e.RightExp)
+	case *tast.OrExp:
+		return cg.expCallsMayThrow(e.LeftExp) || cg.expCallsMayThrow(e.RightExp)
+	case *tast.AssignExp:
+		return cg.expCallsMayThrow(e.ExpLhs) || cg.expCallsMayThrow(e.Exp)
+	case *tast.ArrIndexExp:
+		return cg.expCallsMayThrow(e.Exp) ||
+			slices.ContainsFunc(e.IdxExps, cg.expCallsMayThrow)
+	case *tast.NewArrExp:
+		return slices.ContainsFunc(e.Exps, cg.expCallsMayThrow)
+	case *tast.TupleExp:
+		return slices.ContainsFunc(e.Elems, cg.expCallsMayThrow)
+	case *tast.StructLitExp:
+		return slices.ContainsFunc(e.Exps, cg.expCallsMayThrow)
+	}
+	return false
+}
+
+// stdFuncs are the functions of the runtime, none of which throws.
+var stdFuncs = map[string]struct{}{
+	"printInt": {}, "printDouble": {}, "printString": {},
+	"readInt": {}, "readDouble": {},
+}
+
+// isStdOrExtern reports whether calls to the function name go to the runtime
+// or to C, which never throw.
+func (cg *CodeGenerator) isStdOrExtern(name string) bool {
+	if _, ok := cg.externs[name]; ok {
+		return true
+	}
+	_, isStd := stdFuncs[name]
+	_, isOverloaded := cg.overloaded[name]
+	return isStd && !isOverloaded
+}
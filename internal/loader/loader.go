@@ -0,0 +1,154 @@
+// Package loader provides tools for reading a Javalette program spread over
+// several files. The main entry point is the Loader type, which parses a root
+// file and, transitively, every file it imports, resolving import paths
+// relative to the importing file and then to a list of search directories.
+package loader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/aramyamal/javalette-to-llvm-compiler/gen/parser"
+)
+
+// Module is a single parsed Javalette file.
+type Module struct {
+	Path    string                  // cleaned path of the file, empty for stdin
+	Tree    *parser.PrgmContext     // parse tree of the file
+	Parser  *parser.JavaletteParser // parser that produced Tree
+	Imports []*Module               // modules imported directly by the file
+}
+
+// Loader parses Javalette files and the files they import.
+type Loader struct {
+	searchPath []string
+	listeners  []antlr.ErrorListener
+	modules    map[string]*Module // loaded modules by path
+	loading    []string           // paths of modules currently being loaded
+	order      []*Module          // loaded modules, imports before importers
+}
+
+// NewLoader creates and returns a new Loader that looks for imported files in
+// the directories of searchPath when they are not found next to the importing
+// file. Syntax errors are reported to listeners.
+func NewLoader(
+	searchPath []string, listeners ...antlr.ErrorListener,
+) *Loader {
+	return &Loader{
+		searchPath: searchPath,
+		listeners:  listeners,
+		modules:    make(map[string]*Module),
+	}
+}
+
+// LoadFile parses the file at path and every file it imports. The modules are
+// returned in dependency order, such that every module comes after the modules
+// it imports and the module of path comes last.
+func (l *Loader) LoadFile(path string) ([]*Module, error) {
+	if _, err := l.loadFile(filepath.Clean(path)); err != nil {
+		return nil, err
+	}
+	return l.order, nil
+}
+
+// LoadStream parses a program read from stream, such as standard input, and
+// every file it imports relative to dir. The modules are returned in the same
+// order as for LoadFile.
+func (l *Loader) LoadStream(
+	stream antlr.CharStream, dir string,
+) ([]*Module, error) {
+	if _, err := l.load("", dir, stream); err != nil {
+		return nil, err
+	}
+	return l.order, nil
+}
+
+func (l *Loader) loadFile(path string) (*Module, error) {
+	if module, exists := l.modules[path]; exists {
+		return module, nil
+	}
+
+	// a module imported while it is being loaded closes an import cycle
+	for i, loading := range l.loading {
+		if loading == path {
+			cycle := append(slices.Clone(l.loading[i:]), path)
+			return nil, fmt.Errorf(
+				"import cycle: %s", strings.Join(cycle, " -> "),
+			)
+		}
+	}
+
+	stream, err := antlr.NewFileStream(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file %s: %w", path, err)
+	}
+	return l.load(path, filepath.Dir(path), stream)
+}
+
+func (l *Loader) load(
+	path string, dir string, stream antlr.CharStream,
+) (*Module, error) {
+	lexer := parser.NewJavaletteLexer(stream)
+	lexer.RemoveErrorListeners()
+	tokens := antlr.NewCommonTokenStream(lexer, 0)
+	p := parser.NewJavaletteParser(tokens)
+	p.RemoveErrorListeners()
+	for _, listener := range l.listeners {
+		lexer.AddErrorListener(listener)
+		p.AddErrorListener(listener)
+	}
+
+	tree, ok := p.Prgm().(*parser.PrgmContext)
+	if !ok {
+		return nil, fmt.Errorf("expected *parser.PrgmContext for %s", path)
+	}
+	module := &Module{Path: path, Tree: tree, Parser: p}
+
+	l.loading = append(l.loading, path)
+	for _, decl := range tree.AllImportDecl() {
+		importPath, err := l.resolve(decl, dir)
+		if err != nil {
+			return nil, err
+		}
+		imported, err := l.loadFile(importPath)
+		if err != nil {
+			return nil, err
+		}
+		module.Imports = append(module.Imports, imported)
+	}
+	l.loading = l.loading[:len(l.loading)-1]
+
+	if path != "" {
+		l.modules[path] = module
+	}
+	l.order = append(l.order, module)
+	return module, nil
+}
+
+// resolve returns the path of the file imported by decl, looking first in dir
+// and then in the directories of the search path.
+func (l *Loader) resolve(
+	decl parser.IImportDeclContext, dir string,
+) (string, error) {
+	quoted := decl.String_().GetText()
+	name := quoted[1 : len(quoted)-1]
+	if filepath.IsAbs(name) {
+		return filepath.Clean(name), nil
+	}
+
+	for _, searchDir := range append([]string{dir}, l.searchPath...) {
+		path := filepath.Clean(filepath.Join(searchDir, name))
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf(
+		"imported file '%s' not found at %d:%d near '%s'",
+		name, decl.GetStart().GetLine(), decl.GetStart().GetColumn(),
+		decl.GetText(),
+	)
+}
@@ -0,0 +1,159 @@
+// Package optimize provides the optimization passes of the compiler, which
+// transform either the typed abstract syntax tree (TAST) before code
+// generation or the LLVM IR module built by llvmgen before it is printed.
+package optimize
+
+import "github.com/aramyamal/javalette-to-llvm-compiler/pkg/llvmgen"
+
+// cfg is the control flow graph of the blocks of a function reachable from
+// its entry, with the dominator tree and dominance frontiers of the blocks.
+type cfg struct {
+	blocks   []*llvmgen.BasicBlock // reachable blocks in reverse postorder
+	order    map[*llvmgen.BasicBlock]int
+	byName   map[string]*llvmgen.BasicBlock
+	succs    map[*llvmgen.BasicBlock][]*llvmgen.BasicBlock
+	preds    map[*llvmgen.BasicBlock][]*llvmgen.BasicBlock
+	idom     map[*llvmgen.BasicBlock]*llvmgen.BasicBlock
+	children map[*llvmgen.BasicBlock][]*llvmgen.BasicBlock
+	frontier map[*llvmgen.BasicBlock][]*llvmgen.BasicBlock
+}
+
+func newCFG(f *llvmgen.Function) *cfg {
+	g := &cfg{
+		order:    make(map[*llvmgen.BasicBlock]int),
+		byName:   make(map[string]*llvmgen.BasicBlock),
+		succs:    make(map[*llvmgen.BasicBlock][]*llvmgen.BasicBlock),
+		preds:    make(map[*llvmgen.BasicBlock][]*llvmgen.BasicBlock),
+		idom:     make(map[*llvmgen.BasicBlock]*llvmgen.BasicBlock),
+		children: make(map[*llvmgen.BasicBlock][]*llvmgen.BasicBlock),
+		frontier: make(map[*llvmgen.BasicBlock][]*llvmgen.BasicBlock),
+	}
+	if len(f.Blocks) == 0 {
+		return g
+	}
+	for _, block := range f.Blocks {
+		g.byName[block.Name] = block
+	}
+	g.computeOrder(f.Blocks[0])
+	for _, block := range g.blocks {
+		for _, succ := range g.succs[block] {
+			g.preds[succ] = append(g.preds[succ], block)
+		}
+	}
+	g.computeDominators()
+	g.computeFrontiers()
+	return g
+}
+
+// computeOrder finds the blocks reachable from entry by a depth first search
+// and orders them in reverse postorder.
+func (g *cfg) computeOrder(entry *llvmgen.BasicBlock) {
+	visited := map[*llvmgen.BasicBlock]bool{entry: true}
+	var postorder []*llvmgen.BasicBlock
+	var visit func(block *llvmgen.BasicBlock)
+	visit = func(block *llvmgen.BasicBlock) {
+		for _, label := range block.Succs() {
+			succ, ok := g.byName[label]
+			if !ok {
+				continue
+			}
+			g.succs[block] = append(g.succs[block], succ)
+			if !visited[succ] {
+				visited[succ] = true
+				visit(succ)
+			}
+		}
+		postorder = append(postorder, block)
+	}
+	visit(entry)
+	for i := len(postorder) - 1; i >= 0; i-- {
+		g.order[postorder[i]] = len(g.blocks)
+		g.blocks = append(g.blocks, postorder[i])
+	}
+}
+
+// computeDominators finds the immediate dominator of every reachable block
+// using the iterative algorithm of Cooper, Harvey and Kennedy.
+func (g *cfg) computeDominators() {
+	entry := g.blocks[0]
+	g.idom[entry] = entry
+	for changed := true; changed; {
+		changed = false
+		for _, block := range g.blocks[1:] {
+			var newIdom *llvmgen.BasicBlock
+			for _, pred := range g.preds[block] {
+				if _, ok := g.idom[pred]; !ok {
+					continue
+				}
+				if newIdom == nil {
+					newIdom = pred
+				} else {
+					newIdom = g.intersect(pred, newIdom)
+				}
+			}
+			if g.idom[block] != newIdom {
+				g.idom[block] = newIdom
+				changed = true
+			}
+		}
+	}
+	for _, block := range g.blocks[1:] {
+		g.children[g.idom[block]] = append(g.children[g.idom[block]], block)
+	}
+}
+
+func (g *cfg) intersect(b1, b2 *llvmgen.BasicBlock) *llvmgen.BasicBlock {
+	for b1 != b2 {
+		for g.order[b1] > g.order[b2] {
+			b1 = g.idom[b1]
+		}
+		for g.order[b2] > g.order[b1] {
+			b2 = g.idom[b2]
+		}
+	}
+	return b1
+}
+
+func (g *cfg) computeFrontiers() {
+	for _, block := range g.blocks {
+		if len(g.preds[block]) < 2 {
+			continue
+		}
+		for _, pred := range g.preds[block] {
+			for runner := pred; runner != g.idom[block]; runner = g.idom[runner] {
+				if !containsBlock(g.frontier[runner], block) {
+					g.frontier[runner] = append(g.frontier[runner], block)
+				}
+			}
+		}
+	}
+}
+
+// reachable reports whether block is reachable from the entry block.
+func (g *cfg) reachable(block *llvmgen.BasicBlock) bool {
+	_, ok := g.order[block]
+	return ok
+}
+
+// dominates reports whether every path from the entry to b2 passes b1.
+func (g *cfg) dominates(b1, b2 *llvmgen.BasicBlock) bool {
+	for {
+		if b1 == b2 {
+			return true
+		}
+		idom := g.idom[b2]
+		if idom == b2 || idom == nil {
+			return false
+		}
+		b2 = idom
+	}
+}
+
+func containsBlock(blocks []*llvmgen.BasicBlock, block *llvmgen.BasicBlock) bool {
+	for _, b := range blocks {
+		if b == block {
+			return true
+		}
+	}
+	return false
+}
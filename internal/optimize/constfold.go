@@ -0,0 +1,489 @@
+package optimize
+
+import (
+	"math"
+
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+)
+
+// FoldConstants folds the arithmetic, comparisons and logic of int, double and
+// boolean constants in the function bodies of prgm, and propagates the
+// constant initializers of locals that are never assigned after their
+// declaration. Integer arithmetic wraps around like 32-bit machine integers,
+// while divisions by zero and other operations that trap or produce values
+// without a literal, such as infinities, are left to run.
+func FoldConstants(prgm *tast.Prgm) {
+	for _, def := range prgm.Defs {
+		funcDef, ok := def.(*tast.FuncDef)
+		if !ok {
+			continue
+		}
+		// the first walk finds the locals that are assigned, the second folds
+		// with the constants of the others
+		cf := &constFolder{assigned: make(map[*tast.InitItem]bool)}
+		cf.funcDef(funcDef)
+		cf.rewrite = true
+		cf.funcDef(funcDef)
+	}
+}
+
+// binding is a local variable in scope, holding the literal it is known to be
+// equal to, if any.
+type binding struct {
+	item  *tast.InitItem // declaring item, nil if not declared by one
+	value tast.Exp
+}
+
+type constFolder struct {
+	rewrite  bool
+	scopes   []map[string]*binding
+	assigned map[*tast.InitItem]bool
+}
+
+func (cf *constFolder) enterScope() {
+	cf.scopes = append(cf.scopes, make(map[string]*binding))
+}
+
+func (cf *constFolder) exitScope() {
+	cf.scopes = cf.scopes[:len(cf.scopes)-1]
+}
+
+func (cf *constFolder) declare(id string, b *binding) {
+	cf.scopes[len(cf.scopes)-1][id] = b
+}
+
+func (cf *constFolder) lookup(id string) *binding {
+	for i := len(cf.scopes) - 1; i >= 0; i-- {
+		if b, ok := cf.scopes[i][id]; ok {
+			return b
+		}
+	}
+	return nil
+}
+
+func (cf *constFolder) funcDef(d *tast.FuncDef) {
+	cf.enterScope()
+	defer cf.exitScope()
+	for _, arg := range d.Args {
+		cf.declare(arg.(*tast.ParamArg).Id, &binding{})
+	}
+	cf.stms(d.Stms)
+}
+
+// scopedStms folds stms in a scope of their own.
+func (cf *constFolder) scopedStms(stms []tast.Stm) {
+	cf.enterScope()
+	defer cf.exitScope()
+	cf.stms(stms)
+}
+
+func (cf *constFolder) stms(stms []tast.Stm) {
+	for _, stm := range stms {
+		cf.stm(stm)
+	}
+}
+
+// scopedStm folds stm, such as the body of a loop, in a scope of its own.
+func (cf *constFolder) scopedStm(stm tast.Stm) {
+	cf.enterScope()
+	defer cf.exitScope()
+	cf.stm(stm)
+}
+
+func (cf *constFolder) stm(stm tast.Stm) {
+	switch s := stm.(type) {
+	case *tast.ExpStm:
+		s.Exp = cf.exp(s.Exp)
+	case *tast.DeclsStm:
+		for _, item := range s.Items {
+			cf.item(item)
+		}
+	case *tast.TupleDeclStm:
+		s.Exp = cf.exp(s.Exp)
+		for _, id := range s.Ids {
+			cf.declare(id, &binding{})
+		}
+	case *tast.ReturnStm:
+		s.Exp = cf.exp(s.Exp)
+	case *tast.ForEachStm:
+		s.Exp = cf.exp(s.Exp)
+		cf.enterScope()
+		cf.declare(s.Id, &binding{})
+		cf.scopedStm(s.Stm)
+		cf.exitScope()
+	case *tast.WhileStm:
+		s.Exp = cf.exp(s.Exp)
+		cf.scopedStm(s.Stm)
+	case *tast.BlockStm:
+		cf.scopedStms(s.Stms)
+	case *tast.IfStm:
+		s.Exp = cf.exp(s.Exp)
+		cf.scopedStm(s.ThenStm)
+		if s.ElseStm != nil {
+			cf.scopedStm(s.ElseStm)
+		}
+	case *tast.SwitchStm:
+		s.Exp = cf.exp(s.Exp)
+		for _, c := range s.Cases {
+			cf.scopedStms(c.Stms)
+		}
+		if s.Default != nil {
+			cf.scopedStms(s.Default.Stms)
+		}
+	case *tast.ThrowStm:
+		s.Exp = cf.exp(s.Exp)
+	case *tast.TryStm:
+		cf.scopedStms(s.Stms)
+		for _, c := range s.Catches {
+			cf.enterScope()
+			cf.declare(c.Id, &binding{})
+			cf.stms(c.Stms)
+			cf.exitScope()
+		}
+	case *tast.AssertStm:
+		s.Exp = cf.exp(s.Exp)
+		if s.Message != nil {
+			s.Message = cf.exp(s.Message)
+		}
+	case *tast.DeferStm:
+		s.Exp = cf.exp(s.Exp)
+	}
+}
+
+func (cf *constFolder) item(item tast.Item) {
+	switch i := item.(type) {
+	case *tast.NoInitItem:
+		cf.declare(i.Id, &binding{})
+	case *tast.InitItem:
+		i.Exp = cf.exp(i.Exp)
+		b := &binding{item: i}
+		if cf.rewrite && !cf.assigned[i] && isLiteralOf(i.Exp, i.Type()) {
+			b.value = i.Exp
+		}
+		cf.declare(i.Id, b)
+	}
+}
+
+// exps folds each of exps in place.
+func (cf *constFolder) exps(exps []tast.Exp) {
+	for i, exp := range exps {
+		exps[i] = cf.exp(exp)
+	}
+}
+
+// exp folds the children of exp, and returns the literal exp is equal to if
+// they are constant, or else exp itself.
+func (cf *constFolder) exp(exp tast.Exp) tast.Exp {
+	switch e := exp.(type) {
+	case *tast.IdentExp:
+		if b := cf.lookup(e.Id); b != nil && b.value != nil {
+			return copyLiteral(b.value, e)
+		}
+	case *tast.ParenExp:
+		e.Exp = cf.exp(e.Exp)
+		if isLiteral(e.Exp) {
+			return copyLiteral(e.Exp, e)
+		}
+	case *tast.IntToDoubleExp:
+		e.Exp = cf.exp(e.Exp)
+		if i, ok := e.Exp.(*tast.IntExp); ok {
+			return tast.NewDoubleExp(float64(i.Value), e.Line(), e.Col(), e.Text())
+		}
+	case *tast.NegExp:
+		e.Exp = cf.exp(e.Exp)
+		switch v := e.Exp.(type) {
+		case *tast.IntExp:
+			return tast.NewIntExp(wrapInt(-int64(v.Value)), e.Line(), e.Col(), e.Text())
+		case *tast.DoubleExp:
+			return tast.NewDoubleExp(-v.Value, e.Line(), e.Col(), e.Text())
+		}
+	case *tast.NotExp:
+		e.Exp = cf.exp(e.Exp)
+		if b, ok := e.Exp.(*tast.BoolExp); ok {
+			return tast.NewBoolExp(!b.Value, e.Line(), e.Col(), e.Text())
+		}
+	case *tast.MulExp:
+		e.LeftExp = cf.exp(e.LeftExp)
+		e.RightExp = cf.exp(e.RightExp)
+		if folded := foldArith(e.Op, e.LeftExp, e.RightExp, e); folded != nil {
+			return folded
+		}
+	case *tast.AddExp:
+		e.LeftExp = cf.exp(e.LeftExp)
+		e.RightExp = cf.exp(e.RightExp)
+		if folded := foldArith(e.Op, e.LeftExp, e.RightExp, e); folded != nil {
+			return folded
+		}
+	case *tast.CmpExp:
+		e.LeftExp = cf.exp(e.LeftExp)
+		e.RightExp = cf.exp(e.RightExp)
+		if folded := foldCmp(e.Op, e.LeftExp, e.RightExp, e); folded != nil {
+			return folded
+		}
+	case *tast.AndExp:
+		e.LeftExp = cf.exp(e.LeftExp)
+		e.RightExp = cf.exp(e.RightExp)
+		if l, ok := e.LeftExp.(*tast.BoolExp); ok {
+			if !l.Value {
+				return tast.NewBoolExp(false, e.Line(), e.Col(), e.Text())
+			}
+			return e.RightExp
+		}
+		if r, ok := e.RightExp.(*tast.BoolExp); ok && r.Value {
+			return e.LeftExp
+		}
+	case *tast.OrExp:
+		e.LeftExp = cf.exp(e.LeftExp)
+		e.RightExp = cf.exp(e.RightExp)
+		if l, ok := e.LeftExp.(*tast.BoolExp); ok {
+			if l.Value {
+				return tast.NewBoolExp(true, e.Line(), e.Col(), e.Text())
+			}
+			return e.RightExp
+		}
+		if r, ok := e.RightExp.(*tast.BoolExp); ok && !r.Value {
+			return e.LeftExp
+		}
+	case *tast.AssignExp:
+		cf.markAssigned(e.ExpLhs)
+		e.ExpLhs = cf.lvalue(e.ExpLhs)
+		e.Exp = cf.exp(e.Exp)
+	case *tast.PostExp:
+		cf.markAssigned(e.Exp)
+		e.Exp = cf.lvalue(e.Exp)
+	case *tast.PreExp:
+		cf.markAssigned(e.Exp)
+		e.Exp = cf.lvalue(e.Exp)
+	case *tast.NewArrExp:
+		cf.exps(e.Exps)
+	case *tast.FuncExp:
+		cf.exps(e.Exps)
+	case *tast.CallExp:
+		e.Exp = cf.exp(e.Exp)
+		cf.exps(e.Exps)
+	case *tast.EnumNameExp:
+		e.Exp = cf.exp(e.Exp)
+	case *tast.LambdaExp:
+		// the captures are left as is, since they are copied by name into the
+		// environment of the closure
+		cf.enterScope()
+		for _, arg := range e.Args {
+			cf.declare(arg.(*tast.ParamArg).Id, &binding{})
+		}
+		e.Exp = cf.exp(e.Exp)
+		cf.exitScope()
+	case *tast.TupleExp:
+		cf.exps(e.Elems)
+	case *tast.StructLitExp:
+		cf.exps(e.Exps)
+	case *tast.ArrIndexExp:
+		e.Exp = cf.exp(e.Exp)
+		cf.exps(e.IdxExps)
+	case *tast.FieldExp:
+		e.Exp = cf.exp(e.Exp)
+	case *tast.DerefExp:
+		e.Exp = cf.exp(e.Exp)
+	}
+	return exp
+}
+
+// lvalue folds the subexpressions of the assigned expression exp, such as
+// array indices, leaving the assigned variable itself in place.
+func (cf *constFolder) lvalue(exp tast.Exp) tast.Exp {
+	switch e := exp.(type) {
+	case *tast.IdentExp:
+		return e
+	case *tast.ParenExp:
+		e.Exp = cf.lvalue(e.Exp)
+		return e
+	default:
+		return cf.exp(exp)
+	}
+}
+
+// markAssigned records that the local assigned by exp, if any, is not
+// constant.
+func (cf *constFolder) markAssigned(exp tast.Exp) {
+	for {
+		paren, ok := exp.(*tast.ParenExp)
+		if !ok {
+			break
+		}
+		exp = paren.Exp
+	}
+	ident, ok := exp.(*tast.IdentExp)
+	if !ok {
+		return
+	}
+	if b := cf.lookup(ident.Id); b != nil && b.item != nil {
+		cf.assigned[b.item] = true
+	}
+}
+
+func foldArith(op tast.Op, left, right tast.Exp, at tast.Node) tast.Exp {
+	switch l := left.(type) {
+	case *tast.IntExp:
+		r, ok := right.(*tast.IntExp)
+		if !ok {
+			return nil
+		}
+		a, b := int64(l.Value), int64(r.Value)
+		var v int64
+		switch op {
+		case tast.OpAdd:
+			v = a + b
+		case tast.OpSub:
+			v = a - b
+		case tast.OpMul:
+			v = a * b
+		case tast.OpDiv, tast.OpMod:
+			// division by zero and the overflowing division of the smallest
+			// int by -1 trap at runtime
+			if b == 0 || (a == math.MinInt32 && b == -1) {
+				return nil
+			}
+			if op == tast.OpDiv {
+				v = a / b
+			} else {
+				v = a % b
+			}
+		default:
+			return nil
+		}
+		return tast.NewIntExp(wrapInt(v), at.Line(), at.Col(), at.Text())
+	case *tast.DoubleExp:
+		r, ok := right.(*tast.DoubleExp)
+		if !ok {
+			return nil
+		}
+		var v float64
+		switch op {
+		case tast.OpAdd:
+			v = l.Value + r.Value
+		case tast.OpSub:
+			v = l.Value - r.Value
+		case tast.OpMul:
+			v = l.Value * r.Value
+		case tast.OpDiv:
+			v = l.Value / r.Value
+		default:
+			return nil
+		}
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil
+		}
+		return tast.NewDoubleExp(v, at.Line(), at.Col(), at.Text())
+	}
+	return nil
+}
+
+func foldCmp(op tast.Op, left, right tast.Exp, at tast.Node) tast.Exp {
+	var cmp int
+	var ordered bool
+	switch l := left.(type) {
+	case *tast.IntExp:
+		r, ok := right.(*tast.IntExp)
+		if !ok {
+			return nil
+		}
+		cmp, ordered = compare(float64(l.Value), float64(r.Value)), true
+	case *tast.DoubleExp:
+		r, ok := right.(*tast.DoubleExp)
+		if !ok {
+			return nil
+		}
+		cmp, ordered = compare(l.Value, r.Value), true
+	case *tast.BoolExp:
+		r, ok := right.(*tast.BoolExp)
+		if !ok {
+			return nil
+		}
+		cmp = 1
+		if l.Value == r.Value {
+			cmp = 0
+		}
+	default:
+		return nil
+	}
+
+	var v bool
+	switch op {
+	case tast.OpEq:
+		v = cmp == 0
+	case tast.OpNe:
+		v = cmp != 0
+	case tast.OpLt:
+		v = cmp < 0
+	case tast.OpLe:
+		v = cmp <= 0
+	case tast.OpGt:
+		v = cmp > 0
+	case tast.OpGe:
+		v = cmp >= 0
+	}
+	if !ordered && op != tast.OpEq && op != tast.OpNe {
+		return nil
+	}
+	return tast.NewBoolExp(v, at.Line(), at.Col(), at.Text())
+}
+
+func compare(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// wrapInt truncates v to a 32-bit integer, like the overflowing arithmetic of
+// the generated code.
+func wrapInt(v int64) int {
+	return int(int32(v))
+}
+
+func isLiteral(exp tast.Exp) bool {
+	switch exp.(type) {
+	case *tast.IntExp, *tast.DoubleExp, *tast.BoolExp:
+		return true
+	default:
+		return false
+	}
+}
+
+// isLiteralOf reports whether exp is a literal of typ, which may be a typedef.
+func isLiteralOf(exp tast.Exp, typ tast.Type) bool {
+	for {
+		td, ok := typ.(*tast.TypedefType)
+		if !ok {
+			break
+		}
+		typ = td.Aliased
+	}
+	switch exp.(type) {
+	case *tast.IntExp:
+		return typ == tast.Int
+	case *tast.DoubleExp:
+		return typ == tast.Double
+	case *tast.BoolExp:
+		return typ == tast.Bool
+	default:
+		return false
+	}
+}
+
+// copyLiteral returns a copy of the literal lit at the location of at.
+func copyLiteral(lit tast.Exp, at tast.Node) tast.Exp {
+	switch l := lit.(type) {
+	case *tast.IntExp:
+		return tast.NewIntExp(l.Value, at.Line(), at.Col(), at.Text())
+	case *tast.DoubleExp:
+		return tast.NewDoubleExp(l.Value, at.Line(), at.Col(), at.Text())
+	case *tast.BoolExp:
+		return tast.NewBoolExp(l.Value, at.Line(), at.Col(), at.Text())
+	default:
+		return lit
+	}
+}
@@ -0,0 +1,154 @@
+package optimize
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+)
+
+// Warning is a diagnostic about a suspicious but valid part of the program,
+// which does not stop its compilation.
+type Warning struct {
+	Msg  string
+	Line int
+	Col  int
+	Text string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s at %d:%d near '%s'", w.Msg, w.Line, w.Col, w.Text)
+}
+
+func warningAt(node tast.Node, format string, args ...any) Warning {
+	return Warning{
+		Msg:  fmt.Sprintf(format, args...),
+		Line: node.Line(),
+		Col:  node.Col(),
+		Text: node.Text(),
+	}
+}
+
+// EliminateDeadCode removes the statements of prgm that can never run or have
+// no effect: statements following one that always returns or throws, the
+// branches not taken by ifs and whiles with constant conditions, and
+// expression statements without side effects, such as those left by folding
+// constants. It returns a warning for each removed piece of code.
+func EliminateDeadCode(prgm *tast.Prgm) []Warning {
+	dce := &deadCodeEliminator{}
+	for _, def := range prgm.Defs {
+		if funcDef, ok := def.(*tast.FuncDef); ok {
+			funcDef.Stms = dce.stms(funcDef.Stms)
+		}
+	}
+	return dce.warnings
+}
+
+type deadCodeEliminator struct {
+	warnings []Warning
+}
+
+func (dce *deadCodeEliminator) warn(node tast.Node, format string, args ...any) {
+	dce.warnings = append(dce.warnings, warningAt(node, format, args...))
+}
+
+// stms returns stms without their dead statements.
+func (dce *deadCodeEliminator) stms(stms []tast.Stm) []tast.Stm {
+	live := make([]tast.Stm, 0, len(stms))
+	for i, stm := range stms {
+		if stm = dce.stm(stm); stm != nil {
+			live = append(live, stm)
+		}
+		if tast.GuaranteesReturn(stm) && i+1 < len(stms) {
+			dce.warn(stms[i+1], "unreachable code")
+			break
+		}
+	}
+	return live
+}
+
+// stm returns stm with its dead code removed, a simpler statement doing the
+// same, or nil if stm does nothing.
+func (dce *deadCodeEliminator) stm(stm tast.Stm) tast.Stm {
+	switch s := stm.(type) {
+	case *tast.ExpStm:
+		if !s.Exp.HasSideEffect() {
+			dce.warn(s, "expression statement has no effect")
+			return nil
+		}
+	case *tast.ForEachStm:
+		s.Stm = dce.body(s.Stm)
+	case *tast.WhileStm:
+		if cond, ok := s.Exp.(*tast.BoolExp); ok && !cond.Value {
+			if isWrittenLiteral(cond) {
+				dce.warn(s, "loop condition is always false")
+			}
+			return nil
+		}
+		s.Stm = dce.body(s.Stm)
+	case *tast.BlockStm:
+		s.Stms = dce.stms(s.Stms)
+	case *tast.IfStm:
+		cond, ok := s.Exp.(*tast.BoolExp)
+		if !ok {
+			s.ThenStm = dce.body(s.ThenStm)
+			if s.ElseStm != nil {
+				s.ElseStm = dce.body(s.ElseStm)
+			}
+			return s
+		}
+		if isWrittenLiteral(cond) {
+			dce.warn(s, "condition is always %t", cond.Value)
+		}
+		taken := s.ThenStm
+		if !cond.Value {
+			taken = s.ElseStm
+		}
+		if taken == nil {
+			return nil
+		}
+		return dce.scoped(dce.stm(taken), taken)
+	case *tast.SwitchStm:
+		for _, c := range s.Cases {
+			c.Stms = dce.stms(c.Stms)
+		}
+		if s.Default != nil {
+			s.Default.Stms = dce.stms(s.Default.Stms)
+		}
+	case *tast.TryStm:
+		s.Stms = dce.stms(s.Stms)
+		for _, c := range s.Catches {
+			c.Stms = dce.stms(c.Stms)
+		}
+	case *tast.BlankStm:
+		return nil
+	}
+	return stm
+}
+
+// isWrittenLiteral reports whether cond is written as a literal in the program, rather
+// than folded from an expression or propagated from a local.
+func isWrittenLiteral(cond *tast.BoolExp) bool {
+	return cond.Text() == strconv.FormatBool(cond.Value)
+}
+
+// body returns the body of a loop or branch of an if with its dead code
+// removed, which is blank if nothing remains.
+func (dce *deadCodeEliminator) body(stm tast.Stm) tast.Stm {
+	if live := dce.stm(stm); live != nil {
+		return live
+	}
+	return tast.NewBlankStm(stm.Line(), stm.Col(), stm.Text())
+}
+
+// scoped wraps stm, which replaces the statement at the location of at, in a
+// block so that its declarations stay local to it.
+func (dce *deadCodeEliminator) scoped(stm tast.Stm, at tast.Node) tast.Stm {
+	if stm == nil {
+		return nil
+	}
+	if _, ok := stm.(*tast.BlockStm); ok {
+		return stm
+	}
+	return tast.NewBlockStm([]tast.Stm{stm}, at.Line(), at.Col(), at.Text())
+}
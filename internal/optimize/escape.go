@@ -0,0 +1,313 @@
+package optimize
+
+import "github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+
+// maxStackArrayLength is the length of the longest array allocated on the
+// stack, so that large arrays do not overflow it.
+const maxStackArrayLength = 1024
+
+// AllocateOnStack marks the structs and fixed-size arrays of prgm that do not
+// outlive the function creating them to be allocated on its stack instead of
+// the heap. Only objects initializing a local variable are considered, and
+// they escape when the variable is used other than to access the fields or
+// elements of the object, or to compare it: returning it, passing it to a
+// function, storing it in another variable or object, or capturing it in a
+// lambda all let the object be reached after the function returns.
+func AllocateOnStack(prgm *tast.Prgm) {
+	for _, def := range prgm.Defs {
+		funcDef, ok := def.(*tast.FuncDef)
+		if !ok {
+			continue
+		}
+		ea := &escapeAnalyzer{}
+		ea.funcDef(funcDef)
+		for _, site := range ea.sites {
+			if site.escapes {
+				continue
+			}
+			switch e := site.exp.(type) {
+			case *tast.NewStructExp:
+				e.OnStack = true
+			case *tast.NewArrExp:
+				e.OnStack = true
+			}
+		}
+	}
+}
+
+// allocSite is an allocation initializing a local variable.
+type allocSite struct {
+	exp     tast.Exp
+	escapes bool
+}
+
+// escapeAnalyzer finds the allocation sites of a function and whether they
+// escape. The scopes map the locals in scope to the allocation they were
+// initialized with, or nil for any other local.
+type escapeAnalyzer struct {
+	scopes []map[string]*allocSite
+	sites  []*allocSite
+}
+
+func (ea *escapeAnalyzer) enterScope() {
+	ea.scopes = append(ea.scopes, make(map[string]*allocSite))
+}
+
+func (ea *escapeAnalyzer) exitScope() {
+	ea.scopes = ea.scopes[:len(ea.scopes)-1]
+}
+
+func (ea *escapeAnalyzer) declare(id string, site *allocSite) {
+	ea.scopes[len(ea.scopes)-1][id] = site
+}
+
+func (ea *escapeAnalyzer) lookup(id string) *allocSite {
+	for i := len(ea.scopes) - 1; i >= 0; i-- {
+		if site, ok := ea.scopes[i][id]; ok {
+			return site
+		}
+	}
+	return nil
+}
+
+func (ea *escapeAnalyzer) funcDef(d *tast.FuncDef) {
+	ea.enterScope()
+	defer ea.exitScope()
+	for _, arg := range d.Args {
+		ea.declare(arg.(*tast.ParamArg).Id, nil)
+	}
+	ea.stms(d.Stms)
+}
+
+func (ea *escapeAnalyzer) scopedStms(stms []tast.Stm) {
+	ea.enterScope()
+	defer ea.exitScope()
+	ea.stms(stms)
+}
+
+func (ea *escapeAnalyzer) stms(stms []tast.Stm) {
+	for _, stm := range stms {
+		ea.stm(stm)
+	}
+}
+
+func (ea *escapeAnalyzer) scopedStm(stm tast.Stm) {
+	ea.enterScope()
+	defer ea.exitScope()
+	ea.stm(stm)
+}
+
+func (ea *escapeAnalyzer) stm(stm tast.Stm) {
+	switch s := stm.(type) {
+	case *tast.ExpStm:
+		ea.exp(s.Exp)
+	case *tast.DeclsStm:
+		for _, item := range s.Items {
+			ea.item(item)
+		}
+	case *tast.TupleDeclStm:
+		ea.exp(s.Exp)
+		for _, id := range s.Ids {
+			ea.declare(id, nil)
+		}
+	case *tast.ReturnStm:
+		ea.exp(s.Exp)
+	case *tast.ForEachStm:
+		// the elements are copied to the loop variable, unless they are
+		// struct values, which it points into
+		if !isValueStructType(s.Type) {
+			ea.access(s.Exp)
+		} else {
+			ea.exp(s.Exp)
+		}
+		ea.enterScope()
+		ea.declare(s.Id, nil)
+		ea.scopedStm(s.Stm)
+		ea.exitScope()
+	case *tast.WhileStm:
+		ea.exp(s.Exp)
+		ea.scopedStm(s.Stm)
+	case *tast.BlockStm:
+		ea.scopedStms(s.Stms)
+	case *tast.IfStm:
+		ea.exp(s.Exp)
+		ea.scopedStm(s.ThenStm)
+		if s.ElseStm != nil {
+			ea.scopedStm(s.ElseStm)
+		}
+	case *tast.SwitchStm:
+		ea.exp(s.Exp)
+		for _, c := range s.Cases {
+			ea.scopedStms(c.Stms)
+		}
+		if s.Default != nil {
+			ea.scopedStms(s.Default.Stms)
+		}
+	case *tast.ThrowStm:
+		ea.exp(s.Exp)
+	case *tast.TryStm:
+		ea.scopedStms(s.Stms)
+		for _, c := range s.Catches {
+			ea.enterScope()
+			ea.declare(c.Id, nil)
+			ea.stms(c.Stms)
+			ea.exitScope()
+		}
+	case *tast.AssertStm:
+		ea.exp(s.Exp)
+		if s.Message != nil {
+			ea.exp(s.Message)
+		}
+	case *tast.DeferStm:
+		ea.exp(s.Exp)
+	}
+}
+
+func (ea *escapeAnalyzer) item(item tast.Item) {
+	switch i := item.(type) {
+	case *tast.NoInitItem:
+		ea.declare(i.Id, nil)
+	case *tast.InitItem:
+		if isStackAllocatable(i.Exp) {
+			site := &allocSite{exp: i.Exp}
+			ea.sites = append(ea.sites, site)
+			ea.declare(i.Id, site)
+			return
+		}
+		ea.exp(i.Exp)
+		ea.declare(i.Id, nil)
+	}
+}
+
+// isStackAllocatable reports whether exp allocates a struct, or an array of a
+// single dimension with a constant length, which fit on the stack.
+func isStackAllocatable(exp tast.Exp) bool {
+	switch e := exp.(type) {
+	case *tast.NewStructExp:
+		return true
+	case *tast.NewArrExp:
+		if len(e.Exps) != 1 {
+			return false
+		}
+		length, ok := e.Exps[0].(*tast.IntExp)
+		return ok && length.Value >= 0 && length.Value <= maxStackArrayLength
+	default:
+		return false
+	}
+}
+
+// access walks exp, whose value is only used to access the object it refers
+// to, so that an allocated local it names does not escape.
+func (ea *escapeAnalyzer) access(exp tast.Exp) {
+	if _, ok := exp.(*tast.IdentExp); !ok {
+		ea.exp(exp)
+	}
+}
+
+// exp walks exp, whose value may be kept beyond the function, marking the
+// allocated locals it names as escaping.
+func (ea *escapeAnalyzer) exp(exp tast.Exp) {
+	switch e := exp.(type) {
+	case *tast.IdentExp:
+		if site := ea.lookup(e.Id); site != nil {
+			site.escapes = true
+		}
+	case *tast.ParenExp:
+		ea.exp(e.Exp)
+	case *tast.IntToDoubleExp:
+		ea.exp(e.Exp)
+	case *tast.NegExp:
+		ea.exp(e.Exp)
+	case *tast.NotExp:
+		ea.exp(e.Exp)
+	case *tast.MulExp:
+		ea.exp(e.LeftExp)
+		ea.exp(e.RightExp)
+	case *tast.AddExp:
+		ea.exp(e.LeftExp)
+		ea.exp(e.RightExp)
+	case *tast.AndExp:
+		ea.exp(e.LeftExp)
+		ea.exp(e.RightExp)
+	case *tast.OrExp:
+		ea.exp(e.LeftExp)
+		ea.exp(e.RightExp)
+	case *tast.CmpExp:
+		// comparing the addresses of objects keeps neither
+		ea.access(e.LeftExp)
+		ea.access(e.RightExp)
+	case *tast.AssignExp:
+		// assigning a local only loses the object it held
+		if _, ok := e.ExpLhs.(*tast.IdentExp); !ok {
+			ea.exp(e.ExpLhs)
+		}
+		ea.exp(e.Exp)
+	case *tast.PostExp:
+		ea.exp(e.Exp)
+	case *tast.PreExp:
+		ea.exp(e.Exp)
+	case *tast.DerefExp:
+		// a struct value field is accessed through a pointer into the object
+		if isValueStructType(e.Type()) {
+			ea.exp(e.Exp)
+		} else {
+			ea.access(e.Exp)
+		}
+	case *tast.FieldExp:
+		if isValueStructType(e.Type()) {
+			ea.exp(e.Exp)
+		} else {
+			ea.access(e.Exp)
+		}
+	case *tast.ArrIndexExp:
+		if isValueStructType(e.Type()) {
+			ea.exp(e.Exp)
+		} else {
+			ea.access(e.Exp)
+		}
+		ea.exps(e.IdxExps)
+	case *tast.NewArrExp:
+		ea.exps(e.Exps)
+	case *tast.FuncExp:
+		ea.exps(e.Exps)
+	case *tast.CallExp:
+		ea.exp(e.Exp)
+		ea.exps(e.Exps)
+	case *tast.EnumNameExp:
+		ea.exp(e.Exp)
+	case *tast.LambdaExp:
+		for _, capture := range e.Captures {
+			ea.exp(capture)
+		}
+		ea.enterScope()
+		for _, arg := range e.Args {
+			ea.declare(arg.(*tast.ParamArg).Id, nil)
+		}
+		ea.exp(e.Exp)
+		ea.exitScope()
+	case *tast.TupleExp:
+		ea.exps(e.Elems)
+	case *tast.StructLitExp:
+		ea.exps(e.Exps)
+	}
+}
+
+func (ea *escapeAnalyzer) exps(exps []tast.Exp) {
+	for _, exp := range exps {
+		ea.exp(exp)
+	}
+}
+
+// isValueStructType reports whether typ, which may be a typedef, is a struct
+// stored by value rather than a pointer to one.
+func isValueStructType(typ tast.Type) bool {
+	for {
+		td, ok := typ.(*tast.TypedefType)
+		if !ok {
+			break
+		}
+		typ = td.Aliased
+	}
+	_, ok := typ.(*tast.StructType)
+	return ok
+}
@@ -0,0 +1,325 @@
+package optimize
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/aramyamal/javalette-to-llvm-compiler/pkg/llvmgen"
+)
+
+// maxInlinedSize bounds the number of instructions a function may grow to by
+// inlining calls into it.
+const maxInlinedSize = 2000
+
+// Inline returns a pass inlining the calls to functions of at most threshold
+// instructions, and to any function with the alwaysinline attribute. Calls to
+// functions with the noinline attribute, recursive functions, and functions
+// still holding allocas or calling returns_twice functions are never inlined.
+// Functions are inlined bottom-up in the call graph, so that callees are
+// measured after their own calls were inlined.
+func Inline(threshold int) func(*llvmgen.Module) error {
+	return func(m *llvmgen.Module) error {
+		in := &inliner{
+			threshold: threshold,
+			funcs:     make(map[llvmgen.Global]*llvmgen.Function),
+			recursive: make(map[*llvmgen.Function]bool),
+		}
+		for _, f := range m.Funcs {
+			in.funcs[f.Name] = f
+		}
+		in.returnsTwice = make(map[llvmgen.Global]struct{})
+		for _, global := range m.Globals {
+			if decl, ok := global.(*llvmgen.FuncDecl); ok &&
+				slices.Contains(decl.Attrs, "returns_twice") {
+				in.returnsTwice[decl.Name] = struct{}{}
+			}
+		}
+		in.findRecursive(m)
+		for _, f := range in.bottomUp(m) {
+			in.inlineCalls(f)
+		}
+		return nil
+	}
+}
+
+type inliner struct {
+	threshold    int
+	funcs        map[llvmgen.Global]*llvmgen.Function
+	recursive    map[*llvmgen.Function]bool
+	returnsTwice map[llvmgen.Global]struct{}
+	inlined      int // number of call sites inlined so far
+}
+
+// calledFunc returns the function of the module called by call, or nil if it
+// calls an external function or a function value.
+func (in *inliner) calledFunc(call *llvmgen.Instruction) *llvmgen.Function {
+	if callee, ok := call.Operands[0].(llvmgen.Global); ok {
+		return in.funcs[callee]
+	}
+	return nil
+}
+
+// callees returns the functions of the module called by f.
+func (in *inliner) callees(f *llvmgen.Function) []*llvmgen.Function {
+	var callees []*llvmgen.Function
+	for _, block := range f.Blocks {
+		for _, inst := range block.Instrs {
+			if inst.Op != llvmgen.OpCall {
+				continue
+			}
+			if callee := in.calledFunc(inst); callee != nil &&
+				!slices.Contains(callees, callee) {
+				callees = append(callees, callee)
+			}
+		}
+	}
+	return callees
+}
+
+// findRecursive marks the functions on a cycle of the call graph, which are
+// the strongly connected components found by Tarjan's algorithm with more
+// than one function or a function calling itself.
+func (in *inliner) findRecursive(m *llvmgen.Module) {
+	index := make(map[*llvmgen.Function]int)
+	lowlink := make(map[*llvmgen.Function]int)
+	onStack := make(map[*llvmgen.Function]bool)
+	var stack []*llvmgen.Function
+
+	var connect func(f *llvmgen.Function)
+	connect = func(f *llvmgen.Function) {
+		index[f] = len(index)
+		lowlink[f] = index[f]
+		stack = append(stack, f)
+		onStack[f] = true
+		for _, callee := range in.callees(f) {
+			if _, visited := index[callee]; !visited {
+				connect(callee)
+				lowlink[f] = min(lowlink[f], lowlink[callee])
+			} else if onStack[callee] {
+				lowlink[f] = min(lowlink[f], index[callee])
+			}
+		}
+		if lowlink[f] != index[f] {
+			return
+		}
+		var component []*llvmgen.Function
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == f {
+				break
+			}
+		}
+		if len(component) > 1 || slices.Contains(in.callees(f), f) {
+			for _, member := range component {
+				in.recursive[member] = true
+			}
+		}
+	}
+	for _, f := range m.Funcs {
+		if _, visited := index[f]; !visited {
+			connect(f)
+		}
+	}
+}
+
+// bottomUp orders the functions of m so that each comes after the functions
+// it calls, except for calls within a cycle.
+func (in *inliner) bottomUp(m *llvmgen.Module) []*llvmgen.Function {
+	visited := make(map[*llvmgen.Function]bool)
+	var order []*llvmgen.Function
+	var visit func(f *llvmgen.Function)
+	visit = func(f *llvmgen.Function) {
+		visited[f] = true
+		for _, callee := range in.callees(f) {
+			if !visited[callee] {
+				visit(callee)
+			}
+		}
+		order = append(order, f)
+	}
+	for _, f := range m.Funcs {
+		if !visited[f] {
+			visit(f)
+		}
+	}
+	return order
+}
+
+// inlineCalls inlines the calls of f to the functions worth inlining.
+func (in *inliner) inlineCalls(f *llvmgen.Function) {
+	for i := 0; i < len(f.Blocks); i++ {
+		for _, inst := range f.Blocks[i].Instrs {
+			if inst.Op != llvmgen.OpCall {
+				continue
+			}
+			callee := in.calledFunc(inst)
+			if callee == nil || callee == f || !in.shouldInline(callee) ||
+				funcSize(f)+funcSize(callee) > maxInlinedSize {
+				continue
+			}
+			in.inlineCall(f, inst, callee)
+			// the rest of the block was moved to the block after the inlined
+			// body, which is visited later
+			break
+		}
+	}
+}
+
+func (in *inliner) shouldInline(callee *llvmgen.Function) bool {
+	if in.recursive[callee] || slices.Contains(callee.Attrs, "noinline") ||
+		callsAny(callee, in.returnsTwice) {
+		return false
+	}
+	for _, block := range callee.Blocks {
+		for _, inst := range block.Instrs {
+			if inst.Op == llvmgen.OpAlloca {
+				return false
+			}
+		}
+	}
+	return slices.Contains(callee.Attrs, "alwaysinline") ||
+		funcSize(callee) <= in.threshold
+}
+
+// funcSize returns the number of instructions of f, not counting comments.
+func funcSize(f *llvmgen.Function) int {
+	size := 0
+	for _, block := range f.Blocks {
+		for _, inst := range block.Instrs {
+			if inst.Op != llvmgen.OpComment {
+				size++
+			}
+		}
+	}
+	return size
+}
+
+// inlineCall replaces call in f by a copy of the body of callee. The block of
+// the call is split after it, the copied returns branch to the second half,
+// and the returned values are merged by a phi defining the result of the call.
+func (in *inliner) inlineCall(
+	f *llvmgen.Function, call *llvmgen.Instruction, callee *llvmgen.Function,
+) {
+	prefix := fmt.Sprintf("%s.i%d.", string(callee.Name), in.inlined)
+	in.inlined++
+
+	block := call.Block
+	idx := slices.Index(block.Instrs, call)
+	cont := f.InsertBlockAfter(block, prefix+"cont")
+	for _, inst := range slices.Clone(block.Instrs[idx+1:]) {
+		block.Remove(inst)
+		cont.Append(inst)
+	}
+	block.Remove(call)
+	if block.Name != "" {
+		renameIncoming(f, cont, block.Name, cont.Name)
+	}
+
+	values := make(map[llvmgen.Reg]llvmgen.Value)
+	for i, param := range callee.Params {
+		values[param.Name] = call.Operands[i+1]
+	}
+	mapValue := func(value llvmgen.Value) llvmgen.Value {
+		reg, ok := value.(llvmgen.Reg)
+		if !ok {
+			return value
+		}
+		if mapped, ok := values[reg]; ok {
+			return mapped
+		}
+		return llvmgen.Reg(prefix + string(reg))
+	}
+
+	type ret struct {
+		value llvmgen.Value
+		label string
+	}
+	var rets []ret
+	last := block
+	for _, calleeBlock := range callee.Blocks {
+		clone := f.InsertBlockAfter(last, prefix+calleeBlock.Name)
+		last = clone
+		for _, inst := range calleeBlock.Instrs {
+			if inst.Op == llvmgen.OpRet {
+				if len(inst.Operands) > 0 {
+					rets = append(rets, ret{mapValue(inst.Operands[0]), clone.Name})
+				} else {
+					rets = append(rets, ret{nil, clone.Name})
+				}
+				clone.Append(&llvmgen.Instruction{
+					Op:     llvmgen.OpBr,
+					Type:   llvmgen.Void,
+					Labels: []string{cont.Name},
+				})
+				continue
+			}
+			copied := &llvmgen.Instruction{
+				Op:    inst.Op,
+				Type:  inst.Type,
+				Types: slices.Clone(inst.Types),
+				Pred:  inst.Pred,
+				Index: inst.Index,
+				Text:  inst.Text,
+			}
+			if inst.Des != "" {
+				copied.Des = mapValue(inst.Des).(llvmgen.Reg)
+			}
+			for _, operand := range inst.Operands {
+				copied.Operands = append(copied.Operands, mapValue(operand))
+			}
+			for _, label := range inst.Labels {
+				copied.Labels = append(copied.Labels, prefix+label)
+			}
+			clone.Append(copied)
+		}
+	}
+	block.Append(&llvmgen.Instruction{
+		Op:     llvmgen.OpBr,
+		Type:   llvmgen.Void,
+		Labels: []string{prefix + callee.Blocks[0].Name},
+	})
+
+	if call.Des == "" || call.Type == llvmgen.Void {
+		return
+	}
+	switch len(rets) {
+	case 0:
+		f.ReplaceAllUses(call.Des, llvmgen.Undef())
+	case 1:
+		f.ReplaceAllUses(call.Des, rets[0].value)
+	default:
+		phi := &llvmgen.Instruction{
+			Op:   llvmgen.OpPhi,
+			Des:  call.Des,
+			Type: call.Type,
+		}
+		cont.InsertBefore(0, phi)
+		for _, r := range rets {
+			f.AddIncoming(phi, r.value, r.label)
+		}
+	}
+}
+
+// renameIncoming renames the incoming block from to to in the phis of the
+// successors of block.
+func renameIncoming(f *llvmgen.Function, block *llvmgen.BasicBlock, from, to string) {
+	for _, label := range block.Succs() {
+		succ := f.Block(label)
+		if succ == nil {
+			continue
+		}
+		for _, inst := range succ.Instrs {
+			if inst.Op != llvmgen.OpPhi {
+				continue
+			}
+			for i, incoming := range inst.Labels {
+				if incoming == from {
+					inst.Labels[i] = to
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package optimize
+
+import (
+	"slices"
+
+	"github.com/aramyamal/javalette-to-llvm-compiler/pkg/llvmgen"
+)
+
+// HoistLoopInvariants moves the instructions of loops computing the same
+// value on every iteration to the block entering the loop, so that they run
+// once. Besides arithmetic, this hoists the loads of the length and data
+// pointer of arrays that are known to be non-null before the loop runs, which
+// are otherwise repeated for every indexing and every check of the length in
+// the condition. Loops are processed from the innermost outwards, so that
+// invariants of nested loops move as far out as they can.
+func HoistLoopInvariants(m *llvmgen.Module) error {
+	for _, f := range m.Funcs {
+		hoistLoopInvariants(f)
+	}
+	return nil
+}
+
+func hoistLoopInvariants(f *llvmgen.Function) {
+	g := newCFG(f)
+	if len(g.blocks) == 0 {
+		return
+	}
+	var loops []*loop
+	for _, block := range g.blocks {
+		if l := g.naturalLoop(block); l != nil {
+			loops = append(loops, l)
+		}
+	}
+	// an inner loop has fewer blocks than the loops containing it
+	slices.SortStableFunc(loops, func(l1, l2 *loop) int {
+		return len(l1.blocks) - len(l2.blocks)
+	})
+	for _, l := range loops {
+		// entering blocks added for previous loops change the graph
+		g = newCFG(f)
+		if l = g.naturalLoop(l.header); l != nil {
+			l.hoist(f, g)
+		}
+	}
+}
+
+// loop is a natural loop of a control flow graph, the blocks that can reach
+// a back edge to its header without passing through the header.
+type loop struct {
+	header *llvmgen.BasicBlock
+	blocks []*llvmgen.BasicBlock
+}
+
+// naturalLoop returns the loop with the given header, merging all back edges
+// to it, or nil if no block dominated by header branches to it.
+func (g *cfg) naturalLoop(header *llvmgen.BasicBlock) *loop {
+	l := &loop{header: header, blocks: []*llvmgen.BasicBlock{header}}
+	var work []*llvmgen.BasicBlock
+	for _, pred := range g.preds[header] {
+		if g.dominates(header, pred) && !containsBlock(l.blocks, pred) {
+			l.blocks = append(l.blocks, pred)
+			work = append(work, pred)
+		}
+	}
+	if len(work) == 0 {
+		return nil
+	}
+	for len(work) > 0 {
+		block := work[len(work)-1]
+		work = work[:len(work)-1]
+		for _, pred := range g.preds[block] {
+			if !containsBlock(l.blocks, pred) {
+				l.blocks = append(l.blocks, pred)
+				work = append(work, pred)
+			}
+		}
+	}
+	// keep the blocks in reverse postorder, so that definitions are visited
+	// before their uses
+	slices.SortFunc(l.blocks, func(b1, b2 *llvmgen.BasicBlock) int {
+		return g.order[b1] - g.order[b2]
+	})
+	return l
+}
+
+func (l *loop) contains(block *llvmgen.BasicBlock) bool {
+	return containsBlock(l.blocks, block)
+}
+
+// hoist moves the invariant instructions of l to its preheader.
+func (l *loop) hoist(f *llvmgen.Function, g *cfg) {
+	allocated := l.allocatedArrays(f)
+	safe := slices.DeleteFunc(l.nonNullArrays(g), func(array string) bool {
+		return slices.Contains(allocated, array)
+	})
+	stored := l.storedTypes()
+	invariant := make(map[*llvmgen.Instruction]bool)
+	var hoisted []*llvmgen.Instruction
+	for changed := true; changed; {
+		changed = false
+		for _, block := range l.blocks {
+			for _, inst := range block.Instrs {
+				if invariant[inst] || !l.isInvariant(f, inst, invariant) {
+					continue
+				}
+				if !isSpeculatable(inst) && !isSafeArrayLoad(f, inst, safe) &&
+					!l.isUnclobberedLoad(inst, stored) {
+					continue
+				}
+				invariant[inst] = true
+				hoisted = append(hoisted, inst)
+				changed = true
+			}
+		}
+	}
+	if len(hoisted) == 0 {
+		return
+	}
+	preheader := l.preheader(f, g)
+	if preheader == nil {
+		return
+	}
+	for _, inst := range hoisted {
+		inst.Block.Remove(inst)
+		preheader.InsertBefore(len(preheader.Instrs)-1, inst)
+	}
+}
+
+// isInvariant reports whether the operands of inst are defined outside of l
+// or by instructions already found to be invariant.
+func (l *loop) isInvariant(
+	f *llvmgen.Function,
+	inst *llvmgen.Instruction,
+	invariant map[*llvmgen.Instruction]bool,
+) bool {
+	if inst.Des == "" {
+		return false
+	}
+	for _, operand := range inst.Operands {
+		reg, ok := operand.(llvmgen.Reg)
+		if !ok {
+			continue
+		}
+		def := f.Def(reg)
+		if def != nil && l.contains(def.Block) && !invariant[def] {
+			return false
+		}
+	}
+	return true
+}
+
+// isSpeculatable reports whether inst computes a value without side effects
+// or traps, so that running it when the loop does not is harmless.
+func isSpeculatable(inst *llvmgen.Instruction) bool {
+	switch inst.Op {
+	case llvmgen.OpAdd, llvmgen.OpSub, llvmgen.OpMul, llvmgen.OpXor,
+		llvmgen.OpCmp, llvmgen.OpGetElementPtr, llvmgen.OpBitcast,
+		llvmgen.OpPtrToInt, llvmgen.OpZExt, llvmgen.OpSIToFP,
+		llvmgen.OpInsertValue, llvmgen.OpExtractValue:
+		return true
+	case llvmgen.OpDiv, llvmgen.OpRem:
+		if inst.Type == llvmgen.Double {
+			return true
+		}
+		// integer division traps on a zero divisor, and on -1 for the
+		// smallest dividend
+		divisor, ok := inst.Operands[1].(llvmgen.LitInt)
+		return ok && divisor != 0 && divisor != -1
+	default:
+		return false
+	}
+}
+
+// arrayField returns the array whose length or data pointer ptr points to.
+// These fields are only stored to when the array is allocated, so loading them
+// gives the same value for the whole lifetime of the array.
+func arrayField(f *llvmgen.Function, ptr llvmgen.Value) (llvmgen.Value, bool) {
+	reg, ok := ptr.(llvmgen.Reg)
+	if !ok {
+		return nil, false
+	}
+	gep := f.Def(reg)
+	if gep == nil || gep.Op != llvmgen.OpGetElementPtr || len(gep.Operands) != 3 {
+		return nil, false
+	}
+	structType, ok := gep.Type.(*llvmgen.StructType)
+	if !ok || !structType.Array {
+		return nil, false
+	}
+	return gep.Operands[0], true
+}
+
+// isSafeArrayLoad reports whether inst loads the length or data pointer of
+// an array in safe, which can be loaded anywhere in the loop.
+func isSafeArrayLoad(
+	f *llvmgen.Function, inst *llvmgen.Instruction, safe []string,
+) bool {
+	if inst.Op != llvmgen.OpLoad {
+		return false
+	}
+	array, ok := arrayField(f, inst.Operands[0])
+	return ok && slices.Contains(safe, array.String())
+}
+
+// allocatedArrays returns the arrays whose length or data pointer are stored
+// to in l, which happens when an array allocated on the stack is reused by
+// every iteration.
+func (l *loop) allocatedArrays(f *llvmgen.Function) []string {
+	var allocated []string
+	for _, block := range l.blocks {
+		for _, inst := range block.Instrs {
+			if inst.Op != llvmgen.OpStore {
+				continue
+			}
+			ptr := inst.Operands[1]
+			if array, ok := arrayField(f, ptr); ok {
+				allocated = append(allocated, array.String())
+			} else {
+				allocated = append(allocated, ptr.String())
+			}
+		}
+	}
+	return allocated
+}
+
+// storedTypes returns the types of the values stored to by l, or nil if l
+// may write to any memory, by calling a function or storing an aggregate that
+// overlaps values of other types.
+func (l *loop) storedTypes() []string {
+	stored := []string{}
+	for _, block := range l.blocks {
+		for _, inst := range block.Instrs {
+			switch inst.Op {
+			case llvmgen.OpCall:
+				return nil
+			case llvmgen.OpStore:
+				if !isScalar(inst.Types[0]) {
+					return nil
+				}
+				stored = append(stored, inst.Types[0].String())
+			}
+		}
+	}
+	return stored
+}
+
+// isUnclobberedLoad reports whether inst is a load in the header of l, which
+// runs whenever the loop is entered, from memory of a type l never stores to.
+// Javalette programs cannot reinterpret memory as another type, so such a
+// load gives the same value on every iteration.
+func (l *loop) isUnclobberedLoad(inst *llvmgen.Instruction, stored []string) bool {
+	return inst.Op == llvmgen.OpLoad && inst.Block == l.header &&
+		stored != nil && !slices.Contains(stored, inst.Type.String())
+}
+
+// nonNullArrays returns the arrays whose fields are loaded whenever l is
+// entered, either before it or in its header before any call that could
+// throw, so that loading their fields before the loop cannot fault.
+func (l *loop) nonNullArrays(g *cfg) []string {
+	var safe []string
+	addLoaded := func(inst *llvmgen.Instruction) {
+		if inst.Op != llvmgen.OpLoad {
+			return
+		}
+		f := inst.Block.Parent
+		if array, ok := arrayField(f, inst.Operands[0]); ok &&
+			!slices.Contains(safe, array.String()) {
+			safe = append(safe, array.String())
+		}
+	}
+	for _, block := range g.blocks {
+		if !l.contains(block) && g.dominates(block, l.header) {
+			for _, inst := range block.Instrs {
+				addLoaded(inst)
+			}
+		}
+	}
+	for _, inst := range l.header.Instrs {
+		if inst.Op == llvmgen.OpCall {
+			break
+		}
+		addLoaded(inst)
+	}
+	return safe
+}
+
+// preheader returns the block entering l, which only branches to its header.
+// If the single block entering l branches elsewhere too, a new block is
+// inserted between it and the header. Loops entered from several blocks are
+// not given a preheader, and nil is returned.
+func (l *loop) preheader(f *llvmgen.Function, g *cfg) *llvmgen.BasicBlock {
+	var entering *llvmgen.BasicBlock
+	for _, pred := range g.preds[l.header] {
+		if l.contains(pred) {
+			continue
+		}
+		if entering != nil && entering != pred {
+			return nil
+		}
+		entering = pred
+	}
+	if entering == nil {
+		return nil
+	}
+	if len(entering.Succs()) == 1 {
+		return entering
+	}
+	if entering.Name == "" {
+		return nil
+	}
+
+	preheader := f.InsertBlockAfter(entering, l.header.Name+".preheader")
+	preheader.Append(&llvmgen.Instruction{
+		Op:     llvmgen.OpBr,
+		Type:   llvmgen.Void,
+		Labels: []string{l.header.Name},
+	})
+	term := entering.Terminator()
+	for i, label := range term.Labels {
+		if label == l.header.Name {
+			term.Labels[i] = preheader.Name
+		}
+	}
+	for _, inst := range l.header.Instrs {
+		if inst.Op != llvmgen.OpPhi {
+			continue
+		}
+		for i, label := range inst.Labels {
+			if label == entering.Name {
+				inst.Labels[i] = preheader.Name
+			}
+		}
+	}
+	return preheader
+}
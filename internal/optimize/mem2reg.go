@@ -0,0 +1,311 @@
+package optimize
+
+import (
+	"slices"
+
+	"github.com/aramyamal/javalette-to-llvm-compiler/pkg/llvmgen"
+)
+
+// Mem2Reg promotes the allocas of scalar locals to SSA registers, inserting
+// phis at the dominance frontiers of the blocks storing to them. An alloca is
+// only promoted if it is loaded from and stored to directly, so that its
+// address never escapes. Functions calling a returns_twice function such as
+// setjmp are left as is, since locals modified between the setjmp and the
+// longjmp must still hold their latest values when it returns again.
+func Mem2Reg(m *llvmgen.Module) error {
+	returnsTwice := make(map[llvmgen.Global]struct{})
+	for _, global := range m.Globals {
+		if decl, ok := global.(*llvmgen.FuncDecl); ok &&
+			slices.Contains(decl.Attrs, "returns_twice") {
+			returnsTwice[decl.Name] = struct{}{}
+		}
+	}
+	for _, f := range m.Funcs {
+		if callsAny(f, returnsTwice) {
+			continue
+		}
+		promoteAllocas(f)
+	}
+	return nil
+}
+
+// callsAny reports whether f calls any of the functions in callees.
+func callsAny(f *llvmgen.Function, callees map[llvmgen.Global]struct{}) bool {
+	for _, block := range f.Blocks {
+		for _, inst := range block.Instrs {
+			if inst.Op != llvmgen.OpCall {
+				continue
+			}
+			if callee, ok := inst.Operands[0].(llvmgen.Global); ok {
+				if _, ok := callees[callee]; ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// promoter holds the state of promoting the allocas of a single function.
+type promoter struct {
+	f       *llvmgen.Function
+	g       *cfg
+	order   []*llvmgen.Instruction // promoted allocas in program order
+	allocas map[llvmgen.Reg]*llvmgen.Instruction
+	phis    map[*llvmgen.Instruction]*llvmgen.Instruction // phi to its alloca
+	placed  []*llvmgen.Instruction                        // phis in order
+	values  map[*llvmgen.Instruction][]llvmgen.Value      // alloca to values
+}
+
+func promoteAllocas(f *llvmgen.Function) {
+	p := &promoter{
+		f:       f,
+		allocas: make(map[llvmgen.Reg]*llvmgen.Instruction),
+		phis:    make(map[*llvmgen.Instruction]*llvmgen.Instruction),
+		values:  make(map[*llvmgen.Instruction][]llvmgen.Value),
+	}
+	for _, block := range f.Blocks {
+		for _, inst := range block.Instrs {
+			if inst.Op == llvmgen.OpAlloca && isPromotable(f, inst) {
+				p.allocas[inst.Des] = inst
+				p.order = append(p.order, inst)
+			}
+		}
+	}
+	if len(p.allocas) == 0 {
+		return
+	}
+	p.g = newCFG(f)
+
+	p.insertPhis()
+	p.rename(p.g.blocks[0])
+	p.addUnreachableIncoming()
+
+	// loads and stores in unreachable blocks never run, so they are dropped
+	// along with the allocas
+	for _, block := range slices.Clone(f.Blocks) {
+		if !p.g.reachable(block) {
+			for _, inst := range slices.Clone(block.Instrs) {
+				if alloca := p.accessed(inst); alloca != nil {
+					if inst.Op == llvmgen.OpLoad {
+						f.ReplaceAllUses(inst.Des, llvmgen.Undef())
+					}
+					block.Remove(inst)
+				}
+			}
+		}
+	}
+	for _, alloca := range p.order {
+		alloca.Block.Remove(alloca)
+	}
+	p.simplifyPhis()
+}
+
+// isPromotable reports whether alloca holds a scalar that is only loaded from
+// and stored to with its own type.
+func isPromotable(f *llvmgen.Function, alloca *llvmgen.Instruction) bool {
+	if !isScalar(alloca.Type) {
+		return false
+	}
+	for _, user := range f.Uses(alloca.Des) {
+		switch user.Op {
+		case llvmgen.OpLoad:
+			if user.Type.String() != alloca.Type.String() {
+				return false
+			}
+		case llvmgen.OpStore:
+			if user.Operands[0] == llvmgen.Value(alloca.Des) ||
+				user.Types[0].String() != alloca.Type.String() {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isScalar(typ llvmgen.Type) bool {
+	switch t := typ.(type) {
+	case llvmgen.PrimitiveType:
+		return t != llvmgen.Void
+	case llvmgen.PtrType:
+		return true
+	default:
+		return false
+	}
+}
+
+// accessed returns the promoted alloca inst loads from or stores to, or nil.
+func (p *promoter) accessed(inst *llvmgen.Instruction) *llvmgen.Instruction {
+	var ptr llvmgen.Value
+	switch inst.Op {
+	case llvmgen.OpLoad:
+		ptr = inst.Operands[0]
+	case llvmgen.OpStore:
+		ptr = inst.Operands[1]
+	default:
+		return nil
+	}
+	if reg, ok := ptr.(llvmgen.Reg); ok {
+		return p.allocas[reg]
+	}
+	return nil
+}
+
+// insertPhis places an empty phi for each alloca at the iterated dominance
+// frontier of the blocks storing to it.
+func (p *promoter) insertPhis() {
+	for _, alloca := range p.order {
+		var work []*llvmgen.BasicBlock
+		for _, user := range p.f.Uses(alloca.Des) {
+			if user.Op == llvmgen.OpStore && p.g.reachable(user.Block) &&
+				!containsBlock(work, user.Block) {
+				work = append(work, user.Block)
+			}
+		}
+		placed := make(map[*llvmgen.BasicBlock]bool)
+		for len(work) > 0 {
+			block := work[len(work)-1]
+			work = work[:len(work)-1]
+			for _, df := range p.g.frontier[block] {
+				if placed[df] {
+					continue
+				}
+				placed[df] = true
+				phi := &llvmgen.Instruction{
+					Op:   llvmgen.OpPhi,
+					Des:  llvmgen.Reg(string(alloca.Des) + "." + df.Name),
+					Type: alloca.Type,
+				}
+				df.InsertBefore(0, phi)
+				p.phis[phi] = alloca
+				p.placed = append(p.placed, phi)
+				work = append(work, df)
+			}
+		}
+	}
+}
+
+// rename walks the dominator tree from block, replacing loads by the value
+// last stored to the alloca and filling in the incoming values of phis.
+func (p *promoter) rename(block *llvmgen.BasicBlock) {
+	pushed := make(map[*llvmgen.Instruction]int)
+	push := func(alloca *llvmgen.Instruction, value llvmgen.Value) {
+		p.values[alloca] = append(p.values[alloca], value)
+		pushed[alloca]++
+	}
+
+	for _, inst := range slices.Clone(block.Instrs) {
+		if alloca, ok := p.phis[inst]; ok {
+			push(alloca, inst.Des)
+			continue
+		}
+		alloca := p.accessed(inst)
+		if alloca == nil {
+			continue
+		}
+		if inst.Op == llvmgen.OpLoad {
+			p.f.ReplaceAllUses(inst.Des, p.current(alloca))
+		} else {
+			push(alloca, inst.Operands[0])
+		}
+		block.Remove(inst)
+	}
+
+	for _, label := range block.Succs() {
+		succ, ok := p.g.byName[label]
+		if !ok {
+			continue
+		}
+		for _, inst := range succ.Instrs {
+			if alloca, ok := p.phis[inst]; ok {
+				p.f.AddIncoming(inst, p.current(alloca), block.Name)
+			}
+		}
+	}
+
+	for _, child := range p.g.children[block] {
+		p.rename(child)
+	}
+
+	for alloca, n := range pushed {
+		p.values[alloca] = p.values[alloca][:len(p.values[alloca])-n]
+	}
+}
+
+// addUnreachableIncoming gives the phis an undefined incoming value from each
+// of their unreachable predecessors, such as the blocks of statements after a
+// return, which rename never visits but which must still be listed.
+func (p *promoter) addUnreachableIncoming() {
+	for _, block := range p.f.Blocks {
+		if p.g.reachable(block) {
+			continue
+		}
+		for _, label := range block.Succs() {
+			succ, ok := p.g.byName[label]
+			if !ok {
+				continue
+			}
+			for _, inst := range succ.Instrs {
+				if _, ok := p.phis[inst]; ok &&
+					!slices.Contains(inst.Labels, block.Name) {
+					p.f.AddIncoming(inst, llvmgen.Undef(), block.Name)
+				}
+			}
+		}
+	}
+}
+
+// current returns the value alloca holds at the point being renamed, which is
+// undefined before the first store.
+func (p *promoter) current(alloca *llvmgen.Instruction) llvmgen.Value {
+	values := p.values[alloca]
+	if len(values) == 0 {
+		return llvmgen.Undef()
+	}
+	return values[len(values)-1]
+}
+
+// simplifyPhis removes the inserted phis that are unused, and replaces those
+// merging a single value by that value.
+func (p *promoter) simplifyPhis() {
+	for changed := true; changed; {
+		changed = false
+		for _, phi := range p.placed {
+			if phi.Block == nil {
+				continue
+			}
+			users := p.f.Uses(phi.Des)
+			if len(users) == 0 || (len(users) == 1 && users[0] == phi) {
+				phi.Block.Remove(phi)
+				changed = true
+				continue
+			}
+			if value, ok := p.uniqueIncoming(phi); ok {
+				p.f.ReplaceAllUses(phi.Des, value)
+				phi.Block.Remove(phi)
+				changed = true
+			}
+		}
+	}
+}
+
+// uniqueIncoming returns the only value other than itself that phi merges
+// from the reachable blocks.
+func (p *promoter) uniqueIncoming(phi *llvmgen.Instruction) (llvmgen.Value, bool) {
+	var unique llvmgen.Value
+	for i, operand := range phi.Operands {
+		if operand == llvmgen.Value(phi.Des) {
+			continue
+		}
+		if pred, ok := p.g.byName[phi.Labels[i]]; ok && !p.g.reachable(pred) {
+			continue
+		}
+		if unique != nil && unique.String() != operand.String() {
+			return nil, false
+		}
+		unique = operand
+	}
+	return unique, unique != nil
+}
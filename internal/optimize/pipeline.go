@@ -0,0 +1,239 @@
+package optimize
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+	"github.com/aramyamal/javalette-to-llvm-compiler/pkg/llvmgen"
+)
+
+// Pass is a named optimization pass, which transforms either the TAST before
+// code generation or the generated IR module, depending on which function is
+// set.
+type Pass struct {
+	Name   string
+	Prgm   func(*tast.Prgm) []Warning
+	Module func(*llvmgen.Module) error
+}
+
+// NewPass returns the pass with the given name, where inlineThreshold is the
+// size limit of the inline pass.
+func NewPass(name string, inlineThreshold int) (Pass, error) {
+	switch name {
+	case "constfold":
+		return Pass{Name: name, Prgm: func(prgm *tast.Prgm) []Warning {
+			FoldConstants(prgm)
+			return nil
+		}}, nil
+	case "dce":
+		return Pass{Name: name, Prgm: EliminateDeadCode}, nil
+	case "escape":
+		return Pass{Name: name, Prgm: func(prgm *tast.Prgm) []Warning {
+			AllocateOnStack(prgm)
+			return nil
+		}}, nil
+	case "mem2reg":
+		return Pass{Name: name, Module: Mem2Reg}, nil
+	case "inline":
+		return Pass{Name: name, Module: Inline(inlineThreshold)}, nil
+	case "licm":
+		return Pass{Name: name, Module: HoistLoopInvariants}, nil
+	default:
+		return Pass{}, fmt.Errorf(
+			"unknown pass '%s', expected one of %s",
+			name, strings.Join(PassNames, ", "),
+		)
+	}
+}
+
+// PassNames are the names of all passes, in the order they run at the
+// highest optimization level.
+var PassNames = []string{
+	"constfold", "dce", "escape", "mem2reg", "inline", "licm",
+}
+
+// LevelPasses returns the names of the passes run at the optimization level
+// named by level, which is one of O0, O1, O2 and Os.
+func LevelPasses(level string) ([]string, error) {
+	switch level {
+	case "O0":
+		return nil, nil
+	case "O1":
+		return []string{"constfold", "dce", "escape", "mem2reg", "licm"}, nil
+	case "O2", "Os":
+		// -Os differs from -O2 by the lower inlining threshold only
+		return slices.Clone(PassNames), nil
+	default:
+		return nil, fmt.Errorf("unknown optimization level '%s'", level)
+	}
+}
+
+// Pipeline runs a sequence of passes, printing the IR after some of them,
+// timing each of them and verifying the IR they produce if asked to. The TAST
+// passes run in order before code generation, and the IR passes in order
+// after it.
+type Pipeline struct {
+	passes     []Pass
+	printAfter []string
+	timePasses bool
+	verify     bool
+	out        io.Writer
+	printPrgm  func(*tast.Prgm) (string, error)
+	timings    []passTiming
+}
+
+type passTiming struct {
+	name    string
+	elapsed time.Duration
+}
+
+// NewPipeline returns a pipeline of the passes with the given names. Since
+// the TAST passes run before code generation, they must all be named before
+// the IR passes.
+func NewPipeline(names []string, inlineThreshold int) (*Pipeline, error) {
+	p := &Pipeline{}
+	var irPass string
+	for _, name := range names {
+		pass, err := NewPass(name, inlineThreshold)
+		if err != nil {
+			return nil, err
+		}
+		if pass.Module != nil && irPass == "" {
+			irPass = name
+		}
+		if pass.Prgm != nil && irPass != "" {
+			return nil, fmt.Errorf(
+				"pass '%s' runs before code generation, so it cannot follow "+
+					"the IR pass '%s'", name, irPass,
+			)
+		}
+		p.passes = append(p.passes, pass)
+	}
+	return p, nil
+}
+
+// PrintAfter makes p print the IR to out after each of the passes with the
+// given names. Since the TAST passes run before there is any IR, the IR after
+// them is generated from the TAST with printPrgm.
+func (p *Pipeline) PrintAfter(
+	names []string,
+	out io.Writer,
+	printPrgm func(*tast.Prgm) (string, error),
+) error {
+	for _, name := range names {
+		if !slices.ContainsFunc(p.passes, func(pass Pass) bool {
+			return pass.Name == name
+		}) {
+			return fmt.Errorf("cannot print after '%s', which does not run", name)
+		}
+	}
+	p.printAfter = names
+	p.out = out
+	p.printPrgm = printPrgm
+	return nil
+}
+
+// TimePasses makes p record how long each pass runs, to be printed by
+// WriteTimings.
+func (p *Pipeline) TimePasses() {
+	p.timePasses = true
+}
+
+// VerifyEach makes p verify the generated IR before the first IR pass and
+// after each of them, so that invalid IR is reported along with the pass that
+// produced it.
+func (p *Pipeline) VerifyEach() {
+	p.verify = true
+}
+
+// RunPrgm runs the TAST passes of p over prgm, and returns the warnings about
+// the code they removed.
+func (p *Pipeline) RunPrgm(prgm *tast.Prgm) ([]Warning, error) {
+	var warnings []Warning
+	for _, pass := range p.passes {
+		if pass.Prgm == nil {
+			continue
+		}
+		start := time.Now()
+		warnings = append(warnings, pass.Prgm(prgm)...)
+		p.record(pass.Name, time.Since(start))
+		if slices.Contains(p.printAfter, pass.Name) {
+			ir, err := p.printPrgm(prgm)
+			if err != nil {
+				return nil, err
+			}
+			p.dump(pass.Name, ir)
+		}
+	}
+	return warnings, nil
+}
+
+// ModulePasses returns the IR passes of p, to be run over the generated
+// module in order.
+func (p *Pipeline) ModulePasses() []func(*llvmgen.Module) error {
+	var passes []func(*llvmgen.Module) error
+	if p.verify {
+		passes = append(passes, func(m *llvmgen.Module) error {
+			return verifyAfter("codegen", m)
+		})
+	}
+	for _, pass := range p.passes {
+		if pass.Module == nil {
+			continue
+		}
+		passes = append(passes, func(m *llvmgen.Module) error {
+			start := time.Now()
+			if err := pass.Module(m); err != nil {
+				return err
+			}
+			p.record(pass.Name, time.Since(start))
+			if slices.Contains(p.printAfter, pass.Name) {
+				p.dump(pass.Name, m.String())
+			}
+			if p.verify {
+				return verifyAfter(pass.Name, m)
+			}
+			return nil
+		})
+	}
+	return passes
+}
+
+func verifyAfter(name string, m *llvmgen.Module) error {
+	if err := m.Verify(); err != nil {
+		return fmt.Errorf("invalid IR after %s:\n%w", name, err)
+	}
+	return nil
+}
+
+func (p *Pipeline) record(name string, elapsed time.Duration) {
+	if p.timePasses {
+		p.timings = append(p.timings, passTiming{name, elapsed})
+	}
+}
+
+func (p *Pipeline) dump(name, ir string) {
+	fmt.Fprintf(p.out, "; *** IR Dump After %s ***\n%s\n", name, ir)
+}
+
+// WriteTimings prints the time spent in each pass that ran to w, along with
+// its share of the total time.
+func (p *Pipeline) WriteTimings(w io.Writer) {
+	var total time.Duration
+	for _, t := range p.timings {
+		total += t.elapsed
+	}
+	fmt.Fprintln(w, "===== Pass execution timing report =====")
+	for _, t := range p.timings {
+		share := 0.0
+		if total > 0 {
+			share = 100 * float64(t.elapsed) / float64(total)
+		}
+		fmt.Fprintf(w, "%12s %6.1f%%  %s\n", t.elapsed, share, t.name)
+	}
+	fmt.Fprintf(w, "%12s %6.1f%%  Total\n", total, 100.0)
+}
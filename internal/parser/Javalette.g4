@@ -1,16 +1,32 @@
 grammar Javalette;
 
 // PARSER RULES
-// a program is a list of definitions
+// a program is a list of imports followed by a list of definitions
 prgm
-    : def* 
+    : importDecl* def* 
     ;
 
-// defintions can be function defs, struct defs and typedef defs
+// an import makes the definitions of another file available
+importDecl
+    : 'import' String ';'
+    ;
+
+// defintions can be function defs, struct defs, typedef defs, enum defs and
+// extern function declarations, where function and struct defs may be generic
+// over a list of type parameters, structs may extend another struct and
+// typedefs may alias any type. Function defs may be annotated to always or
+// never be inlined
 def 
-    : type Ident '(' (arg (',' arg)*)? ')' '{' stm* '}' # FuncDef
-    | 'struct' Ident '{' structField* '}' ';'           # StructDef
-    | 'typedef' 'struct' type '*' type ';'              # TypedefDef
+    : inlineHint? type Ident typeParams? '(' (arg (',' arg)*)? ')' '{' stm* '}' # FuncDef
+    | 'struct' Ident typeParams? structExtends? '{' structField* '}' ';' # StructDef
+    | 'typedef' type Ident ';'                          # TypedefDef
+    | 'enum' Ident '{' Ident (',' Ident)* '}' ';'?      # EnumDef
+    | 'extern' type Ident '(' (arg (',' arg)*)? ')' ';'  # ExternDef
+    ;
+
+inlineHint
+    : 'inline'                                  # Inline
+    | 'noinline'                                # NoInline
     ;
 
 // an argument is a type and identifier
@@ -22,19 +38,50 @@ structField
     : type Ident ';'
     ;
 
-// statements can be the following
+structExtends
+    : 'extends' Ident
+    ;
+
+typeParams
+    : '<' Ident (',' Ident)* '>'
+    ;
+
+typeArgs
+    : '<' type (',' type)* '>'
+    ;
+
+// statements can be the following, where a statement that can be read as both
+// a declaration and an expression is a declaration, so that 'Node* p = q;'
+// declares a pointer and 'Box<Node> b;' an instance of a generic struct
 stm
-    : exp ';'                                   # ExpStm
-    | type item (',' item)* ';'                 # DeclsStm
+    : (type | 'var') item (',' item)* ';'       # DeclsStm
+    | exp ';'                                   # ExpStm
+    | '(' arg (',' arg)+ ')' '=' exp ';'        # TupleDeclStm
     | 'return' exp ';'                          # ReturnStm
     | 'return' ';'                              # VoidReturnStm
     | 'for' '(' type Ident ':' exp ')' stm      # ForEachStm
     | 'while' '(' exp ')' stm                   # WhileStm
     | '{' stm* '}'                              # BlockStm
     | 'if' '(' exp ')' stm ('else' stm)?        # IfStm
+    | 'switch' '(' exp ')' '{' switchCase* '}'  # SwitchStm
+    | 'throw' exp ';'                           # ThrowStm
+    | 'try' '{' stm* '}' catchClause+           # TryStm
+    | 'assert' '(' exp (',' exp)? ')' ';'       # AssertStm
+    | 'defer' exp ';'                           # DeferStm
     | ';'                                       # BlankStm
     ;
 
+// switch cases do not fall through to the next case
+switchCase
+    : 'case' exp (',' exp)* ':' stm*            # ExpCase
+    | 'default' ':' stm*                        # DefaultCase
+    ;
+
+// a catch clause handles the errors of its type and the types extending it
+catchClause
+    : 'catch' '(' type Ident ')' '{' stm* '}'
+    ;
+
 item
     : Ident                             # NoInitItem
     | Ident '=' exp                     # InitItem
@@ -43,14 +90,17 @@ item
 // expressions can be the following
 exp
     : '(' exp ')'                                # ParenExp
+    | '(' exp (',' exp)+ ')'                     # TupleExp
     | '(' type ')' 'null'                        # NullPtrExp
     | boolLit                                    # BoolExp
     | Integer                                    # IntExp
     | Double                                     # DoubleExp
     | 'new' baseType arrayIndex+                 # NewArrExp
-    | 'new' Ident                                # NewStructExp
+    | 'new' Ident typeArgs?                      # NewStructExp
+    | Ident '{' (fieldInit (',' fieldInit)*)? '}' # StructLitExp
     | Ident                                      # IdentExp
     | Ident '(' (exp (',' exp)*)? ')'            # FuncExp
+    | exp '(' (exp (',' exp)*)? ')'              # CallExp
     | exp arrayIndex+                            # ArrIndexExp
     | exp '.' Ident                              # FieldExp
     | exp '->' Ident                             # DerefExp
@@ -65,18 +115,25 @@ exp
     | exp '&&' exp                               # AndExp
     | exp '||' exp                               # OrExp
     | <assoc=right> exp '=' exp                  # AssignExp
+    | '(' (arg (',' arg)*)? ')' '->' exp         # LambdaExp
     ;
 
 arrayIndex
     : '[' exp ']'
     ;
 
+// a field initializer of a struct literal, where omitted fields are zero
+fieldInit
+    : Ident ':' exp
+    ;
+
 boolType: 'boolean';
 intType: 'int';
 doubleType: 'double';
 stringType: 'string';
 voidType: 'void';
-customType: Ident;
+customType: Ident typeArgs?;
+structType: 'struct' Ident typeArgs?;
 baseType
     : boolType
     | intType
@@ -84,10 +141,17 @@ baseType
     | stringType
     | voidType
     | customType
+    | structType
     ;
 
 type
-    : baseType arraySuffix*             #PrimitiveType
+    : baseType ptrSuffix? arraySuffix*              #PrimitiveType
+    | 'fn' '(' (type (',' type)*)? ')' '->' type    #FuncType
+    | '(' type (',' type)+ ')'                      #TupleType
+    ;
+
+ptrSuffix
+    : '*'
     ;
 
 arraySuffix
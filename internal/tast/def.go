@@ -6,11 +6,21 @@ type Def interface {
 	defNode()
 }
 
+// Inlining is the inlining annotation of a function definition.
+type Inlining int
+
+const (
+	InlineAuto   Inlining = iota // inlined if small enough
+	InlineAlways                 // annotated with inline
+	InlineNever                  // annotated with noinline
+)
+
 // FuncDef represents a function definition in the TAST.
 type FuncDef struct {
-	Id   string // Function name
-	Args []Arg  // Function arguments
-	Stms []Stm  // Function body statements
+	Id     string   // Function name
+	Args   []Arg    // Function arguments
+	Stms   []Stm    // Function body statements
+	Inline Inlining // Inlining annotation
 
 	BaseTypedNode // Embeds type and source location information
 }
@@ -18,20 +28,22 @@ type FuncDef struct {
 func (*FuncDef) defNode() {}
 
 // NewFuncDef creates a new FuncDef node with the given name, arguments, body
-// statements, type, and source location information.
+// statements, inlining annotation, type, and source location information.
 func NewFuncDef(
 	id string,
 	args []Arg,
 	stms []Stm,
+	inline Inlining,
 	typ Type,
 	line int,
 	col int,
 	text string,
 ) *FuncDef {
 	return &FuncDef{
-		Id:   id,
-		Args: args,
-		Stms: stms,
+		Id:     id,
+		Args:   args,
+		Stms:   stms,
+		Inline: inline,
 		BaseTypedNode: BaseTypedNode{
 			typ:      typ,
 			BaseNode: BaseNode{line: line, col: col, text: text},
@@ -42,6 +54,40 @@ func NewFuncDef(
 // check that FuncDef implements Def
 var _ Def = (*FuncDef)(nil)
 
+// ExternDef represents the declaration of a function defined outside of the
+// program, such as in a C library, in the TAST.
+type ExternDef struct {
+	Id   string // Function name
+	Args []Arg  // Function arguments
+
+	BaseTypedNode // Embeds type and source location information
+}
+
+func (*ExternDef) defNode() {}
+
+// NewExternDef creates a new ExternDef node with the given name, arguments,
+// return type, and source location information.
+func NewExternDef(
+	id string,
+	args []Arg,
+	typ Type,
+	line int,
+	col int,
+	text string,
+) *ExternDef {
+	return &ExternDef{
+		Id:   id,
+		Args: args,
+		BaseTypedNode: BaseTypedNode{
+			typ:      typ,
+			BaseNode: BaseNode{line: line, col: col, text: text},
+		},
+	}
+}
+
+// check that ExternDef implements Def
+var _ Def = (*ExternDef)(nil)
+
 // StructDef represents a struct definition in the TAST.
 type StructDef struct {
 	BaseTypedNode // Embed type and source location information
@@ -96,3 +142,29 @@ func NewTypedefDef(
 
 // check that TypeDef implements Def
 var _ Def = (*TypedefDef)(nil)
+
+// EnumDef represents an enum definition in the TAST.
+type EnumDef struct {
+	BaseTypedNode // Embed type and source location information
+}
+
+func (*EnumDef) defNode() {}
+
+// NewEnumDef creates a new EnumDef node with the given enum type and source
+// location information.
+func NewEnumDef(
+	enumType *EnumType,
+	line,
+	col int,
+	text string,
+) *EnumDef {
+	return &EnumDef{
+		BaseTypedNode: BaseTypedNode{
+			typ:      enumType,
+			BaseNode: BaseNode{line: line, col: col, text: text},
+		},
+	}
+}
+
+// check that EnumDef implements Def
+var _ Def = (*EnumDef)(nil)
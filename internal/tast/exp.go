@@ -190,7 +190,8 @@ var _ Exp = (*DoubleExp)(nil)
 
 // NewArrExp represents array allocation expression in the TAST.
 type NewArrExp struct {
-	Exps []Exp // Array index expressions
+	Exps    []Exp // Array index expressions
+	OnStack bool  // Whether the array does not escape and is stack allocated
 
 	BaseTypedNode // Embeds type and source location information
 }
@@ -222,6 +223,8 @@ var _ Exp = (*NewArrExp)(nil)
 
 // NewStructExp represents struct allocation expression in the TAST.
 type NewStructExp struct {
+	OnStack bool // Whether the struct does not escape and is stack allocated
+
 	BaseTypedNode // Embeds type and source location information
 }
 
@@ -281,8 +284,9 @@ var _ Exp = (*IdentExp)(nil)
 
 // FuncExp represents a function call expression node in the TAST.
 type FuncExp struct {
-	Id   string // Function name
-	Exps []Exp  // Function arguments
+	Id     string // Function name
+	Exps   []Exp  // Function arguments
+	Params []Type // Parameter types of the called function
 
 	BaseTypedNode // Embeds type and source location information
 }
@@ -292,18 +296,20 @@ func (FuncExp) HasSideEffect() bool { return true }
 func (FuncExp) IsLValue() bool      { return false }
 
 // NewFuncExp creates a new FuncExp node with the given function name,
-// arguments, type, and source location.
+// arguments, parameter types of the called function, type, and source location.
 func NewFuncExp(
 	id string,
 	exps []Exp,
+	params []Type,
 	typ Type,
 	line int,
 	col int,
 	text string,
 ) *FuncExp {
 	return &FuncExp{
-		Id:   id,
-		Exps: exps,
+		Id:     id,
+		Exps:   exps,
+		Params: params,
 		BaseTypedNode: BaseTypedNode{
 			typ:      typ,
 			BaseNode: BaseNode{line: line, col: col, text: text},
@@ -314,6 +320,269 @@ func NewFuncExp(
 // check that FuncExp implements Exp
 var _ Exp = (*FuncExp)(nil)
 
+// FuncRefExp represents a reference to a named function used as a value in
+// the TAST.
+type FuncRefExp struct {
+	Id string // Function name
+
+	BaseTypedNode // Embeds type and source location information
+}
+
+func (*FuncRefExp) expNode()           {}
+func (FuncRefExp) HasSideEffect() bool { return false }
+func (FuncRefExp) IsLValue() bool      { return false }
+
+// NewFuncRefExp creates a new FuncRefExp node with the given function name,
+// function type, and source location.
+func NewFuncRefExp(
+	id string,
+	typ *FuncType,
+	line int,
+	col int,
+	text string,
+) *FuncRefExp {
+	return &FuncRefExp{
+		Id: id,
+		BaseTypedNode: BaseTypedNode{
+			typ:      typ,
+			BaseNode: BaseNode{line: line, col: col, text: text},
+		},
+	}
+}
+
+// check that FuncRefExp implements Exp
+var _ Exp = (*FuncRefExp)(nil)
+
+// CallExp represents an indirect function call through a function value in
+// the TAST.
+type CallExp struct {
+	Exp  Exp   // Expression evaluating to the function value
+	Exps []Exp // Function arguments
+
+	BaseTypedNode // Embeds type and source location information
+}
+
+func (*CallExp) expNode()           {}
+func (CallExp) HasSideEffect() bool { return true }
+func (CallExp) IsLValue() bool      { return false }
+
+// NewCallExp creates a new CallExp node with the given function value
+// expression, arguments, return type, and source location.
+func NewCallExp(
+	exp Exp,
+	exps []Exp,
+	typ Type,
+	line int,
+	col int,
+	text string,
+) *CallExp {
+	return &CallExp{
+		Exp:  exp,
+		Exps: exps,
+		BaseTypedNode: BaseTypedNode{
+			typ:      typ,
+			BaseNode: BaseNode{line: line, col: col, text: text},
+		},
+	}
+}
+
+// check that CallExp implements Exp
+var _ Exp = (*CallExp)(nil)
+
+// EnumExp represents a constant of an enum in the TAST.
+type EnumExp struct {
+	Constant string // Name of the constant
+	Value    int    // Index of the constant in the enum
+
+	BaseTypedNode // Embeds type and source location information
+}
+
+func (*EnumExp) expNode()           {}
+func (EnumExp) HasSideEffect() bool { return false }
+func (EnumExp) IsLValue() bool      { return false }
+
+// NewEnumExp creates a new EnumExp node with the given constant name, its
+// index, enum type, and source location.
+func NewEnumExp(
+	constant string,
+	value int,
+	typ *EnumType,
+	line int,
+	col int,
+	text string,
+) *EnumExp {
+	return &EnumExp{
+		Constant: constant,
+		Value:    value,
+		BaseTypedNode: BaseTypedNode{
+			typ:      typ,
+			BaseNode: BaseNode{line: line, col: col, text: text},
+		},
+	}
+}
+
+// check that EnumExp implements Exp
+var _ Exp = (*EnumExp)(nil)
+
+// EnumNameExp represents the name of the constant an enum value holds, as a
+// string, in the TAST.
+type EnumNameExp struct {
+	Exp Exp // Enum valued expression
+
+	BaseTypedNode // Embeds type and source location information
+}
+
+func (*EnumNameExp) expNode()           {}
+func (EnumNameExp) HasSideEffect() bool { return false }
+func (EnumNameExp) IsLValue() bool      { return false }
+
+// NewEnumNameExp creates a new EnumNameExp node with the given enum valued
+// expression and source location.
+func NewEnumNameExp(
+	exp Exp,
+	line int,
+	col int,
+	text string,
+) *EnumNameExp {
+	return &EnumNameExp{
+		Exp: exp,
+		BaseTypedNode: BaseTypedNode{
+			typ:      String,
+			BaseNode: BaseNode{line: line, col: col, text: text},
+		},
+	}
+}
+
+// check that EnumNameExp implements Exp
+var _ Exp = (*EnumNameExp)(nil)
+
+// LambdaExp represents an anonymous function in the TAST. Captures holds the
+// variables of enclosing scopes referenced in the body, whose values are copied
+// into the closure environment when the lambda is evaluated.
+type LambdaExp struct {
+	Id       string      // Unique generated name of the lifted function
+	Args     []Arg       // Lambda parameters
+	Captures []*IdentExp // Captured variables of enclosing scopes
+	Exp      Exp         // Body expression
+
+	BaseTypedNode // Embeds type and source location information
+}
+
+func (*LambdaExp) expNode()           {}
+func (LambdaExp) HasSideEffect() bool { return false }
+func (LambdaExp) IsLValue() bool      { return false }
+
+// NewLambdaExp creates a new LambdaExp node with the given generated name,
+// parameters, captured variables, body, function type, and source location.
+func NewLambdaExp(
+	id string,
+	args []Arg,
+	captures []*IdentExp,
+	exp Exp,
+	typ *FuncType,
+	line int,
+	col int,
+	text string,
+) *LambdaExp {
+	return &LambdaExp{
+		Id:       id,
+		Args:     args,
+		Captures: captures,
+		Exp:      exp,
+		BaseTypedNode: BaseTypedNode{
+			typ:      typ,
+			BaseNode: BaseNode{line: line, col: col, text: text},
+		},
+	}
+}
+
+// check that LambdaExp implements Exp
+var _ Exp = (*LambdaExp)(nil)
+
+// TupleExp represents a tuple literal expression in the TAST.
+type TupleExp struct {
+	Elems []Exp // Element expressions
+
+	BaseTypedNode // Embeds type and source location information
+}
+
+func (*TupleExp) expNode()      {}
+func (TupleExp) IsLValue() bool { return false }
+
+func (e TupleExp) HasSideEffect() bool {
+	for _, elem := range e.Elems {
+		if elem.HasSideEffect() {
+			return true
+		}
+	}
+	return false
+}
+
+// NewTupleExp creates a new TupleExp node with the given elements, tuple type,
+// and source location.
+func NewTupleExp(
+	elems []Exp,
+	typ *TupleType,
+	line int,
+	col int,
+	text string,
+) *TupleExp {
+	return &TupleExp{
+		Elems: elems,
+		BaseTypedNode: BaseTypedNode{
+			typ:      typ,
+			BaseNode: BaseNode{line: line, col: col, text: text},
+		},
+	}
+}
+
+// check that TupleExp implements Exp
+var _ Exp = (*TupleExp)(nil)
+
+// StructLitExp represents a struct literal expression in the TAST, creating a
+// struct value whose fields not initialized by it are zero.
+type StructLitExp struct {
+	Fields []string // Names of the initialized fields
+	Exps   []Exp    // Initializer expressions, one for each field
+
+	BaseTypedNode // Embeds type and source location information
+}
+
+func (*StructLitExp) expNode()      {}
+func (StructLitExp) IsLValue() bool { return false }
+
+func (e StructLitExp) HasSideEffect() bool {
+	for _, exp := range e.Exps {
+		if exp.HasSideEffect() {
+			return true
+		}
+	}
+	return false
+}
+
+// NewStructLitExp creates a new StructLitExp node with the given field names,
+// initializer expressions, struct type, and source location.
+func NewStructLitExp(
+	fields []string,
+	exps []Exp,
+	typ *StructType,
+	line int,
+	col int,
+	text string,
+) *StructLitExp {
+	return &StructLitExp{
+		Fields: fields,
+		Exps:   exps,
+		BaseTypedNode: BaseTypedNode{
+			typ:      typ,
+			BaseNode: BaseNode{line: line, col: col, text: text},
+		},
+	}
+}
+
+// check that StructLitExp implements Exp
+var _ Exp = (*StructLitExp)(nil)
+
 // ArrIndexExp represents an array element access expression in the TAST.
 type ArrIndexExp struct {
 	Exp     Exp   // Array expression
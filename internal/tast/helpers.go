@@ -6,7 +6,8 @@ import "slices"
 // paths when traversing all children nodes of the statement node TAST.
 func GuaranteesReturn(stm Stm) bool {
 	switch s := stm.(type) {
-	case *ReturnStm:
+	case *ReturnStm, *ThrowStm:
+		// a throw statement never completes normally either
 		return true
 	case *BlockStm:
 		// a block guarantees return if at least one statement guarantees return
@@ -17,6 +18,31 @@ func GuaranteesReturn(stm Stm) bool {
 			return false // no else branch means no guarantee
 		}
 		return GuaranteesReturn(s.ThenStm) && GuaranteesReturn(s.ElseStm)
+	case *SwitchStm:
+		// switch statement guarantees return only if it covers every value and
+		// all of its cases guarantee return
+		if !s.Exhaustive {
+			return false
+		}
+		for _, c := range s.Cases {
+			if !slices.ContainsFunc(c.Stms, GuaranteesReturn) {
+				return false
+			}
+		}
+		return s.Default == nil ||
+			slices.ContainsFunc(s.Default.Stms, GuaranteesReturn)
+	case *TryStm:
+		// try statement guarantees return only if the try block and all of its
+		// catch clauses guarantee return, since unhandled errors propagate
+		if !slices.ContainsFunc(s.Stms, GuaranteesReturn) {
+			return false
+		}
+		for _, c := range s.Catches {
+			if !slices.ContainsFunc(c.Stms, GuaranteesReturn) {
+				return false
+			}
+		}
+		return true
 	default:
 		return false
 	}
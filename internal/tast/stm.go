@@ -239,6 +239,247 @@ func NewIfStm(
 // ensure that IfStm implements Stm
 var _ Stm = (*IfStm)(nil)
 
+// SwitchCase represents a case of a switch statement in the TAST, matching any
+// of its values. Cases do not fall through.
+type SwitchCase struct {
+	Values []int // Values matched by the case, empty for the default case
+	Stms   []Stm // Statements executed when the case matches
+
+	BaseNode // Embeds source location information
+}
+
+// NewSwitchCase creates a new SwitchCase with the given values, statements, and
+// source location.
+func NewSwitchCase(
+	values []int,
+	stms []Stm,
+	line int,
+	col int,
+	text string,
+) *SwitchCase {
+	return &SwitchCase{
+		Values:   values,
+		Stms:     stms,
+		BaseNode: BaseNode{line: line, col: col, text: text},
+	}
+}
+
+// SwitchStm represents a switch statement node in the TAST.
+type SwitchStm struct {
+	Exp        Exp           // Expression switched on
+	Cases      []*SwitchCase // Cases with values
+	Default    *SwitchCase   // Default case (nil if absent)
+	Exhaustive bool          // Whether the cases cover every possible value
+
+	BaseNode // Embeds source location information
+}
+
+func (*SwitchStm) stmNode() {}
+
+// NewSwitchStm creates a new SwitchStm node with the given expression, cases,
+// default case, exhaustiveness, and source location.
+func NewSwitchStm(
+	exp Exp,
+	cases []*SwitchCase,
+	defaultCase *SwitchCase,
+	exhaustive bool,
+	line int,
+	col int,
+	text string,
+) *SwitchStm {
+	return &SwitchStm{
+		Exp:        exp,
+		Cases:      cases,
+		Default:    defaultCase,
+		Exhaustive: exhaustive,
+		BaseNode:   BaseNode{line: line, col: col, text: text},
+	}
+}
+
+// ensure that SwitchStm implements Stm
+var _ Stm = (*SwitchStm)(nil)
+
+// TupleDeclStm represents a destructuring declaration node in the TAST, which
+// declares a variable for each element of a tuple.
+type TupleDeclStm struct {
+	Ids   []string // Names of the declared variables
+	Types []Type   // Declared types of the variables
+	Exp   Exp      // Destructured tuple
+
+	BaseNode // Embeds source location information
+}
+
+func (*TupleDeclStm) stmNode() {}
+
+// NewTupleDeclStm creates a new TupleDeclStm node with the given variables,
+// destructured expression, and source location.
+func NewTupleDeclStm(
+	ids []string,
+	types []Type,
+	exp Exp,
+	line int,
+	col int,
+	text string,
+) *TupleDeclStm {
+	return &TupleDeclStm{
+		Ids:      ids,
+		Types:    types,
+		Exp:      exp,
+		BaseNode: BaseNode{line: line, col: col, text: text},
+	}
+}
+
+// ensure that TupleDeclStm implements Stm
+var _ Stm = (*TupleDeclStm)(nil)
+
+// ThrowStm represents a throw statement node in the TAST, which transfers
+// control to the innermost catch clause handling the thrown value.
+type ThrowStm struct {
+	Exp Exp // Thrown value, a pointer to a struct extending Error
+
+	BaseNode // Embeds source location information
+}
+
+func (*ThrowStm) stmNode() {}
+
+// NewThrowStm creates a new ThrowStm node with the given thrown expression and
+// source location.
+func NewThrowStm(
+	exp Exp,
+	line int,
+	col int,
+	text string,
+) *ThrowStm {
+	return &ThrowStm{
+		Exp:      exp,
+		BaseNode: BaseNode{line: line, col: col, text: text},
+	}
+}
+
+// ensure that ThrowStm implements Stm
+var _ Stm = (*ThrowStm)(nil)
+
+// CatchClause represents a catch clause of a try statement in the TAST,
+// handling thrown values of its error struct and the structs extending it.
+type CatchClause struct {
+	Id    string      // Name of the variable bound to the thrown value
+	Type  Type        // Declared type of the variable
+	Error *StructType // Error struct handled by the clause
+	Stms  []Stm       // Statements executed when the clause handles a value
+
+	BaseNode // Embeds source location information
+}
+
+// NewCatchClause creates a new CatchClause with the given variable, handled
+// error struct, statements, and source location.
+func NewCatchClause(
+	id string,
+	typ Type,
+	errorType *StructType,
+	stms []Stm,
+	line int,
+	col int,
+	text string,
+) *CatchClause {
+	return &CatchClause{
+		Id:       id,
+		Type:     typ,
+		Error:    errorType,
+		Stms:     stms,
+		BaseNode: BaseNode{line: line, col: col, text: text},
+	}
+}
+
+// TryStm represents a try statement node in the TAST.
+type TryStm struct {
+	Stms    []Stm          // Statements of the try block
+	Catches []*CatchClause // Catch clauses, tried in order
+
+	BaseNode // Embeds source location information
+}
+
+func (*TryStm) stmNode() {}
+
+// NewTryStm creates a new TryStm node with the given statements, catch
+// clauses, and source location.
+func NewTryStm(
+	stms []Stm,
+	catches []*CatchClause,
+	line int,
+	col int,
+	text string,
+) *TryStm {
+	return &TryStm{
+		Stms:     stms,
+		Catches:  catches,
+		BaseNode: BaseNode{line: line, col: col, text: text},
+	}
+}
+
+// ensure that TryStm implements Stm
+var _ Stm = (*TryStm)(nil)
+
+// AssertStm represents an assert statement node in the TAST, which stops the
+// program with a message locating the assertion when its condition is false.
+type AssertStm struct {
+	Exp     Exp    // Asserted condition
+	Message Exp    // Message of the failure (nil if absent)
+	File    string // Name of the file containing the assertion
+
+	BaseNode // Embeds source location information
+}
+
+func (*AssertStm) stmNode() {}
+
+// NewAssertStm creates a new AssertStm node with the given condition, message,
+// file name, and source location.
+func NewAssertStm(
+	exp Exp,
+	message Exp,
+	file string,
+	line int,
+	col int,
+	text string,
+) *AssertStm {
+	return &AssertStm{
+		Exp:      exp,
+		Message:  message,
+		File:     file,
+		BaseNode: BaseNode{line: line, col: col, text: text},
+	}
+}
+
+// ensure that AssertStm implements Stm
+var _ Stm = (*AssertStm)(nil)
+
+// DeferStm represents a defer statement node in the TAST, whose expression is
+// evaluated when the enclosing block exits, normally, by returning or by a
+// thrown value.
+type DeferStm struct {
+	Exp Exp // Deferred expression
+
+	BaseNode // Embeds source location information
+}
+
+func (*DeferStm) stmNode() {}
+
+// NewDeferStm creates a new DeferStm node with the given expression and source
+// location.
+func NewDeferStm(
+	exp Exp,
+	line int,
+	col int,
+	text string,
+) *DeferStm {
+	return &DeferStm{
+		Exp:      exp,
+		BaseNode: BaseNode{line: line, col: col, text: text},
+	}
+}
+
+// ensure that DeferStm implements Stm
+var _ Stm = (*DeferStm)(nil)
+
 // BlankStm represents an empty statement node in the TAST.
 type BlankStm struct {
 	BaseNode // Embeds source location information
@@ -1,5 +1,7 @@
 package tast
 
+import "strings"
+
 // Type represents a Javalette type in the typed abstract syntax tree (TAST).
 type Type interface {
 	String() string
@@ -65,7 +67,9 @@ func Array(elem Type) *ArrayType {
 }
 
 type StructType struct {
-	Name string
+	Name     string
+	Generic  string // name of the generic struct this is an instance of
+	TypeArgs []Type // type arguments of the instance
 }
 
 // global mapping of struct fields to structs to prevent recursion problems
@@ -76,6 +80,59 @@ func RegisterStruct(name string, fields ...*FieldCreator) *StructType {
 	return &StructType{Name: name}
 }
 
+// RegisterInstance registers the fields of the instance of generic struct
+// generic with the given type arguments under the unique name.
+func RegisterInstance(
+	name string,
+	generic string,
+	typeArgs []Type,
+	fields ...*FieldCreator,
+) *StructType {
+	structFields[name] = fields
+	return &StructType{Name: name, Generic: generic, TypeArgs: typeArgs}
+}
+
+// global mapping of struct names to the structs they extend
+var structParents = map[string]*StructType{}
+
+// SetParent records that the struct named name extends parent. The fields of
+// parent must be registered as the first fields of the struct.
+func SetParent(name string, parent *StructType) {
+	structParents[name] = parent
+}
+
+// Parent returns the struct that s extends, if any.
+func (s *StructType) Parent() (*StructType, bool) {
+	parent, ok := structParents[s.Name]
+	return parent, ok
+}
+
+// IsSubtypeOf reports whether s is the struct named name or extends it,
+// directly or indirectly.
+func (s *StructType) IsSubtypeOf(name string) bool {
+	for current, ok := s, true; ok; current, ok = current.Parent() {
+		if current.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorName is the name of the built-in struct that every thrown value must
+// extend.
+const ErrorName = "Error"
+
+// ErrorKindField is the hidden first field of Error holding the identifier of
+// the dynamic type of a thrown value, which cannot be named in source code.
+const ErrorKindField = "$kind"
+
+// RegisterError registers the built-in Error struct, holding a message.
+func RegisterError() *StructType {
+	return RegisterStruct(
+		ErrorName, Field(Int, ErrorKindField), Field(String, "message"),
+	)
+}
+
 type FieldCreator struct {
 	Type Type
 	Name string
@@ -127,6 +184,32 @@ func (s *StructType) Fields() []string {
 	return names
 }
 
+// EnumType is an enumeration of named constants, represented by their index.
+type EnumType struct {
+	Name      string
+	Constants []string
+}
+
+func Enum(name string, constants ...string) *EnumType {
+	return &EnumType{Name: name, Constants: constants}
+}
+
+func (e *EnumType) String() string {
+	return typeSummary(e)
+}
+
+func (e *EnumType) isTastType() {}
+
+// Value returns the index of constant in the enum.
+func (e *EnumType) Value(constant string) (int, bool) {
+	for i, c := range e.Constants {
+		if c == constant {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
 type TypedefType struct {
 	Name    string
 	Aliased Type
@@ -155,15 +238,46 @@ func Pointer(elem Type) *PointerType {
 	return &PointerType{Elem: elem}
 }
 
+type FuncType struct {
+	Params  []Type
+	Returns Type
+}
+
+func (f *FuncType) String() string {
+	return typeSummary(f)
+}
+
+func (f *FuncType) isTastType() {}
+
+func Func(returns Type, params ...Type) *FuncType {
+	return &FuncType{Params: params, Returns: returns}
+}
+
+// TupleType is a fixed size group of values of possibly different types, which
+// is passed and returned by value.
+type TupleType struct {
+	Elems []Type
+}
+
+func (t *TupleType) String() string {
+	return typeSummary(t)
+}
+
+func (t *TupleType) isTastType() {}
+
+func Tuple(elems ...Type) *TupleType {
+	return &TupleType{Elems: elems}
+}
+
 func typeSummary(typ Type) string {
 	switch t := typ.(type) {
 	case *StructType:
-		return "struct " + t.Name
+		return "struct " + structSummary(t)
 	case *PointerType:
 		// only print one level that is pointer to struct or base type
 		switch elem := t.Elem.(type) {
 		case *StructType:
-			return "struct " + elem.Name + "*"
+			return "struct " + structSummary(elem) + "*"
 		default:
 			return typeSummary(elem) + "*"
 		}
@@ -173,11 +287,89 @@ func typeSummary(typ Type) string {
 		return t.String()
 	case *TypedefType:
 		return t.Name
+	case *EnumType:
+		return "enum " + t.Name
+	case *FuncType:
+		params := make([]string, len(t.Params))
+		for i, param := range t.Params {
+			params[i] = typeSummary(param)
+		}
+		return "fn(" + strings.Join(params, ", ") + ") -> " +
+			typeSummary(t.Returns)
+	case *TupleType:
+		elems := make([]string, len(t.Elems))
+		for i, elem := range t.Elems {
+			elems[i] = typeSummary(elem)
+		}
+		return "(" + strings.Join(elems, ", ") + ")"
 	default:
 		return "unknown"
 	}
 }
 
+// structSummary returns the name of a struct as written in source, with the
+// type arguments of generic struct instances in angle brackets.
+func structSummary(s *StructType) string {
+	if s.Generic == "" {
+		return s.Name
+	}
+	args := make([]string, len(s.TypeArgs))
+	for i, arg := range s.TypeArgs {
+		args[i] = typeSummary(arg)
+	}
+	return s.Generic + "<" + strings.Join(args, ", ") + ">"
+}
+
+// Mangle returns a representation of typ that can be used as part of the
+// generated names of generic instances and overloaded functions.
+func Mangle(typ Type) string {
+	switch t := typ.(type) {
+	case *StructType:
+		return t.Name
+	case *PointerType:
+		return "ptr." + Mangle(t.Elem)
+	case *ArrayType:
+		return "arr." + Mangle(t.Elem)
+	case *TypedefType:
+		return Mangle(t.Aliased)
+	case *EnumType:
+		return t.Name
+	case *FuncType:
+		var sb strings.Builder
+		sb.WriteString("fn.")
+		for _, param := range t.Params {
+			sb.WriteString(Mangle(param))
+			sb.WriteString(".")
+		}
+		sb.WriteString("to.")
+		sb.WriteString(Mangle(t.Returns))
+		return sb.String()
+	case *TupleType:
+		var sb strings.Builder
+		sb.WriteString("tup.")
+		for _, elem := range t.Elems {
+			sb.WriteString(Mangle(elem))
+			sb.WriteString(".")
+		}
+		sb.WriteString("end")
+		return sb.String()
+	case BaseType:
+		switch t {
+		case Int:
+			return "int"
+		case Double:
+			return "double"
+		case Bool:
+			return "boolean"
+		case String:
+			return "string"
+		case Void:
+			return "void"
+		}
+	}
+	return "unknown"
+}
+
 // Op represents an operator in the TAST.
 type Op int
 
@@ -243,3 +435,5 @@ var _ Type = (*StructType)(nil)
 var _ FieldProvider = (*StructType)(nil)
 var _ Type = (*TypedefType)(nil)
 var _ Type = (*PointerType)(nil)
+var _ Type = (*FuncType)(nil)
+var _ Type = (*EnumType)(nil)
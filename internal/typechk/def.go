@@ -12,6 +12,10 @@ func (tc *TypeChecker) checkDefs(defs []parser.IDefContext) ([]tast.Def, error)
 
 	var typedDefs []tast.Def
 	for _, def := range defs {
+		// generic definitions are checked per instantiation
+		if isGenericDef(def) {
+			continue
+		}
 		typedDef, err := tc.checkDef(def)
 		if err != nil {
 			return nil, err
@@ -19,19 +23,43 @@ func (tc *TypeChecker) checkDefs(defs []parser.IDefContext) ([]tast.Def, error)
 		typedDefs = append(typedDefs, typedDef)
 		tc.env.SetReturnType(tast.Unknown)
 	}
+
+	instances, err := tc.checkFuncInstances()
+	if err != nil {
+		return nil, err
+	}
+	typedDefs = append(typedDefs, instances...)
+	typedDefs = append(typedDefs, tc.instances...)
 	return typedDefs, nil
 }
 
+func isGenericDef(def parser.IDefContext) bool {
+	switch d := def.(type) {
+	case *parser.FuncDefContext:
+		return d.TypeParams() != nil
+	case *parser.StructDefContext:
+		return d.TypeParams() != nil
+	default:
+		return false
+	}
+}
+
 func (tc *TypeChecker) checkDef(def parser.IDefContext) (tast.Def, error) {
 	tc.env.EnterContext()
 	line, col, text := extractPosData(def)
 	switch d := def.(type) {
 	case *parser.FuncDefContext:
-		return tc.checkFuncDef(d, line, col, text)
+		return tc.checkFuncDef(
+			d, tc.funcLink(d.Ident().GetText()), line, col, text,
+		)
 	case *parser.StructDefContext:
 		return tc.checkStructDef(d, line, col, text)
 	case *parser.TypedefDefContext:
 		return tc.checkTypedefDef(d, line, col, text)
+	case *parser.EnumDefContext:
+		return tc.checkEnumDef(d, line, col, text)
+	case *parser.ExternDefContext:
+		return tc.checkExternDef(d, line, col, text)
 	default:
 		return nil, fmt.Errorf(
 			"checkDef: unhandled def type %T at %d:%d near '%s'",
@@ -41,7 +69,7 @@ func (tc *TypeChecker) checkDef(def parser.IDefContext) (tast.Def, error) {
 }
 
 func (tc *TypeChecker) checkFuncDef(
-	d *parser.FuncDefContext, line, col int, text string,
+	d *parser.FuncDefContext, name string, line, col int, text string,
 ) (*tast.FuncDef, error) {
 	_, params, err := tc.extractParams(d.AllArg())
 	if err != nil {
@@ -53,7 +81,7 @@ func (tc *TypeChecker) checkFuncDef(
 		if !ok {
 			return nil, fmt.Errorf(
 				"duplicate parameter name '%s' in function '%s' at %d:%d",
-				varName, d.Ident().GetText(), line, col,
+				varName, name, line, col,
 			)
 		}
 	}
@@ -64,13 +92,9 @@ func (tc *TypeChecker) checkFuncDef(
 	}
 	tc.env.SetReturnType(typ)
 
-	var typedStms []tast.Stm
-	for _, stm := range d.AllStm() {
-		typedStm, err := tc.checkStm(stm)
-		if err != nil {
-			return nil, err
-		}
-		typedStms = append(typedStms, typedStm)
+	typedStms, err := tc.checkStmList(d.AllStm())
+	if err != nil {
+		return nil, err
 	}
 
 	hasReturn := slices.ContainsFunc(typedStms, tast.GuaranteesReturn)
@@ -101,10 +125,19 @@ func (tc *TypeChecker) checkFuncDef(
 		return nil, err
 	}
 	tc.env.ExitContext()
+
+	inline := tast.InlineAuto
+	switch d.InlineHint().(type) {
+	case *parser.InlineContext:
+		inline = tast.InlineAlways
+	case *parser.NoInlineContext:
+		inline = tast.InlineNever
+	}
 	return tast.NewFuncDef(
-		d.Ident().GetText(),
+		name,
 		typedArgs,
 		typedStms,
+		inline,
 		typ,
 		line, col, text,
 	), nil
@@ -134,8 +167,8 @@ func (tc *TypeChecker) checkStructDef(
 func (tc *TypeChecker) checkTypedefDef(
 	d *parser.TypedefDefContext, line, col int, text string,
 ) (*tast.TypedefDef, error) {
-	alias := d.Type_(1).GetText()
-	aliasedType, err := tc.toTastType(d.Type_(0))
+	alias := d.Ident().GetText()
+	aliasedType, err := tc.toTastType(d.Type_())
 	if err != nil {
 		return nil, fmt.Errorf(
 			"cannot create typedef '%s' at %d:%d near %s: %w",
@@ -144,3 +177,24 @@ func (tc *TypeChecker) checkTypedefDef(
 	}
 	return tast.NewTypedefDef(alias, aliasedType, line, col, text), nil
 }
+
+func (tc *TypeChecker) checkEnumDef(
+	d *parser.EnumDefContext, line, col int, text string,
+) (*tast.EnumDef, error) {
+	enumName := d.Ident(0).GetText()
+	envEnum, ok := tc.env.LookupEnum(enumName)
+	if !ok {
+		return nil, fmt.Errorf(
+			"error typechecking enum %s at %d:%d near %s",
+			enumName, line, col, text,
+		)
+	}
+	enumType, ok := envEnum.(*tast.EnumType)
+	if !ok {
+		return nil, fmt.Errorf(
+			"error typechecking enum, did not retrieve expected type %T, but"+
+				" got %T instead", enumType, envEnum,
+		)
+	}
+	return tast.NewEnumDef(enumType, line, col, text), nil
+}
@@ -24,10 +24,18 @@ func (tc *TypeChecker) inferExp(exp parser.IExpContext) (tast.Exp, error) {
 		return tc.inferNewArrExp(e, line, col, text)
 	case *parser.NewStructExpContext:
 		return tc.inferNewStructExp(e, line, col, text)
+	case *parser.StructLitExpContext:
+		return tc.inferStructLitExp(e, line, col, text)
 	case *parser.IdentExpContext:
 		return tc.inferIdentExp(e, line, col, text)
 	case *parser.FuncExpContext:
 		return tc.inferFuncExp(e, line, col, text)
+	case *parser.CallExpContext:
+		return tc.inferCallExp(e, line, col, text)
+	case *parser.LambdaExpContext:
+		return tc.inferLambdaExp(e, line, col, text)
+	case *parser.TupleExpContext:
+		return tc.inferTupleExp(e, line, col, text)
 	case *parser.ArrIndexExpContext:
 		return tc.inferArrIndexExp(e, line, col, text)
 	case *parser.FieldExpContext:
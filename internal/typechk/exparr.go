@@ -20,7 +20,7 @@ func (tc *TypeChecker) inferNewArrExp(
 		if err != nil {
 			return nil, err
 		}
-		if typedExp.Type() != tast.Int {
+		if UnwrapTypedef(typedExp.Type()) != tast.Int {
 			return nil, fmt.Errorf(
 				"array index at dimension %d must be of integer type at %d:%d "+
 					" near %s", i, line, col, text,
@@ -69,7 +69,7 @@ func (tc *TypeChecker) inferArrayIndexing(
 			return nil, nil, err
 		}
 
-		if idxExp.Type() != tast.Int {
+		if UnwrapTypedef(idxExp.Type()) != tast.Int {
 			return nil, nil, fmt.Errorf(
 				"array index access at dimension %d must be integer type at "+
 					"%d:%d near %s", i, line, col, text,
@@ -77,7 +77,7 @@ func (tc *TypeChecker) inferArrayIndexing(
 		}
 		idxExps = append(idxExps, idxExp)
 
-		arrType, ok := currentType.(*tast.ArrayType)
+		arrType, ok := UnwrapTypedef(currentType).(*tast.ArrayType)
 		if !ok {
 			return nil, nil, fmt.Errorf(
 				"array index mismatch at dimension %d at %d:%d near %s "+
@@ -92,14 +92,39 @@ func (tc *TypeChecker) inferArrayIndexing(
 
 func (tc *TypeChecker) inferFieldExp(
 	e *parser.FieldExpContext, line, col int, text string,
-) (*tast.FieldExp, error) {
+) (tast.Exp, error) {
+	fieldName := e.Ident().GetText()
+
+	// constants of enums are accessed through the enum name, unless it is
+	// shadowed by a variable
+	if ident, ok := e.Exp().(*parser.IdentExpContext); ok {
+		enumName := ident.Ident().GetText()
+		if _, isVar := tc.env.LookupVar(enumName); !isVar {
+			if typ, isEnum := tc.env.LookupEnum(enumName); isEnum {
+				return tc.enumConstant(
+					typ.(*tast.EnumType), fieldName, line, col, text,
+				)
+			}
+		}
+	}
+
 	exp, err := tc.inferExp(e.Exp())
 	if err != nil {
 		return nil, err
 	}
-	fieldName := e.Ident().GetText()
 
-	fieldProviderType, ok := exp.Type().(tast.FieldProvider)
+	// enum values provide the name of their constant as a string
+	if _, ok := UnwrapTypedef(exp.Type()).(*tast.EnumType); ok {
+		if fieldName != "name" {
+			return nil, fmt.Errorf(
+				"type %s does not have field %s at %d:%d near %s",
+				exp.Type(), fieldName, line, col, text,
+			)
+		}
+		return tast.NewEnumNameExp(exp, line, col, text), nil
+	}
+
+	fieldProviderType, ok := UnwrapTypedef(exp.Type()).(tast.FieldProvider)
 	if !ok {
 		return nil, fmt.Errorf(
 			"type %s does not have any accessible fields at %d:%d near %s",
@@ -120,3 +145,17 @@ func (tc *TypeChecker) inferFieldExp(
 		line, col, text,
 	), nil
 }
+
+// enumConstant returns the constant of enumType with the given name.
+func (tc *TypeChecker) enumConstant(
+	enumType *tast.EnumType, constant string, line, col int, text string,
+) (*tast.EnumExp, error) {
+	value, ok := enumType.Value(constant)
+	if !ok {
+		return nil, fmt.Errorf(
+			"enum '%s' has no constant '%s' at %d:%d near '%s'",
+			enumType.Name, constant, line, col, text,
+		)
+	}
+	return tast.NewEnumExp(constant, value, enumType, line, col, text), nil
+}
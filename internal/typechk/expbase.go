@@ -60,50 +60,169 @@ func (tc *TypeChecker) inferDoubleExp(
 
 func (tc *TypeChecker) inferIdentExp(
 	e *parser.IdentExpContext, line, col int, text string,
-) (*tast.IdentExp, error) {
+) (tast.Exp, error) {
 	varName := e.Ident().GetText()
-	typ, ok := tc.env.LookupVar(varName)
-	if !ok {
-		return nil, fmt.Errorf(
-			"trying to reference an undeclared variable '%s' at %d:%d",
-			varName, line, col,
-		)
+	typ, ok := tc.lookupVar(varName, line, col)
+	if ok {
+		return tast.NewIdentExp(
+			varName, typ, line, col, text,
+		), nil
 	}
-	return tast.NewIdentExp(varName, typ, line, col, text), nil
+
+	// fall back to referencing a named function as a function value
+	if signs := tc.env.LookupFuncs(varName); len(signs) > 0 {
+		if len(signs) > 1 {
+			return nil, fmt.Errorf(
+				"ambiguous reference to overloaded function '%s' at %d:%d",
+				varName, line, col,
+			)
+		}
+		return tast.NewFuncRefExp(
+			tc.funcLink(varName), signatureType(signs[0]), line, col, text,
+		), nil
+	}
+	return nil, fmt.Errorf(
+		"trying to reference an undeclared variable '%s' at %d:%d",
+		varName, line, col,
+	)
 }
 
 func (tc *TypeChecker) inferFuncExp(
 	e *parser.FuncExpContext, line, col int, text string,
-) (*tast.FuncExp, error) {
-	// check if func is defined before it is called and that call is correct
+) (tast.Exp, error) {
 	funcName := e.Ident().GetText()
-	// check if func signature in env
-	sign, exists := tc.env.LookupFunc(funcName)
-	if !exists {
+
+	// variables of function type shadow named functions
+	if typ, ok := tc.lookupVar(funcName, line, col); ok {
+		if funcType, ok := UnwrapTypedef(typ).(*tast.FuncType); ok {
+			typedExps, err := tc.checkCallArgs(
+				funcName, funcType.Params, e.AllExp(), line, col,
+			)
+			if err != nil {
+				return nil, err
+			}
+			return tast.NewCallExp(
+				tast.NewIdentExp(funcName, typ, line, col, funcName),
+				typedExps,
+				funcType.Returns,
+				line, col, text,
+			), nil
+		}
+	}
+
+	// generic functions are instantiated for the inferred type arguments
+	if tmpl, ok := tc.funcTemplates[funcName]; ok {
+		return tc.inferGenericFuncExp(tmpl, e, line, col, text)
+	}
+
+	// check if func is defined before it is called and that call is correct
+	signs := tc.env.LookupFuncs(funcName)
+	if len(signs) == 0 {
 		return nil, fmt.Errorf(
 			"calling undefined function '%s' at %d:%d", funcName, line, col,
 		)
 	}
-	expTypes := []tast.Type{}
-	typedExps := []tast.Exp{}
 
-	// extract tast.in correct order
-	paramTypes := make([]tast.Type, 0, len(sign.ParamNames))
-	for _, paramName := range sign.ParamNames {
-		paramTypes = append(paramTypes, sign.Params[paramName])
+	typedExps, err := tc.inferCallArgs(e.AllExp())
+	if err != nil {
+		return nil, err
+	}
+
+	var funcType *tast.FuncType
+	if len(signs) > 1 {
+		// pick the overload matching the argument types best
+		sign, err := resolveOverload(funcName, signs, typedExps, line, col)
+		if err != nil {
+			return nil, err
+		}
+		funcType = signatureType(sign)
+		for i, param := range funcType.Params {
+			typedExps[i] = promoteExp(typedExps[i], param)
+		}
+	} else {
+		funcType = signatureType(signs[0])
+		typedExps, err = convertCallArgs(
+			funcName, funcType.Params, typedExps, line, col,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tast.NewFuncExp(
+		tc.funcLink(funcName),
+		typedExps,
+		funcType.Params,
+		funcType.Returns,
+		line, col, text,
+	), nil
+}
+
+func (tc *TypeChecker) inferCallExp(
+	e *parser.CallExpContext, line, col int, text string,
+) (*tast.CallExp, error) {
+	exps := e.AllExp()
+	funcExp, err := tc.inferExp(exps[0])
+	if err != nil {
+		return nil, err
+	}
+	funcType, ok := UnwrapTypedef(funcExp.Type()).(*tast.FuncType)
+	if !ok {
+		return nil, fmt.Errorf(
+			"cannot call value of non-function type %s at %d:%d near '%s'",
+			funcExp.Type(), line, col, text,
+		)
 	}
+	typedExps, err := tc.checkCallArgs(
+		funcExp.Text(), funcType.Params, exps[1:], line, col,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return tast.NewCallExp(
+		funcExp, typedExps, funcType.Returns, line, col, text,
+	), nil
+}
 
-	for _, exp := range e.AllExp() {
+// checkCallArgs infers the argument expressions of a call to funcName and
+// checks them against paramTypes, promoting arguments where needed.
+func (tc *TypeChecker) checkCallArgs(
+	funcName string,
+	paramTypes []tast.Type,
+	args []parser.IExpContext,
+	line, col int,
+) ([]tast.Exp, error) {
+	typedExps, err := tc.inferCallArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	return convertCallArgs(funcName, paramTypes, typedExps, line, col)
+}
+
+func (tc *TypeChecker) inferCallArgs(
+	args []parser.IExpContext,
+) ([]tast.Exp, error) {
+	typedExps := []tast.Exp{}
+	for _, exp := range args {
 		typedExp, err := tc.inferExp(exp)
 		if err != nil {
 			return nil, err
 		}
-		expTypes = append(expTypes, typedExp.Type())
 		typedExps = append(typedExps, typedExp)
 	}
+	return typedExps, nil
+}
 
+// convertCallArgs checks the typed arguments of a call to funcName against
+// paramTypes, promoting int arguments to double where needed.
+func convertCallArgs(
+	funcName string,
+	paramTypes []tast.Type,
+	typedExps []tast.Exp,
+	line, col int,
+) ([]tast.Exp, error) {
 	// check if number of arguments matches function signature
-	if len(paramTypes) != len(expTypes) && len(sign.Params) > 0 {
+	if len(paramTypes) != len(typedExps) {
 		return nil, fmt.Errorf(
 			"function '%s' called with wrong number of arguments at %d:%d",
 			funcName, line, col,
@@ -111,10 +230,8 @@ func (tc *TypeChecker) inferFuncExp(
 	}
 
 	// verify and promote argument types
-	for i := range paramTypes {
-		expected := paramTypes[i]
-		actual := expTypes[i]
-
+	for i, expected := range paramTypes {
+		actual := typedExps[i].Type()
 		if !isConvertible(expected, actual) {
 			return nil, fmt.Errorf(
 				"argument %d of function '%s' has incompatible type. "+
@@ -126,13 +243,7 @@ func (tc *TypeChecker) inferFuncExp(
 		// promote expression if needed
 		typedExps[i] = promoteExp(typedExps[i], expected)
 	}
-
-	return tast.NewFuncExp(
-		funcName,
-		typedExps,
-		sign.Returns,
-		line, col, text,
-	), nil
+	return typedExps, nil
 }
 
 func (tc *TypeChecker) inferStringExp(
@@ -151,11 +262,11 @@ func (tc *TypeChecker) inferNegExp(
 	if err != nil {
 		return nil, err
 	}
-	typ := typedExp.Type()
+	typ := UnwrapTypedef(typedExp.Type())
 	if !(typ == tast.Double || typ == tast.Int) {
 		return nil, fmt.Errorf(
 			"negation not defined for type %s at %d:%d near '%s'",
-			typ.String(), line, col, text,
+			typedExp.Type(), line, col, text,
 		)
 	}
 	return tast.NewNegExp(typedExp, typ, line, col, text), nil
@@ -168,7 +279,7 @@ func (tc *TypeChecker) inferNotExp(
 	if err != nil {
 		return nil, err
 	}
-	if typ := typedExp.Type(); typ != tast.Bool {
+	if typ := UnwrapTypedef(typedExp.Type()); typ != tast.Bool {
 		return nil, fmt.Errorf(
 			"'!' not defined for type bool at %d:%d near '%s'", line, col, text,
 		)
@@ -190,7 +301,7 @@ func (tc *TypeChecker) inferPostExp(
 		)
 	}
 
-	typ := typedExp.Type()
+	typ := UnwrapTypedef(typedExp.Type())
 	if typ != tast.Int { //&& typ != tast.Double {
 		return nil, fmt.Errorf(
 			// "'++' or '--' operation can only be done on int or double at "+
@@ -219,7 +330,7 @@ func (tc *TypeChecker) inferPreExp(
 		)
 	}
 
-	typ := typedExp.Type()
+	typ := UnwrapTypedef(typedExp.Type())
 	if typ != tast.Int { //&& typ != tast.Double {
 		return nil, fmt.Errorf(
 			// "'++' or '--' operation can only be done on int or double at "+
@@ -241,12 +352,12 @@ func (tc *TypeChecker) inferMulExp(
 	if err != nil {
 		return nil, err
 	}
-	leftType := leftExp.Type()
+	leftType := UnwrapTypedef(leftExp.Type())
 	rightExp, err := tc.inferExp(e.Exp(1))
 	if err != nil {
 		return nil, err
 	}
-	rightType := rightExp.Type()
+	rightType := UnwrapTypedef(rightExp.Type())
 
 	var op tast.Op
 	switch e.MulOp().(type) {
@@ -300,12 +411,12 @@ func (tc *TypeChecker) inferAddExp(
 	if err != nil {
 		return nil, err
 	}
-	leftType := leftExp.Type()
+	leftType := UnwrapTypedef(leftExp.Type())
 	rightExp, err := tc.inferExp(e.Exp(1))
 	if err != nil {
 		return nil, err
 	}
-	rightType := rightExp.Type()
+	rightType := UnwrapTypedef(rightExp.Type())
 
 	var op tast.Op
 	switch e.AddOp().(type) {
@@ -356,8 +467,8 @@ func (tc *TypeChecker) inferCmpExp(
 		return nil, err
 	}
 
-	leftType := leftExp.Type()
-	rightType := rightExp.Type()
+	leftType := UnwrapTypedef(leftExp.Type())
+	rightType := UnwrapTypedef(rightExp.Type())
 
 	if leftType == tast.Void || rightType == tast.Void {
 		return nil, fmt.Errorf(
@@ -424,6 +535,43 @@ func (tc *TypeChecker) inferCmpExp(
 		)
 	}
 
+	// enum values can only be compared for (in)equality with the same enum
+	leftEnum, leftIsEnum := leftType.(*tast.EnumType)
+	rightEnum, rightIsEnum := rightType.(*tast.EnumType)
+	if leftIsEnum || rightIsEnum {
+		if (op != tast.OpEq && op != tast.OpNe) || leftEnum != rightEnum {
+			return nil, fmt.Errorf(
+				"illegal comparison between %s and %s at %d:%d near '%s'",
+				leftExp.Type(), rightExp.Type(), line, col, text,
+			)
+		}
+		return tast.NewCmpExp(leftExp, rightExp, op, line, col, text), nil
+	}
+
+	// function values can only be compared for (in)equality
+	leftFunc, leftIsFunc := leftType.(*tast.FuncType)
+	rightFunc, rightIsFunc := rightType.(*tast.FuncType)
+	if leftIsFunc || rightIsFunc {
+		if (op != tast.OpEq && op != tast.OpNe) || !leftIsFunc ||
+			!rightIsFunc || !sameFuncType(leftFunc, rightFunc) {
+			return nil, fmt.Errorf(
+				"illegal comparison between %s and %s at %d:%d near '%s'",
+				leftExp.Type(), rightExp.Type(), line, col, text,
+			)
+		}
+		return tast.NewCmpExp(leftExp, rightExp, op, line, col, text), nil
+	}
+
+	// struct values cannot be compared
+	_, leftIsStruct := leftType.(*tast.StructType)
+	_, rightIsStruct := rightType.(*tast.StructType)
+	if leftIsStruct || rightIsStruct {
+		return nil, fmt.Errorf(
+			"illegal comparison between %s and %s at %d:%d near '%s'",
+			leftExp.Type(), rightExp.Type(), line, col, text,
+		)
+	}
+
 	// Get dominant type for proper promotion
 	domType, err := dominantType(leftType, rightType)
 	if err != nil {
@@ -449,7 +597,8 @@ func (tc *TypeChecker) inferAndExp(
 	if err != nil {
 		return nil, err
 	}
-	if leftExp.Type() != tast.Bool || rightExp.Type() != tast.Bool {
+	if UnwrapTypedef(leftExp.Type()) != tast.Bool ||
+		UnwrapTypedef(rightExp.Type()) != tast.Bool {
 		return nil, fmt.Errorf(
 			"AND (&&) operation can only occur between booleans at %d:%d "+
 				"near '%s'", line, col, text,
@@ -470,7 +619,8 @@ func (tc *TypeChecker) inferOrExp(
 		return nil, err
 	}
 
-	if leftExp.Type() != tast.Bool || rightExp.Type() != tast.Bool {
+	if UnwrapTypedef(leftExp.Type()) != tast.Bool ||
+		UnwrapTypedef(rightExp.Type()) != tast.Bool {
 		return nil, fmt.Errorf(
 			"OR (||) operation can only occur between booleans at %d:%d "+
 				"near '%s'", line, col, text,
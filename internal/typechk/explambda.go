@@ -0,0 +1,85 @@
+package typechk
+
+import (
+	"fmt"
+
+	"github.com/aramyamal/javalette-to-llvm-compiler/gen/parser"
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+)
+
+// lambdaScope tracks the variables captured by a lambda that is currently
+// being type checked.
+type lambdaScope struct {
+	depth    int // number of contexts entered outside of the lambda
+	captures []*tast.IdentExp
+	captured map[string]struct{}
+}
+
+// lookupVar looks up a variable in the environment and records it as captured
+// by every lambda being checked whose body it is referenced from, but that it
+// was declared outside of.
+func (tc *TypeChecker) lookupVar(
+	varName string, line, col int,
+) (tast.Type, bool) {
+	typ, depth, ok := tc.env.LookupVarDepth(varName)
+	if !ok {
+		return nil, false
+	}
+	for _, scope := range tc.lambdas {
+		if depth >= scope.depth {
+			continue
+		}
+		if _, seen := scope.captured[varName]; seen {
+			continue
+		}
+		scope.captured[varName] = struct{}{}
+		scope.captures = append(
+			scope.captures,
+			tast.NewIdentExp(varName, typ, line, col, varName),
+		)
+	}
+	return typ, true
+}
+
+func (tc *TypeChecker) inferLambdaExp(
+	e *parser.LambdaExpContext, line, col int, text string,
+) (*tast.LambdaExp, error) {
+	typedArgs, paramNames, params, err := tc.extractArgs(e.AllArg())
+	if err != nil {
+		return nil, err
+	}
+
+	scope := &lambdaScope{
+		depth:    tc.env.Depth(),
+		captured: make(map[string]struct{}),
+	}
+	tc.lambdas = append(tc.lambdas, scope)
+	tc.env.EnterContext()
+	for _, paramName := range paramNames {
+		tc.env.ExtendVar(paramName, params[paramName])
+	}
+
+	body, err := tc.inferExp(e.Exp())
+
+	tc.env.ExitContext()
+	tc.lambdas = tc.lambdas[:len(tc.lambdas)-1]
+	if err != nil {
+		return nil, err
+	}
+
+	paramTypes := make([]tast.Type, len(paramNames))
+	for i, paramName := range paramNames {
+		paramTypes[i] = params[paramName]
+	}
+
+	id := fmt.Sprintf("lambda.%d", tc.lambdaCount)
+	tc.lambdaCount++
+	return tast.NewLambdaExp(
+		id,
+		typedArgs,
+		scope.captures,
+		body,
+		tast.Func(body.Type(), paramTypes...),
+		line, col, text,
+	), nil
+}
@@ -21,9 +21,34 @@ func (tc *TypeChecker) inferNewStructExp(
 	e *parser.NewStructExpContext, line, col int, text string,
 ) (*tast.NewStructExp, error) {
 	name := e.Ident().GetText()
-	// try typedef first
+	// instantiate generic structs with the given type arguments
+	if e.TypeArgs() != nil {
+		typeArgs, err := tc.toTastTypeArgs(e.TypeArgs())
+		if err != nil {
+			return nil, err
+		}
+		typ, err := tc.instantiateStruct(name, typeArgs, line, col)
+		if err != nil {
+			return nil, err
+		}
+		return tast.NewNewStructExp(tast.Pointer(typ), line, col, text), nil
+	}
+	// try typedef first, which must alias a pointer to a struct
 	if typ, ok := tc.env.LookupTypedef(name); ok {
-		return tast.NewNewStructExp(typ, line, col, text), nil
+		ptrType, isPtr := UnwrapTypedef(typ).(*tast.PointerType)
+		if !isPtr {
+			return nil, fmt.Errorf(
+				"cannot allocate new '%s' of non-struct-pointer type %s at "+
+					"%d:%d near %s", name, typ, line, col, text,
+			)
+		}
+		if _, isStruct := UnwrapTypedef(ptrType.Elem).(*tast.StructType); !isStruct {
+			return nil, fmt.Errorf(
+				"cannot allocate new '%s' of non-struct-pointer type %s at "+
+					"%d:%d near %s", name, typ, line, col, text,
+			)
+		}
+		return tast.NewNewStructExp(ptrType, line, col, text), nil
 	}
 	// fallback: try struct
 	if typ, ok := tc.env.LookupStruct(name); ok {
@@ -90,3 +115,58 @@ func UnwrapTypedef(t tast.Type) tast.Type {
 		}
 	}
 }
+
+func (tc *TypeChecker) inferStructLitExp(
+	e *parser.StructLitExpContext, line, col int, text string,
+) (*tast.StructLitExp, error) {
+	name := e.Ident().GetText()
+	typ, ok := tc.env.LookupTypedef(name)
+	if !ok {
+		typ, ok = tc.env.LookupStruct(name)
+	}
+	structType, isStruct := UnwrapTypedef(typ).(*tast.StructType)
+	if !ok || !isStruct {
+		return nil, fmt.Errorf(
+			"struct literal of '%s', which is not a struct, at %d:%d near '%s'",
+			name, line, col, text,
+		)
+	}
+
+	var fields []string
+	var exps []tast.Exp
+	initialized := make(map[string]struct{})
+	for _, fieldInit := range e.AllFieldInit() {
+		fieldName := fieldInit.Ident().GetText()
+		fieldInfo, ok := structType.FieldInfo(fieldName)
+		if !ok || fieldName == tast.ErrorKindField {
+			return nil, fmt.Errorf(
+				"struct '%s' does not have field %s at %d:%d near '%s'",
+				name, fieldName, line, col, text,
+			)
+		}
+		if _, exists := initialized[fieldName]; exists {
+			return nil, fmt.Errorf(
+				"field %s initialized more than once at %d:%d near '%s'",
+				fieldName, line, col, text,
+			)
+		}
+		initialized[fieldName] = struct{}{}
+
+		exp, err := tc.inferExp(fieldInit.Exp())
+		if err != nil {
+			return nil, err
+		}
+		if !isConvertible(fieldInfo.Type, exp.Type()) {
+			return nil, fmt.Errorf(
+				"cannot initialize field %s of type %s with value of type %s "+
+					"at %d:%d near '%s'",
+				fieldName, fieldInfo.Type, exp.Type(), line, col, text,
+			)
+		}
+		fields = append(fields, fieldName)
+		exps = append(exps, promoteExp(exp, fieldInfo.Type))
+	}
+	return tast.NewStructLitExp(
+		fields, exps, structType, line, col, text,
+	), nil
+}
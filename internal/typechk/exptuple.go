@@ -0,0 +1,91 @@
+package typechk
+
+import (
+	"fmt"
+
+	"github.com/aramyamal/javalette-to-llvm-compiler/gen/parser"
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+)
+
+func (tc *TypeChecker) inferTupleExp(
+	e *parser.TupleExpContext, line, col int, text string,
+) (*tast.TupleExp, error) {
+	var elems []tast.Exp
+	var elemTypes []tast.Type
+	for _, elemCtx := range e.AllExp() {
+		elem, err := tc.inferExp(elemCtx)
+		if err != nil {
+			return nil, err
+		}
+		if elem.Type() == tast.Void {
+			return nil, fmt.Errorf(
+				"tuple element of type void at %d:%d near '%s'",
+				line, col, text,
+			)
+		}
+		elems = append(elems, elem)
+		elemTypes = append(elemTypes, elem.Type())
+	}
+	return tast.NewTupleExp(
+		elems, tast.Tuple(elemTypes...), line, col, text,
+	), nil
+}
+
+func (tc *TypeChecker) checkTupleDeclStm(
+	s *parser.TupleDeclStmContext, line, col int, text string,
+) (*tast.TupleDeclStm, error) {
+	typedExp, err := tc.inferExp(s.Exp())
+	if err != nil {
+		return nil, err
+	}
+	tupleType, ok := UnwrapTypedef(typedExp.Type()).(*tast.TupleType)
+	if !ok {
+		return nil, fmt.Errorf(
+			"cannot destructure value of non-tuple type %s at %d:%d near '%s'",
+			typedExp.Type(), line, col, text,
+		)
+	}
+	args := s.AllArg()
+	if len(args) != len(tupleType.Elems) {
+		return nil, fmt.Errorf(
+			"cannot destructure tuple of %d elements into %d variables at "+
+				"%d:%d near '%s'",
+			len(tupleType.Elems), len(args), line, col, text,
+		)
+	}
+
+	currentCtx, _ := tc.env.Peek()
+	ids := make([]string, len(args))
+	types := make([]tast.Type, len(args))
+	for i, arg := range args {
+		paramArg, ok := arg.(*parser.ParamArgContext)
+		if !ok {
+			return nil, fmt.Errorf(
+				"checkTupleDeclStm: unhandled arg type %T at %d:%d near '%s'",
+				arg, line, col, text,
+			)
+		}
+		typ, err := tc.toTastType(paramArg.Type_())
+		if err != nil {
+			return nil, err
+		}
+		if !isConvertible(typ, tupleType.Elems[i]) {
+			return nil, fmt.Errorf(
+				"cannot assign tuple element of type %s to variable '%s' of "+
+					"type %s at %d:%d near '%s'",
+				tupleType.Elems[i], paramArg.Ident().GetText(), typ,
+				line, col, text,
+			)
+		}
+		ids[i] = paramArg.Ident().GetText()
+		types[i] = typ
+		if currentCtx.Has(ids[i]) {
+			return nil, fmt.Errorf(
+				"variable with name '%s' declared twice at %d:%d near '%s'",
+				ids[i], line, col, text,
+			)
+		}
+		(*currentCtx)[ids[i]] = typ
+	}
+	return tast.NewTupleDeclStm(ids, types, typedExp, line, col, text), nil
+}
@@ -0,0 +1,99 @@
+package typechk
+
+import (
+	"fmt"
+
+	"github.com/aramyamal/javalette-to-llvm-compiler/gen/parser"
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+)
+
+// isExternType reports whether values of typ can be passed to or, if isReturn
+// is set, returned from an extern function. Values are marshalled to C as
+// follows:
+//
+//   - int and enum values are passed as int32_t, and double as double.
+//   - boolean values are passed as int, where any nonzero value returned is
+//     true.
+//   - strings are passed as const char pointers to their NUL terminated
+//     contents, and returned strings must outlive the program.
+//   - arrays of int, double and string are passed as pointers to their first
+//     element, so their length must be passed separately. Arrays cannot be
+//     returned.
+//   - pointers to structs are passed as pointers to C structs with the same
+//     fields in the same order.
+//
+// Function values cannot be passed to extern functions.
+func isExternType(typ tast.Type, isReturn bool) bool {
+	switch t := UnwrapTypedef(typ).(type) {
+	case *tast.EnumType:
+		return true
+	case *tast.PointerType:
+		_, isStruct := UnwrapTypedef(t.Elem).(*tast.StructType)
+		return isStruct
+	case *tast.ArrayType:
+		if isReturn {
+			return false
+		}
+		elem := UnwrapTypedef(t.Elem)
+		return elem == tast.Int || elem == tast.Double || elem == tast.String
+	}
+	switch UnwrapTypedef(typ) {
+	case tast.Int, tast.Double, tast.Bool, tast.String:
+		return true
+	case tast.Void:
+		return isReturn
+	default:
+		return false
+	}
+}
+
+// validateExtern resolves the signature of the extern declaration d and checks
+// that it can be called from C.
+func (tc *TypeChecker) validateExtern(
+	d *parser.ExternDefContext,
+) ([]string, map[string]tast.Type, tast.Type, error) {
+	name := d.Ident().GetText()
+	line, col, text := extractPosData(d)
+
+	returnType, err := tc.toTastType(d.Type_())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !isExternType(returnType, true) {
+		return nil, nil, nil, fmt.Errorf(
+			"extern function '%s' cannot return type %s at %d:%d near '%s'",
+			name, returnType, line, col, text,
+		)
+	}
+
+	paramNames, params, err := tc.extractParams(d.AllArg())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, paramName := range paramNames {
+		if !isExternType(params[paramName], false) {
+			return nil, nil, nil, fmt.Errorf(
+				"extern function '%s' cannot take parameter '%s' of type %s "+
+					"at %d:%d near '%s'",
+				name, paramName, params[paramName], line, col, text,
+			)
+		}
+	}
+	return paramNames, params, returnType, nil
+}
+
+func (tc *TypeChecker) checkExternDef(
+	d *parser.ExternDefContext, line, col int, text string,
+) (*tast.ExternDef, error) {
+	returnType, err := tc.toTastType(d.Type_())
+	if err != nil {
+		return nil, err
+	}
+	typedArgs, err := tc.toTastArgs(d.AllArg())
+	if err != nil {
+		return nil, err
+	}
+	return tast.NewExternDef(
+		d.Ident().GetText(), typedArgs, returnType, line, col, text,
+	), nil
+}
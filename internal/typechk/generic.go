@@ -0,0 +1,418 @@
+package typechk
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aramyamal/javalette-to-llvm-compiler/gen/parser"
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+	"github.com/aramyamal/javalette-to-llvm-compiler/pkg/env"
+)
+
+// funcInstance is an instantiation of a generic function whose body is yet to
+// be type checked.
+type funcInstance struct {
+	def      *parser.FuncDefContext
+	name     string
+	typeArgs map[string]tast.Type
+	scope    *moduleScope // scope of the module defining the function
+}
+
+// typeParamNames returns the names of the type parameters in params, and
+// reports duplicates.
+func typeParamNames(params parser.ITypeParamsContext) ([]string, error) {
+	var names []string
+	seen := make(map[string]struct{})
+	for _, ident := range params.AllIdent() {
+		name := ident.GetText()
+		if _, exists := seen[name]; exists {
+			return nil, fmt.Errorf(
+				"duplicate type parameter '%s' at %d:%d",
+				name, ident.GetSymbol().GetLine(), ident.GetSymbol().GetColumn(),
+			)
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (tc *TypeChecker) registerStructTemplate(d *parser.StructDefContext) error {
+	name := d.Ident().GetText()
+	if _, exists := tc.env.LookupStruct(name); exists {
+		return fmt.Errorf(
+			"redefinition of struct '%s' at %d:%d",
+			name, d.GetStart().GetLine(), d.GetStart().GetColumn(),
+		)
+	}
+	if _, err := typeParamNames(d.TypeParams()); err != nil {
+		return err
+	}
+	tc.structTemplates[name] = d
+	return nil
+}
+
+func (tc *TypeChecker) registerFuncTemplate(d *parser.FuncDefContext) error {
+	name := d.Ident().GetText()
+	if _, exists := tc.env.LookupFunc(name); exists {
+		return fmt.Errorf(
+			"redefinition of function '%s' at %d:%d",
+			name, d.GetStart().GetLine(), d.GetStart().GetColumn(),
+		)
+	}
+	if _, err := typeParamNames(d.TypeParams()); err != nil {
+		return err
+	}
+	tc.funcTemplates[name] = d
+	return nil
+}
+
+// mangleName returns the unique name of the instantiation of the generic
+// struct or function name with typeArgs.
+func mangleName(name string, typeArgs []tast.Type) string {
+	var sb strings.Builder
+	sb.WriteString(name)
+	sb.WriteString("$")
+	for _, typeArg := range typeArgs {
+		sb.WriteString(tast.Mangle(typeArg))
+		sb.WriteString("$")
+	}
+	return sb.String()
+}
+
+// withTypeArgs runs f with the type parameters of a generic definition bound
+// to the given type arguments.
+func (tc *TypeChecker) withTypeArgs(
+	typeArgs map[string]tast.Type, f func() error,
+) error {
+	outer := tc.typeArgs
+	tc.typeArgs = typeArgs
+	defer func() { tc.typeArgs = outer }()
+	return f()
+}
+
+// instantiateStruct returns the instance of the generic struct name for the
+// given type arguments, resolving its fields the first time it is used.
+func (tc *TypeChecker) instantiateStruct(
+	name string, typeArgs []tast.Type, line, col int,
+) (*tast.StructType, error) {
+	tmpl, ok := tc.structTemplates[name]
+	if !ok {
+		return nil, fmt.Errorf(
+			"type arguments given to non-generic type '%s' at %d:%d",
+			name, line, col,
+		)
+	}
+	paramNames, err := typeParamNames(tmpl.TypeParams())
+	if err != nil {
+		return nil, err
+	}
+	if len(paramNames) != len(typeArgs) {
+		return nil, fmt.Errorf(
+			"generic struct '%s' expects %d type arguments but got %d at %d:%d",
+			name, len(paramNames), len(typeArgs), line, col,
+		)
+	}
+
+	mangled := mangleName(name, typeArgs)
+	if typ, exists := tc.env.LookupStruct(mangled); exists {
+		return typ.(*tast.StructType), nil
+	}
+
+	// register the instance before resolving its fields so that it may refer
+	// to itself, also in the module defining the template
+	structType := tast.RegisterInstance(mangled, name, typeArgs)
+	tc.env.ExtendStruct(mangled, structType)
+
+	bindings := make(map[string]tast.Type)
+	for i, paramName := range paramNames {
+		bindings[paramName] = typeArgs[i]
+	}
+	var fields []*tast.FieldCreator
+	if err := tc.inScope(tc.templateScope(tmpl), func() error {
+		tc.env.ExtendStruct(mangled, structType)
+		return tc.withTypeArgs(bindings, func() error {
+			fields, err = tc.resolveStructFields(tmpl, name)
+			return err
+		})
+	}); err != nil {
+		return nil, err
+	}
+	tast.RegisterInstance(mangled, name, typeArgs, fields...)
+	if err := validateStructSize(structType, tmpl); err != nil {
+		return nil, err
+	}
+
+	tmplLine, tmplCol, tmplText := extractPosData(tmpl)
+	tc.instances = append(tc.instances, tast.NewStructDef(
+		structType, tmplLine, tmplCol, tmplText,
+	))
+	return structType, nil
+}
+
+func (tc *TypeChecker) toTastTypeArgs(
+	args parser.ITypeArgsContext,
+) ([]tast.Type, error) {
+	var typeArgs []tast.Type
+	for _, argCtx := range args.AllType_() {
+		typeArg, err := tc.toTastType(argCtx)
+		if err != nil {
+			return nil, err
+		}
+		if typeArg == tast.Void {
+			return nil, fmt.Errorf(
+				"type argument of type void at %d:%d near '%s'",
+				argCtx.GetStart().GetLine(), argCtx.GetStart().GetColumn(),
+				argCtx.GetText(),
+			)
+		}
+		typeArgs = append(typeArgs, typeArg)
+	}
+	return typeArgs, nil
+}
+
+// instantiateFunc returns the name and signature of the instance of the
+// generic function tmpl for the given type arguments. The body of a new
+// instance is type checked after all other definitions.
+func (tc *TypeChecker) instantiateFunc(
+	tmpl *parser.FuncDefContext, typeArgs []tast.Type,
+) (string, env.Signature[tast.Type], error) {
+	name := tmpl.Ident().GetText()
+	mangled := mangleName(tc.funcLink(name), typeArgs)
+	if sign, exists := tc.env.LookupFunc(mangled); exists {
+		return mangled, sign, nil
+	}
+
+	paramNames, err := typeParamNames(tmpl.TypeParams())
+	if err != nil {
+		return "", env.Signature[tast.Type]{}, err
+	}
+	bindings := make(map[string]tast.Type)
+	for i, paramName := range paramNames {
+		bindings[paramName] = typeArgs[i]
+	}
+
+	// the signature is resolved in the module defining the template, where
+	// the instance may already exist
+	scope := tc.templateScope(tmpl)
+	if err := tc.inScope(scope, func() error {
+		if _, exists := tc.env.LookupFunc(mangled); exists {
+			return nil
+		}
+		return tc.withTypeArgs(bindings, func() error {
+			returnType, err := tc.toTastType(tmpl.Type_())
+			if err != nil {
+				return err
+			}
+			argNames, params, err := tc.extractParams(tmpl.AllArg())
+			if err != nil {
+				return err
+			}
+			tc.env.ExtendFunc(mangled, argNames, params, returnType)
+			tc.pendingFuncs = append(tc.pendingFuncs, funcInstance{
+				def: tmpl, name: mangled, typeArgs: bindings, scope: scope,
+			})
+			return nil
+		})
+	}); err != nil {
+		return "", env.Signature[tast.Type]{}, err
+	}
+
+	if scope.env != tc.env {
+		tc.env.ImportFunc(mangled, scope.env.LookupFuncs(mangled))
+	}
+	sign, _ := tc.env.LookupFunc(mangled)
+	return mangled, sign, nil
+}
+
+// checkFuncInstances type checks the bodies of the instantiated generic
+// functions, including those instantiated while doing so.
+func (tc *TypeChecker) checkFuncInstances() ([]tast.Def, error) {
+	var typedDefs []tast.Def
+	for len(tc.pendingFuncs) > 0 {
+		inst := tc.pendingFuncs[0]
+		tc.pendingFuncs = tc.pendingFuncs[1:]
+
+		line, col, text := extractPosData(inst.def)
+		if err := tc.inScope(inst.scope, func() error {
+			return tc.withTypeArgs(inst.typeArgs, func() error {
+				tc.env.EnterContext()
+				typedDef, err := tc.checkFuncDef(
+					inst.def, inst.name, line, col, text,
+				)
+				if err != nil {
+					return fmt.Errorf(
+						"in instantiation %s of generic function '%s': %w",
+						inst.name, inst.def.Ident().GetText(), err,
+					)
+				}
+				typedDefs = append(typedDefs, typedDef)
+				tc.env.SetReturnType(tast.Unknown)
+				return nil
+			})
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return typedDefs, nil
+}
+
+// unify matches the parameter type pattern of a generic function against the
+// type of an argument, binding the type parameters in typeParams found in the
+// pattern. Mismatches in structure are left for the argument check to report.
+func unify(
+	pattern parser.ITypeContext,
+	actual tast.Type,
+	typeParams map[string]struct{},
+	bindings map[string]tast.Type,
+) error {
+	actual = UnwrapTypedef(actual)
+	switch t := pattern.(type) {
+	case *parser.PrimitiveTypeContext:
+		for range t.AllArraySuffix() {
+			arr, ok := actual.(*tast.ArrayType)
+			if !ok {
+				return nil
+			}
+			actual = UnwrapTypedef(arr.Elem)
+		}
+		if t.PtrSuffix() != nil {
+			ptr, ok := actual.(*tast.PointerType)
+			if !ok {
+				return nil
+			}
+			actual = UnwrapTypedef(ptr.Elem)
+		}
+		custom, ok := t.BaseType().GetChild(0).(*parser.CustomTypeContext)
+		if !ok {
+			return nil
+		}
+		name := custom.Ident().GetText()
+
+		if custom.TypeArgs() == nil {
+			if _, isParam := typeParams[name]; !isParam {
+				return nil
+			}
+			if bound, exists := bindings[name]; exists &&
+				tast.Mangle(bound) != tast.Mangle(actual) {
+				return fmt.Errorf(
+					"conflicting types %s and %s inferred for type "+
+						"parameter '%s'",
+					bound, actual, name,
+				)
+			}
+			bindings[name] = actual
+			return nil
+		}
+
+		structType, ok := actual.(*tast.StructType)
+		argCtxs := custom.TypeArgs().AllType_()
+		if !ok || structType.Generic != name ||
+			len(structType.TypeArgs) != len(argCtxs) {
+			return nil
+		}
+		for i, argCtx := range argCtxs {
+			if err := unify(
+				argCtx, structType.TypeArgs[i], typeParams, bindings,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case *parser.FuncTypeContext:
+		funcType, ok := actual.(*tast.FuncType)
+		types := t.AllType_()
+		if !ok || len(types)-1 != len(funcType.Params) {
+			return nil
+		}
+		for i, param := range funcType.Params {
+			if err := unify(types[i], param, typeParams, bindings); err != nil {
+				return err
+			}
+		}
+		return unify(types[len(types)-1], funcType.Returns, typeParams, bindings)
+
+	default:
+		return nil
+	}
+}
+
+func (tc *TypeChecker) inferGenericFuncExp(
+	tmpl *parser.FuncDefContext,
+	e *parser.FuncExpContext,
+	line, col int,
+	text string,
+) (*tast.FuncExp, error) {
+	funcName := e.Ident().GetText()
+	typedExps, err := tc.inferCallArgs(e.AllExp())
+	if err != nil {
+		return nil, err
+	}
+
+	args := tmpl.AllArg()
+	if len(args) != len(typedExps) {
+		return nil, fmt.Errorf(
+			"function '%s' called with wrong number of arguments at %d:%d",
+			funcName, line, col,
+		)
+	}
+
+	// infer the type arguments from the types of the arguments
+	paramNames, err := typeParamNames(tmpl.TypeParams())
+	if err != nil {
+		return nil, err
+	}
+	typeParams := make(map[string]struct{})
+	for _, paramName := range paramNames {
+		typeParams[paramName] = struct{}{}
+	}
+	bindings := make(map[string]tast.Type)
+	for i, arg := range args {
+		paramArg, ok := arg.(*parser.ParamArgContext)
+		if !ok {
+			continue
+		}
+		if err := unify(
+			paramArg.Type_(), typedExps[i].Type(), typeParams, bindings,
+		); err != nil {
+			return nil, fmt.Errorf(
+				"%v in call to generic function '%s' at %d:%d near '%s'",
+				err, funcName, line, col, text,
+			)
+		}
+	}
+
+	typeArgs := make([]tast.Type, len(paramNames))
+	for i, paramName := range paramNames {
+		typeArg, ok := bindings[paramName]
+		if !ok {
+			return nil, fmt.Errorf(
+				"cannot infer type parameter '%s' of generic function '%s' "+
+					"at %d:%d near '%s'",
+				paramName, funcName, line, col, text,
+			)
+		}
+		typeArgs[i] = typeArg
+	}
+
+	mangled, sign, err := tc.instantiateFunc(tmpl, typeArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	funcType := signatureType(sign)
+	typedExps, err = convertCallArgs(
+		funcName, funcType.Params, typedExps, line, col,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return tast.NewFuncExp(
+		mangled,
+		typedExps,
+		funcType.Params,
+		sign.Returns,
+		line, col, text,
+	), nil
+}
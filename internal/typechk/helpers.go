@@ -6,6 +6,7 @@ import (
 	"github.com/antlr4-go/antlr/v4"
 	"github.com/aramyamal/javalette-to-llvm-compiler/gen/parser"
 	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+	"github.com/aramyamal/javalette-to-llvm-compiler/pkg/env"
 )
 
 func extractPosData(pr antlr.ParserRuleContext) (int, int, string) {
@@ -26,10 +27,35 @@ func (tc *TypeChecker) toTastBaseType(fromType parser.IBaseTypeContext) (tast.Ty
 		return tast.Void, nil
 	case *parser.CustomTypeContext:
 		name := t.Ident().GetText()
+		if t.TypeArgs() != nil {
+			typeArgs, err := tc.toTastTypeArgs(t.TypeArgs())
+			if err != nil {
+				return nil, err
+			}
+			return tc.instantiateStruct(
+				name, typeArgs,
+				t.GetStart().GetLine(), t.GetStart().GetColumn(),
+			)
+		}
+		if typeArg, ok := tc.typeArgs[name]; ok {
+			return typeArg, nil
+		}
+		if _, ok := tc.structTemplates[name]; ok {
+			return nil, fmt.Errorf(
+				"generic type '%s' used without type arguments at %d:%d",
+				name, t.GetStart().GetLine(), t.GetStart().GetColumn(),
+			)
+		}
+		if err := tc.resolveTypedef(name); err != nil {
+			return nil, err
+		}
 		var baseType tast.Type
 		var found bool
 		if baseType, found = tc.env.LookupTypedef(name); !found {
 			if baseType, found = tc.env.LookupStruct(name); !found {
+				if enumType, isEnum := tc.env.LookupEnum(name); isEnum {
+					return enumType, nil
+				}
 				return nil, fmt.Errorf(
 					"type '%s' not defined at %d:%d", name,
 					t.GetStart().GetLine(), t.GetStart().GetColumn(),
@@ -38,6 +64,30 @@ func (tc *TypeChecker) toTastBaseType(fromType parser.IBaseTypeContext) (tast.Ty
 		}
 		return baseType, nil
 
+	case *parser.StructTypeContext:
+		name := t.Ident().GetText()
+		line, col := t.GetStart().GetLine(), t.GetStart().GetColumn()
+		if t.TypeArgs() != nil {
+			typeArgs, err := tc.toTastTypeArgs(t.TypeArgs())
+			if err != nil {
+				return nil, err
+			}
+			return tc.instantiateStruct(name, typeArgs, line, col)
+		}
+		if _, ok := tc.structTemplates[name]; ok {
+			return nil, fmt.Errorf(
+				"generic type '%s' used without type arguments at %d:%d",
+				name, line, col,
+			)
+		}
+		structType, ok := tc.env.LookupStruct(name)
+		if !ok {
+			return nil, fmt.Errorf(
+				"struct '%s' not defined at %d:%d", name, line, col,
+			)
+		}
+		return structType, nil
+
 	default:
 		return tast.Unknown, fmt.Errorf(
 			"type '%T' not yet implemented at %d:%d near '%s'",
@@ -57,11 +107,51 @@ func (tc *TypeChecker) toTastType(fromType parser.ITypeContext) (tast.Type, erro
 		if err != nil {
 			return nil, err
 		}
+		if t.PtrSuffix() != nil {
+			typ = tast.Pointer(typ)
+		}
 		arraySuffixes := t.AllArraySuffix()
 		for range arraySuffixes {
 			typ = tast.Array(typ)
 		}
 		return typ, nil
+	case *parser.FuncTypeContext:
+		types := t.AllType_()
+		returns, err := tc.toTastType(types[len(types)-1])
+		if err != nil {
+			return nil, err
+		}
+		var params []tast.Type
+		for _, paramCtx := range types[:len(types)-1] {
+			param, err := tc.toTastType(paramCtx)
+			if err != nil {
+				return nil, err
+			}
+			if param == tast.Void {
+				return nil, fmt.Errorf(
+					"function type parameter of type void at %d:%d near '%s'",
+					t.GetStart().GetLine(), t.GetStart().GetColumn(), t.GetText(),
+				)
+			}
+			params = append(params, param)
+		}
+		return tast.Func(returns, params...), nil
+	case *parser.TupleTypeContext:
+		var elems []tast.Type
+		for _, elemCtx := range t.AllType_() {
+			elem, err := tc.toTastType(elemCtx)
+			if err != nil {
+				return nil, err
+			}
+			if elem == tast.Void {
+				return nil, fmt.Errorf(
+					"tuple type element of type void at %d:%d near '%s'",
+					t.GetStart().GetLine(), t.GetStart().GetColumn(), t.GetText(),
+				)
+			}
+			elems = append(elems, elem)
+		}
+		return tast.Tuple(elems...), nil
 	default:
 		return nil, fmt.Errorf("unhandled type '%T'", fromType)
 	}
@@ -71,6 +161,16 @@ func (tc *TypeChecker) toTastType(fromType parser.ITypeContext) (tast.Type, erro
 // conversion is valid.
 func isConvertible(expected, actual tast.Type) bool {
 
+	// handle typedefs by the types they alias
+	expectedTypedef, expectedIsTypedef := expected.(*tast.TypedefType)
+	actualTypedef, actualIsTypedef := actual.(*tast.TypedefType)
+	if expectedIsTypedef {
+		return isConvertible(expectedTypedef.Aliased, actual)
+	}
+	if actualIsTypedef {
+		return isConvertible(expected, actualTypedef.Aliased)
+	}
+
 	// handle array type recursively
 	expectedArr, expectedIsArr := expected.(*tast.ArrayType)
 	actualArr, actualIsArr := actual.(*tast.ArrayType)
@@ -91,14 +191,32 @@ func isConvertible(expected, actual tast.Type) bool {
 		return false
 	}
 
-	// handle typedefs, allow if both are typedefs with the same name
-	expectedTypedef, expectedIsTypedef := expected.(*tast.TypedefType)
-	actualTypedef, actualIsTypedef := actual.(*tast.TypedefType)
-	if expectedIsTypedef {
-		return isConvertible(expectedTypedef.Aliased, actual)
+	// handle function types structurally
+	expectedFunc, expectedIsFunc := expected.(*tast.FuncType)
+	actualFunc, actualIsFunc := actual.(*tast.FuncType)
+	if expectedIsFunc && actualIsFunc {
+		return sameFuncType(expectedFunc, actualFunc)
 	}
-	if actualIsTypedef {
-		return isConvertible(expected, actualTypedef.Aliased)
+	if expectedIsFunc || actualIsFunc {
+		return false
+	}
+
+	// handle tuple types element by element
+	expectedTuple, expectedIsTuple := expected.(*tast.TupleType)
+	actualTuple, actualIsTuple := actual.(*tast.TupleType)
+	if expectedIsTuple && actualIsTuple {
+		if len(expectedTuple.Elems) != len(actualTuple.Elems) {
+			return false
+		}
+		for i := range expectedTuple.Elems {
+			if !isConvertible(expectedTuple.Elems[i], actualTuple.Elems[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if expectedIsTuple || actualIsTuple {
+		return false
 	}
 
 	// handle struct types only by name
@@ -111,6 +229,13 @@ func isConvertible(expected, actual tast.Type) bool {
 		return false
 	}
 
+	// enums are only convertible to themselves
+	expectedEnum, expectedIsEnum := expected.(*tast.EnumType)
+	actualEnum, actualIsEnum := actual.(*tast.EnumType)
+	if expectedIsEnum || actualIsEnum {
+		return expectedEnum == actualEnum
+	}
+
 	// then handle base types
 	switch expected {
 	case tast.Double:
@@ -129,6 +254,32 @@ func isConvertible(expected, actual tast.Type) bool {
 	}
 }
 
+// isOverloadConvertible reports whether an argument of type actual can be
+// passed to a parameter of type expected of an overloaded function. Unlike
+// other conversions, an int is promoted where a double is expected, so that
+// overloads are picked by the best match rather than only the exact one.
+func isOverloadConvertible(expected, actual tast.Type) bool {
+	if UnwrapTypedef(expected) == tast.Double && UnwrapTypedef(actual) == tast.Int {
+		return true
+	}
+	return isConvertible(expected, actual)
+}
+
+// Checks if two function types have identical parameter and return types.
+func sameFuncType(f1, f2 *tast.FuncType) bool {
+	if len(f1.Params) != len(f2.Params) {
+		return false
+	}
+	for i := range f1.Params {
+		if !isConvertible(f1.Params[i], f2.Params[i]) ||
+			!isConvertible(f2.Params[i], f1.Params[i]) {
+			return false
+		}
+	}
+	return isConvertible(f1.Returns, f2.Returns) &&
+		isConvertible(f2.Returns, f1.Returns)
+}
+
 // Determines the dominant type between two tast.for operations. For example,
 // int + double = double
 func dominantType(type1, type2 tast.Type) (tast.Type, error) {
@@ -199,8 +350,18 @@ func extractIncDecOp(opCtx parser.IIncDecOpContext) (tast.Op, error) {
 	}
 }
 
+// signatureType converts a function signature from the environment into the
+// corresponding function type.
+func signatureType(sign env.Signature[tast.Type]) *tast.FuncType {
+	params := make([]tast.Type, 0, len(sign.ParamNames))
+	for _, paramName := range sign.ParamNames {
+		params = append(params, sign.Params[paramName])
+	}
+	return tast.Func(sign.Returns, params...)
+}
+
 func promoteExp(exp tast.Exp, typ tast.Type) tast.Exp {
-	if exp.Type() == tast.Int && typ == tast.Double {
+	if UnwrapTypedef(exp.Type()) == tast.Int && UnwrapTypedef(typ) == tast.Double {
 		return tast.NewIntToDoubleExp(exp)
 	}
 	return exp
@@ -46,6 +46,14 @@ func (tc *TypeChecker) checkNoInitItem(
 		)
 	}
 
+	if typ == nil {
+		return nil, fmt.Errorf(
+			"cannot infer the type of variable '%s' declared with var "+
+				"without an initializer at %d:%d near '%s'",
+			varName, line, col, text,
+		)
+	}
+
 	(*currentCtx)[varName] = typ
 	return tast.NewNoInitItem(varName, typ, line, col, text), nil
 }
@@ -71,6 +79,25 @@ func (tc *TypeChecker) checkInitItem(
 		)
 	}
 
+	// variables declared with var take the type of their initializer, in
+	// which they are not yet in scope
+	if typ == nil {
+		typedExp, err := tc.inferExp(i.Exp())
+		if err != nil {
+			return nil, err
+		}
+		if typedExp.Type() == tast.Void {
+			return nil, fmt.Errorf(
+				"cannot infer the type of variable '%s' declared with var "+
+					"from an initializer of type void at %d:%d near '%s'",
+				varName, line, col, text,
+			)
+		}
+		typ = typedExp.Type()
+		(*currentCtx)[varName] = typ
+		return tast.NewInitItem(varName, typedExp, typ, line, col, text), nil
+	}
+
 	(*currentCtx)[varName] = typ
 
 	typedExp, err := tc.inferExp(i.Exp())
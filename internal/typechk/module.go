@@ -0,0 +1,356 @@
+package typechk
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aramyamal/javalette-to-llvm-compiler/gen/parser"
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/loader"
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+	"github.com/aramyamal/javalette-to-llvm-compiler/pkg/env"
+)
+
+// moduleScope holds the symbol tables of a module, which are swapped in when
+// instantiating the generic definitions of the module from another module.
+type moduleScope struct {
+	env             *env.Environment[tast.Type]
+	structTemplates map[string]*parser.StructDefContext
+	funcTemplates   map[string]*parser.FuncDefContext
+	funcLinks       map[string]string
+	file            string
+}
+
+func (tc *TypeChecker) currentScope() *moduleScope {
+	return &moduleScope{
+		env:             tc.env,
+		structTemplates: tc.structTemplates,
+		funcTemplates:   tc.funcTemplates,
+		funcLinks:       tc.funcLinks,
+		file:            tc.file,
+	}
+}
+
+// templateScope returns the scope of the module defining the generic
+// definition tmpl.
+func (tc *TypeChecker) templateScope(tmpl parser.IDefContext) *moduleScope {
+	if scope, ok := tc.templateScopes[tmpl]; ok {
+		return scope
+	}
+	return tc.currentScope()
+}
+
+// inScope runs f with the symbol tables of scope.
+func (tc *TypeChecker) inScope(scope *moduleScope, f func() error) error {
+	outer := tc.currentScope()
+	tc.env = scope.env
+	tc.structTemplates = scope.structTemplates
+	tc.funcTemplates = scope.funcTemplates
+	tc.funcLinks = scope.funcLinks
+	tc.file = scope.file
+	defer func() {
+		tc.env = outer.env
+		tc.structTemplates = outer.structTemplates
+		tc.funcTemplates = outer.funcTemplates
+		tc.funcLinks = outer.funcLinks
+		tc.file = outer.file
+	}()
+	return f()
+}
+
+// newModuleEnv returns an environment holding only the standard functions and
+// the built-in Error struct.
+func newModuleEnv() *env.Environment[tast.Type] {
+	moduleEnv := env.NewEnvironment[tast.Type]()
+	moduleEnv.ExtendStruct(tast.ErrorName, tast.RegisterError())
+	moduleEnv.AddStdFunc("printInt", tast.Void, tast.Int)
+	moduleEnv.AddStdFunc("printDouble", tast.Void, tast.Double)
+	moduleEnv.AddStdFunc("printString", tast.Void, tast.String)
+	moduleEnv.AddStdFuncNoParam("readInt", tast.Int)
+	moduleEnv.AddStdFuncNoParam("readDouble", tast.Double)
+	return moduleEnv
+}
+
+// checkModule type checks the definitions of module in a fresh scope holding
+// the definitions of the modules it imports. The functions of the module named
+// in links are given the link names they map to in the linked program.
+func (tc *TypeChecker) checkModule(
+	module *loader.Module,
+	links map[string]string,
+	scopes map[*loader.Module]*moduleScope,
+) ([]tast.Def, error) {
+	tc.env = newModuleEnv()
+	tc.structTemplates = make(map[string]*parser.StructDefContext)
+	tc.funcTemplates = make(map[string]*parser.FuncDefContext)
+	tc.funcLinks = links
+	tc.instances = nil
+	tc.file = moduleName(module)
+
+	seen := make(map[*loader.Module]struct{})
+	for i, imported := range module.Imports {
+		if _, exists := seen[imported]; exists {
+			continue
+		}
+		seen[imported] = struct{}{}
+		if err := tc.importModule(imported, scopes[imported]); err != nil {
+			decl := module.Tree.ImportDecl(i)
+			return nil, fmt.Errorf(
+				"%v at %d:%d near '%s'", err,
+				decl.GetStart().GetLine(), decl.GetStart().GetColumn(),
+				decl.GetText(),
+			)
+		}
+	}
+
+	tc.env.EnterContext()
+	defs := module.Tree.AllDef()
+	if err := tc.validateDefs(defs); err != nil {
+		return nil, err
+	}
+	typedDefs, err := tc.checkDefs(defs)
+	if err != nil {
+		return nil, err
+	}
+	tc.env.ExitContext()
+	return typedDefs, nil
+}
+
+// importModule makes the definitions of imported visible in the current
+// scope. Definitions imported by imported are not visible.
+func (tc *TypeChecker) importModule(
+	imported *loader.Module, scope *moduleScope,
+) error {
+	importedFuncs := make(map[string]struct{})
+	for _, def := range imported.Tree.AllDef() {
+		switch d := def.(type) {
+		case *parser.FuncDefContext:
+			name := d.Ident().GetText()
+			if _, exists := tc.funcTemplates[name]; exists {
+				return fmt.Errorf("imported function '%s' already defined", name)
+			}
+			if link, ok := scope.funcLinks[name]; ok {
+				tc.funcLinks[name] = link
+			}
+			if d.TypeParams() != nil {
+				if _, exists := tc.env.LookupFunc(name); exists {
+					return fmt.Errorf(
+						"imported function '%s' already defined", name,
+					)
+				}
+				tc.funcTemplates[name] = d
+				tc.templateScopes[d] = scope
+				continue
+			}
+			// overloads are imported together with the first definition
+			if _, exists := importedFuncs[name]; exists {
+				continue
+			}
+			importedFuncs[name] = struct{}{}
+			if ok := tc.env.ImportFunc(
+				name, scope.env.LookupFuncs(name),
+			); !ok {
+				return fmt.Errorf("imported function '%s' already defined", name)
+			}
+		case *parser.ExternDefContext:
+			name := d.Ident().GetText()
+			if ok := tc.env.ImportFunc(
+				name, scope.env.LookupFuncs(name),
+			); !ok && !sameExtern(tc.env, scope.env, name) {
+				return fmt.Errorf("imported function '%s' already defined", name)
+			}
+			tc.externs[name] = struct{}{}
+		case *parser.StructDefContext:
+			name := d.Ident().GetText()
+			if err := tc.checkImportedType(name); err != nil {
+				return err
+			}
+			if d.TypeParams() != nil {
+				tc.structTemplates[name] = d
+				tc.templateScopes[d] = scope
+				continue
+			}
+			structType, _ := scope.env.LookupStruct(name)
+			tc.env.ExtendStruct(name, structType)
+		case *parser.TypedefDefContext:
+			alias := d.Ident().GetText()
+			aliasType, _ := scope.env.LookupTypedef(alias)
+			if ok := tc.env.ExtendTypedef(alias, aliasType); !ok {
+				return fmt.Errorf("imported typedef '%s' already defined", alias)
+			}
+		case *parser.EnumDefContext:
+			name := d.Ident(0).GetText()
+			if err := tc.checkImportedType(name); err != nil {
+				return err
+			}
+			enumType, _ := scope.env.LookupEnum(name)
+			tc.env.ExtendEnum(name, enumType)
+		}
+	}
+	return nil
+}
+
+func (tc *TypeChecker) checkImportedType(name string) error {
+	_, isStruct := tc.env.LookupStruct(name)
+	_, isTemplate := tc.structTemplates[name]
+	_, isEnum := tc.env.LookupEnum(name)
+	if isStruct || isTemplate || isEnum {
+		return fmt.Errorf("imported type '%s' already defined", name)
+	}
+	return nil
+}
+
+// sameExtern reports whether funcName is an extern function declared with the
+// same signature in both environments, as when several modules declare the
+// same C function.
+func sameExtern(a, b *env.Environment[tast.Type], funcName string) bool {
+	aSign, aExists := a.LookupFunc(funcName)
+	bSign, bExists := b.LookupFunc(funcName)
+	return aExists && bExists &&
+		sameFuncType(signatureType(aSign), signatureType(bSign))
+}
+
+// funcLinks returns the link names of the functions defined by each module
+// whose names are also used by another module. Each module only sees its own
+// functions and those of the modules it imports, so modules that do not see
+// each other may reuse a name. Such functions are given a name unique to the
+// module, as all modules are linked into one program. Extern functions and
+// the entrypoint of the root module keep their names.
+func funcLinks(modules []*loader.Module) []map[string]string {
+	usedBy := make(map[string]int)
+	for _, module := range modules {
+		used := make(map[string]struct{})
+		for _, def := range module.Tree.AllDef() {
+			switch d := def.(type) {
+			case *parser.FuncDefContext:
+				used[d.Ident().GetText()] = struct{}{}
+			case *parser.ExternDefContext:
+				used[d.Ident().GetText()] = struct{}{}
+			}
+		}
+		for name := range used {
+			usedBy[name]++
+		}
+	}
+
+	links := make([]map[string]string, len(modules))
+	for i, module := range modules {
+		links[i] = make(map[string]string)
+		root := i == len(modules)-1
+		for _, def := range module.Tree.AllDef() {
+			d, ok := def.(*parser.FuncDefContext)
+			if !ok {
+				continue
+			}
+			name := d.Ident().GetText()
+			if usedBy[name] > 1 && !(root && name == "main") {
+				links[i][name] = fmt.Sprintf("%s$%d", name, i)
+			}
+		}
+	}
+	return links
+}
+
+// funcLink returns the name in the linked program of the function funcName
+// visible in the current module.
+func (tc *TypeChecker) funcLink(funcName string) string {
+	if link, ok := tc.funcLinks[funcName]; ok {
+		return link
+	}
+	return funcName
+}
+
+// validateSharedDefs checks the definitions that name one entity in the whole
+// linked program, and so cannot be told apart by the symbol tables of the
+// modules. The fields of structs and the constant names of enums are
+// registered by type name.
+func validateSharedDefs(modules []*loader.Module) error {
+	definedIn := make(map[string]*loader.Module)
+	for _, module := range modules {
+		for _, def := range module.Tree.AllDef() {
+			var kind, name string
+			switch d := def.(type) {
+			case *parser.StructDefContext:
+				kind, name = "struct", d.Ident().GetText()
+			case *parser.EnumDefContext:
+				kind, name = "enum", d.Ident(0).GetText()
+			default:
+				continue
+			}
+			key := "type " + name
+			if other, exists := definedIn[key]; exists && other != module {
+				return fmt.Errorf(
+					"%s '%s' is defined in both %s and %s",
+					kind, name, moduleName(other), moduleName(module),
+				)
+			}
+			definedIn[key] = module
+		}
+	}
+	return nil
+}
+
+// validateExterns checks that every module declaring an extern function, which
+// names a single C symbol in the linked program, declares it with the same
+// signature. The signatures are compared once the modules are checked, so that
+// parameter names and typedefs of the same type do not matter.
+func validateExterns(
+	modules []*loader.Module, scopes map[*loader.Module]*moduleScope,
+) error {
+	declaredIn := make(map[string]*loader.Module)
+	for _, module := range modules {
+		for _, def := range module.Tree.AllDef() {
+			d, ok := def.(*parser.ExternDefContext)
+			if !ok {
+				continue
+			}
+			name := d.Ident().GetText()
+			other, exists := declaredIn[name]
+			if !exists {
+				declaredIn[name] = module
+				continue
+			}
+			if !sameExtern(scopes[other].env, scopes[module].env, name) {
+				return fmt.Errorf(
+					"extern function '%s' is declared differently in %s and %s",
+					name, moduleName(other), moduleName(module),
+				)
+			}
+		}
+	}
+	return nil
+}
+
+func moduleName(module *loader.Module) string {
+	if module.Path == "" {
+		return "<stdin>"
+	}
+	return module.Path
+}
+
+// linkDefs removes the duplicate instances of generic definitions that were
+// instantiated in more than one module.
+func linkDefs(defs []tast.Def) []tast.Def {
+	var linked []tast.Def
+	instances := make(map[string]struct{})
+	for _, def := range defs {
+		var instance string
+		switch d := def.(type) {
+		case *tast.FuncDef:
+			if strings.Contains(d.Id, "$") {
+				instance = "function " + d.Id
+			}
+		case *tast.StructDef:
+			if structType, ok := d.Type().(*tast.StructType); ok &&
+				structType.Generic != "" {
+				instance = "struct " + structType.Name
+			}
+		}
+		if instance != "" {
+			if _, exists := instances[instance]; exists {
+				continue
+			}
+			instances[instance] = struct{}{}
+		}
+		linked = append(linked, def)
+	}
+	return linked
+}
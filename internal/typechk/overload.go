@@ -0,0 +1,118 @@
+package typechk
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aramyamal/javalette-to-llvm-compiler/gen/parser"
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+	"github.com/aramyamal/javalette-to-llvm-compiler/pkg/env"
+)
+
+// validateOverload checks that the function definition d of the already
+// defined function name can be told apart from its other overloads.
+func (tc *TypeChecker) validateOverload(
+	d *parser.FuncDefContext,
+	name string,
+	paramNames []string,
+	params map[string]tast.Type,
+) error {
+	line, col := d.GetStart().GetLine(), d.GetStart().GetColumn()
+	if name == "main" {
+		return fmt.Errorf(
+			"entrypoint 'main' may not be overloaded at %d:%d", line, col,
+		)
+	}
+
+	paramTypes := make([]tast.Type, len(paramNames))
+	for i, paramName := range paramNames {
+		paramTypes[i] = params[paramName]
+	}
+	for _, sign := range tc.env.LookupFuncs(name) {
+		if sameParamTypes(signatureType(sign).Params, paramTypes) {
+			return fmt.Errorf(
+				"redefinition of function '%s' with the same parameter types "+
+					"at %d:%d",
+				name, line, col,
+			)
+		}
+	}
+	return nil
+}
+
+func sameParamTypes(params1, params2 []tast.Type) bool {
+	if len(params1) != len(params2) {
+		return false
+	}
+	for i := range params1 {
+		if tast.Mangle(params1[i]) != tast.Mangle(params2[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveOverload picks the signature of funcName that the arguments
+// typedExps convert to with the fewest promotions. As in every call, int
+// arguments may be promoted to double, but such matches rank below exact
+// ones. Calls that match no overload, or several equally well, are errors.
+func resolveOverload(
+	funcName string,
+	signs []env.Signature[tast.Type],
+	typedExps []tast.Exp,
+	line, col int,
+) (env.Signature[tast.Type], error) {
+	var best []env.Signature[tast.Type]
+	bestCost := -1
+	for _, sign := range signs {
+		cost, viable := conversionCost(signatureType(sign).Params, typedExps)
+		if !viable {
+			continue
+		}
+		switch {
+		case bestCost < 0 || cost < bestCost:
+			best, bestCost = []env.Signature[tast.Type]{sign}, cost
+		case cost == bestCost:
+			best = append(best, sign)
+		}
+	}
+
+	argTypes := make([]string, len(typedExps))
+	for i, exp := range typedExps {
+		argTypes[i] = exp.Type().String()
+	}
+	switch len(best) {
+	case 0:
+		return env.Signature[tast.Type]{}, fmt.Errorf(
+			"no overload of function '%s' matches argument types (%s) at %d:%d",
+			funcName, strings.Join(argTypes, ", "), line, col,
+		)
+	case 1:
+		return best[0], nil
+	default:
+		return env.Signature[tast.Type]{}, fmt.Errorf(
+			"ambiguous call to overloaded function '%s' with argument types "+
+				"(%s) at %d:%d",
+			funcName, strings.Join(argTypes, ", "), line, col,
+		)
+	}
+}
+
+// conversionCost returns the number of arguments that have to be converted to
+// match paramTypes, and whether all arguments can be converted at all.
+func conversionCost(paramTypes []tast.Type, typedExps []tast.Exp) (int, bool) {
+	if len(paramTypes) != len(typedExps) {
+		return 0, false
+	}
+	cost := 0
+	for i, expected := range paramTypes {
+		actual := typedExps[i].Type()
+		switch {
+		case !isOverloadConvertible(expected, actual):
+			return 0, false
+		case tast.Mangle(expected) != tast.Mangle(actual):
+			cost++
+		}
+	}
+	return cost, true
+}
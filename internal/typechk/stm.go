@@ -14,6 +14,8 @@ func (tc *TypeChecker) checkStm(stm parser.IStmContext) (tast.Stm, error) {
 		return tc.checkExpStm(s, line, col, text)
 	case *parser.DeclsStmContext:
 		return tc.checkDeclsStm(s, line, col, text)
+	case *parser.TupleDeclStmContext:
+		return tc.checkTupleDeclStm(s, line, col, text)
 	case *parser.ReturnStmContext:
 		return tc.checkReturnStm(s, line, col, text)
 	case *parser.VoidReturnStmContext:
@@ -26,8 +28,21 @@ func (tc *TypeChecker) checkStm(stm parser.IStmContext) (tast.Stm, error) {
 		return tc.checkBlockStm(s, line, col, text)
 	case *parser.IfStmContext:
 		return tc.checkIfStm(s, line, col, text)
+	case *parser.SwitchStmContext:
+		return tc.checkSwitchStm(s, line, col, text)
+	case *parser.ThrowStmContext:
+		return tc.checkThrowStm(s, line, col, text)
+	case *parser.TryStmContext:
+		return tc.checkTryStm(s, line, col, text)
+	case *parser.AssertStmContext:
+		return tc.checkAssertStm(s, line, col, text)
 	case *parser.BlankStmContext:
 		return tast.NewBlankStm(line, col, text), nil
+	case *parser.DeferStmContext:
+		return nil, fmt.Errorf(
+			"defer statement must be directly inside a block at %d:%d near '%s'",
+			line, col, text,
+		)
 	default:
 		return nil, fmt.Errorf(
 			"checkStm: unhandled stm type %T at %d:%d near '%s'",
@@ -35,3 +50,32 @@ func (tc *TypeChecker) checkStm(stm parser.IStmContext) (tast.Stm, error) {
 		)
 	}
 }
+
+// checkStmList type checks the statements of a block, which unlike the single
+// statements of if and loop statements may be defer statements.
+func (tc *TypeChecker) checkStmList(stms []parser.IStmContext) ([]tast.Stm, error) {
+	typedStms := []tast.Stm{}
+	for _, stm := range stms {
+		var typedStm tast.Stm
+		var err error
+		if s, ok := stm.(*parser.DeferStmContext); ok {
+			typedStm, err = tc.checkDeferStm(s)
+		} else {
+			typedStm, err = tc.checkStm(stm)
+		}
+		if err != nil {
+			return nil, err
+		}
+		typedStms = append(typedStms, typedStm)
+	}
+	return typedStms, nil
+}
+
+func (tc *TypeChecker) checkDeferStm(s *parser.DeferStmContext) (*tast.DeferStm, error) {
+	line, col, text := extractPosData(s)
+	typedExp, err := tc.inferExp(s.Exp())
+	if err != nil {
+		return nil, err
+	}
+	return tast.NewDeferStm(typedExp, line, col, text), nil
+}
@@ -32,7 +32,7 @@ func (tc *TypeChecker) checkForEachStm(
 		return nil, err
 	}
 
-	arrType, ok := exp.Type().(*tast.ArrayType)
+	arrType, ok := UnwrapTypedef(exp.Type()).(*tast.ArrayType)
 	if !ok {
 		return nil, fmt.Errorf(
 			"can only iterate over array objects at %d:%d near %s",
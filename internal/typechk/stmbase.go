@@ -27,9 +27,15 @@ func (tc *TypeChecker) checkExpStm(
 func (tc *TypeChecker) checkDeclsStm(
 	s *parser.DeclsStmContext, line, col int, text string,
 ) (*tast.DeclsStm, error) {
-	typ, err := tc.toTastType(s.Type_())
-	if err != nil {
-		return nil, err
+	// the type of each variable declared with var is inferred from its
+	// initializer
+	var typ tast.Type
+	if s.Type_() != nil {
+		var err error
+		typ, err = tc.toTastType(s.Type_())
+		if err != nil {
+			return nil, err
+		}
 	}
 	if typ == tast.Void {
 		return nil, fmt.Errorf(
@@ -90,7 +96,7 @@ func (tc *TypeChecker) checkWhileStm(
 	if err != nil {
 		return nil, err
 	}
-	if typedExp.Type() != tast.Bool {
+	if UnwrapTypedef(typedExp.Type()) != tast.Bool {
 		return nil, fmt.Errorf(
 			"expression in while-loop does not have type bool at %d:%d "+
 				"near '%s'", line, col, text,
@@ -109,13 +115,9 @@ func (tc *TypeChecker) checkBlockStm(
 	s *parser.BlockStmContext, line, col int, text string,
 ) (*tast.BlockStm, error) {
 	tc.env.EnterContext()
-	typedStms := []tast.Stm{}
-	for _, stm := range s.AllStm() {
-		typedStm, err := tc.checkStm(stm)
-		if err != nil {
-			return nil, err
-		}
-		typedStms = append(typedStms, typedStm)
+	typedStms, err := tc.checkStmList(s.AllStm())
+	if err != nil {
+		return nil, err
 	}
 	tc.env.ExitContext()
 	return tast.NewBlockStm(typedStms, line, col, text), nil
@@ -128,7 +130,7 @@ func (tc *TypeChecker) checkIfStm(
 	if err != nil {
 		return nil, err
 	}
-	if typedExp.Type() != tast.Bool {
+	if UnwrapTypedef(typedExp.Type()) != tast.Bool {
 		return nil, fmt.Errorf(
 			"if else expression does not have type bool at %d:%d near '%s'",
 			line, col, text,
@@ -153,3 +155,35 @@ func (tc *TypeChecker) checkIfStm(
 	}
 	return tast.NewIfStm(typedExp, thenStm, elseStm, line, col, text), nil
 }
+
+func (tc *TypeChecker) checkAssertStm(
+	s *parser.AssertStmContext, line, col int, text string,
+) (*tast.AssertStm, error) {
+	typedExp, err := tc.inferExp(s.Exp(0))
+	if err != nil {
+		return nil, err
+	}
+	if UnwrapTypedef(typedExp.Type()) != tast.Bool {
+		return nil, fmt.Errorf(
+			"asserted expression does not have type bool at %d:%d near '%s'",
+			line, col, text,
+		)
+	}
+
+	var message tast.Exp
+	if s.Exp(1) != nil {
+		message, err = tc.inferExp(s.Exp(1))
+		if err != nil {
+			return nil, err
+		}
+		if UnwrapTypedef(message.Type()) != tast.String {
+			return nil, fmt.Errorf(
+				"assert message does not have type string at %d:%d near '%s'",
+				line, col, text,
+			)
+		}
+	}
+	return tast.NewAssertStm(
+		typedExp, message, tc.file, line, col, text,
+	), nil
+}
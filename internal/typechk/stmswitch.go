@@ -0,0 +1,174 @@
+package typechk
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aramyamal/javalette-to-llvm-compiler/gen/parser"
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+)
+
+func (tc *TypeChecker) checkSwitchStm(
+	s *parser.SwitchStmContext, line, col int, text string,
+) (*tast.SwitchStm, error) {
+	typedExp, err := tc.inferExp(s.Exp())
+	if err != nil {
+		return nil, err
+	}
+	enumType, isEnum := UnwrapTypedef(typedExp.Type()).(*tast.EnumType)
+	if !isEnum && UnwrapTypedef(typedExp.Type()) != tast.Int {
+		return nil, fmt.Errorf(
+			"switch expression must have type int or enum but has type %s "+
+				"at %d:%d near '%s'",
+			typedExp.Type(), line, col, text,
+		)
+	}
+
+	var cases []*tast.SwitchCase
+	var defaultCase *tast.SwitchCase
+	seen := make(map[int]struct{})
+	for _, switchCase := range s.AllSwitchCase() {
+		caseLine, caseCol, caseText := extractPosData(switchCase)
+		switch c := switchCase.(type) {
+		case *parser.ExpCaseContext:
+			var values []int
+			for _, label := range c.AllExp() {
+				value, err := tc.caseValue(label, enumType)
+				if err != nil {
+					return nil, err
+				}
+				if _, exists := seen[value]; exists {
+					labelLine, labelCol, labelText := extractPosData(label)
+					return nil, fmt.Errorf(
+						"duplicate case '%s' in switch at %d:%d",
+						labelText, labelLine, labelCol,
+					)
+				}
+				seen[value] = struct{}{}
+				values = append(values, value)
+			}
+			stms, err := tc.checkCaseStms(c.AllStm())
+			if err != nil {
+				return nil, err
+			}
+			cases = append(cases, tast.NewSwitchCase(
+				values, stms, caseLine, caseCol, caseText,
+			))
+		case *parser.DefaultCaseContext:
+			if defaultCase != nil {
+				return nil, fmt.Errorf(
+					"multiple default cases in switch at %d:%d",
+					caseLine, caseCol,
+				)
+			}
+			stms, err := tc.checkCaseStms(c.AllStm())
+			if err != nil {
+				return nil, err
+			}
+			defaultCase = tast.NewSwitchCase(
+				nil, stms, caseLine, caseCol, caseText,
+			)
+		default:
+			return nil, fmt.Errorf(
+				"checkSwitchStm: unhandled case type %T at %d:%d near '%s'",
+				c, caseLine, caseCol, caseText,
+			)
+		}
+	}
+
+	// switches over enums must handle every constant unless they have a
+	// default case
+	exhaustive := defaultCase != nil
+	if isEnum {
+		var missing []string
+		for i, constant := range enumType.Constants {
+			if _, exists := seen[i]; !exists {
+				missing = append(missing, constant)
+			}
+		}
+		if len(missing) == 0 {
+			exhaustive = true
+		} else if defaultCase == nil {
+			return nil, fmt.Errorf(
+				"switch on enum '%s' does not handle %s at %d:%d near '%s'",
+				enumType.Name, strings.Join(missing, ", "), line, col, text,
+			)
+		}
+	}
+
+	return tast.NewSwitchStm(
+		typedExp, cases, defaultCase, exhaustive, line, col, text,
+	), nil
+}
+
+// checkCaseStms type checks the statements of a switch case in their own scope.
+func (tc *TypeChecker) checkCaseStms(stms []parser.IStmContext) ([]tast.Stm, error) {
+	tc.env.EnterContext()
+	typedStms, err := tc.checkStmList(stms)
+	if err != nil {
+		return nil, err
+	}
+	tc.env.ExitContext()
+	return typedStms, nil
+}
+
+// caseValue returns the value of a case label, which is a constant of enumType
+// when switching on an enum, and an integer literal otherwise.
+func (tc *TypeChecker) caseValue(
+	label parser.IExpContext, enumType *tast.EnumType,
+) (int, error) {
+	line, col, text := extractPosData(label)
+
+	if enumType != nil {
+		// constants may be used without the enum name in case labels
+		if ident, ok := label.(*parser.IdentExpContext); ok {
+			constant, err := tc.enumConstant(
+				enumType, ident.Ident().GetText(), line, col, text,
+			)
+			if err != nil {
+				return 0, err
+			}
+			return constant.Value, nil
+		}
+		typedExp, err := tc.inferExp(label)
+		if err != nil {
+			return 0, err
+		}
+		constant, ok := typedExp.(*tast.EnumExp)
+		if !ok || UnwrapTypedef(typedExp.Type()) != enumType {
+			return 0, fmt.Errorf(
+				"case label is not a constant of enum '%s' at %d:%d near '%s'",
+				enumType.Name, line, col, text,
+			)
+		}
+		return constant.Value, nil
+	}
+
+	typedExp, err := tc.inferExp(label)
+	if err != nil {
+		return 0, err
+	}
+	value, ok := intLiteral(typedExp)
+	if !ok {
+		return 0, fmt.Errorf(
+			"case label is not an integer literal at %d:%d near '%s'",
+			line, col, text,
+		)
+	}
+	return value, nil
+}
+
+// intLiteral returns the value of a possibly negated integer literal.
+func intLiteral(exp tast.Exp) (int, bool) {
+	switch e := exp.(type) {
+	case *tast.IntExp:
+		return e.Value, true
+	case *tast.ParenExp:
+		return intLiteral(e.Exp)
+	case *tast.NegExp:
+		value, ok := intLiteral(e.Exp)
+		return -value, ok
+	default:
+		return 0, false
+	}
+}
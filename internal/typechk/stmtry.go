@@ -0,0 +1,104 @@
+package typechk
+
+import (
+	"fmt"
+
+	"github.com/aramyamal/javalette-to-llvm-compiler/gen/parser"
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+)
+
+func (tc *TypeChecker) checkThrowStm(
+	s *parser.ThrowStmContext, line, col int, text string,
+) (*tast.ThrowStm, error) {
+	typedExp, err := tc.inferExp(s.Exp())
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := errorStruct(typedExp.Type()); !ok {
+		return nil, fmt.Errorf(
+			"thrown value must be a pointer to a struct extending %s but has "+
+				"type %s at %d:%d near '%s'",
+			tast.ErrorName, typedExp.Type(), line, col, text,
+		)
+	}
+	return tast.NewThrowStm(typedExp, line, col, text), nil
+}
+
+func (tc *TypeChecker) checkTryStm(
+	s *parser.TryStmContext, line, col int, text string,
+) (*tast.TryStm, error) {
+	stms, err := tc.checkScopedStms(s.AllStm(), "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var catches []*tast.CatchClause
+	for _, clause := range s.AllCatchClause() {
+		clauseLine, clauseCol, clauseText := extractPosData(clause)
+		typ, err := tc.toTastType(clause.Type_())
+		if err != nil {
+			return nil, err
+		}
+		errorType, ok := errorStruct(typ)
+		if !ok {
+			return nil, fmt.Errorf(
+				"caught type must be a pointer to a struct extending %s but is "+
+					"%s at %d:%d near '%s'",
+				tast.ErrorName, typ, clauseLine, clauseCol, clauseText,
+			)
+		}
+
+		// a clause after one handling a struct it extends is never reached
+		for _, earlier := range catches {
+			if errorType.IsSubtypeOf(earlier.Error.Name) {
+				return nil, fmt.Errorf(
+					"unreachable catch clause for '%s', which is already "+
+						"caught as '%s' at %d:%d near '%s'",
+					errorType.Name, earlier.Error.Name,
+					clauseLine, clauseCol, clauseText,
+				)
+			}
+		}
+
+		id := clause.Ident().GetText()
+		clauseStms, err := tc.checkScopedStms(clause.AllStm(), id, typ)
+		if err != nil {
+			return nil, err
+		}
+		catches = append(catches, tast.NewCatchClause(
+			id, typ, errorType, clauseStms, clauseLine, clauseCol, clauseText,
+		))
+	}
+
+	return tast.NewTryStm(stms, catches, line, col, text), nil
+}
+
+// checkScopedStms type checks stms in their own scope, in which the variable
+// id of type typ is declared unless id is empty.
+func (tc *TypeChecker) checkScopedStms(
+	stms []parser.IStmContext, id string, typ tast.Type,
+) ([]tast.Stm, error) {
+	tc.env.EnterContext()
+	if id != "" {
+		tc.env.ExtendVar(id, typ)
+	}
+	typedStms, err := tc.checkStmList(stms)
+	if err != nil {
+		return nil, err
+	}
+	tc.env.ExitContext()
+	return typedStms, nil
+}
+
+// errorStruct returns the struct pointed to by typ if it extends Error.
+func errorStruct(typ tast.Type) (*tast.StructType, bool) {
+	pointerType, ok := UnwrapTypedef(typ).(*tast.PointerType)
+	if !ok {
+		return nil, false
+	}
+	structType, ok := UnwrapTypedef(pointerType.Elem).(*tast.StructType)
+	if !ok || !structType.IsSubtypeOf(tast.ErrorName) {
+		return nil, false
+	}
+	return structType, true
+}
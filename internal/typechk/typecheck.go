@@ -9,6 +9,7 @@ import (
 	"fmt"
 
 	"github.com/aramyamal/javalette-to-llvm-compiler/gen/parser"
+	"github.com/aramyamal/javalette-to-llvm-compiler/internal/loader"
 	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
 	"github.com/aramyamal/javalette-to-llvm-compiler/pkg/env"
 )
@@ -17,13 +18,39 @@ import (
 // produced by ANTLR4 from package parser. After successful type checking, it
 // produces a typed abstract syntax tree using the tast package.
 type TypeChecker struct {
-	env *env.Environment[tast.Type]
+	env         *env.Environment[tast.Type]
+	lambdas     []*lambdaScope // lambdas enclosing the current expression
+	lambdaCount int
+
+	structTemplates map[string]*parser.StructDefContext
+	funcTemplates   map[string]*parser.FuncDefContext
+	funcLinks       map[string]string    // link names of reused functions
+	typeArgs        map[string]tast.Type // bound type parameters
+	instances       []tast.Def           // instantiated generic structs
+	pendingFuncs    []funcInstance       // generic functions to check
+
+	// scopes of the modules defining imported generic definitions
+	templateScopes map[parser.IDefContext]*moduleScope
+	externs        map[string]struct{} // names of extern functions
+	file           string              // name of the file being checked
+
+	// typedefs of the module being validated that are not yet registered,
+	// and those whose aliased type is being resolved
+	pendingTypedefs   map[string]*parser.TypedefDefContext
+	resolvingTypedefs map[string]struct{}
 }
 
 // NewTypeChecker creates and returns a new TypeChecker instance.
 func NewTypeChecker() *TypeChecker {
 	env := env.NewEnvironment[tast.Type]()
-	return &TypeChecker{env: env}
+	return &TypeChecker{
+		env:             env,
+		structTemplates: make(map[string]*parser.StructDefContext),
+		funcTemplates:   make(map[string]*parser.FuncDefContext),
+		funcLinks:       make(map[string]string),
+		templateScopes:  make(map[parser.IDefContext]*moduleScope),
+		externs:         make(map[string]struct{}),
+	}
 }
 
 // Typecheck performs type checking on the given parse tree representing a
@@ -38,30 +65,56 @@ func (tc *TypeChecker) Typecheck(tree parser.IPrgmContext) (*tast.Prgm, error) {
 	if !ok {
 		return nil, fmt.Errorf("expected *parser.ProgramContext, got %T", tree)
 	}
-	defs := prgm.AllDef()
-	if err := tc.validateMainFunc(defs); err != nil {
-		return nil, err
+	if len(prgm.AllImportDecl()) > 0 {
+		return nil, fmt.Errorf(
+			"imports require the program to be loaded with package loader",
+		)
 	}
+	return tc.TypecheckModules([]*loader.Module{{Tree: prgm}})
+}
 
-	tc.env.AddStdFunc("printInt", tast.Void, tast.Int)
-	tc.env.AddStdFunc("printDouble", tast.Void, tast.Double)
-	tc.env.AddStdFunc("printString", tast.Void, tast.String)
-	tc.env.AddStdFuncNoParam("readInt", tast.Int)
-	tc.env.AddStdFuncNoParam("readDouble", tast.Double)
-
-	tc.env.EnterContext()
-
-	if err := tc.validateDefs(defs); err != nil {
+// TypecheckModules performs type checking on a Javalette program made up of
+// several modules, as loaded by the loader package. The modules must be given
+// in dependency order with the module defining the entrypoint last.
+//
+// Each module is checked in its own environment, where only its own
+// definitions and those of the modules it imports directly are visible. The
+// definitions of all modules are linked into a single TAST.
+func (tc *TypeChecker) TypecheckModules(
+	modules []*loader.Module,
+) (*tast.Prgm, error) {
+	if len(modules) == 0 {
+		return nil, fmt.Errorf("program has no modules")
+	}
+	root := modules[len(modules)-1]
+	if err := tc.validateMainFunc(root.Tree.AllDef()); err != nil {
 		return nil, err
 	}
-
-	typedDefs, err := tc.checkDefs(prgm.AllDef())
-	if err != nil {
+	if err := validateSharedDefs(modules); err != nil {
 		return nil, err
 	}
+	links := funcLinks(modules)
 
-	tc.env.ExitContext()
+	// the built-in Error struct is defined once for all modules
+	scopes := make(map[*loader.Module]*moduleScope)
+	typedDefs := []tast.Def{
+		tast.NewStructDef(tast.RegisterError(), 0, 0, tast.ErrorName),
+	}
+	for i, module := range modules {
+		moduleDefs, err := tc.checkModule(module, links[i], scopes)
+		if err != nil {
+			if module.Path != "" && module != root {
+				return nil, fmt.Errorf("in module %s: %w", module.Path, err)
+			}
+			return nil, err
+		}
+		scopes[module] = tc.currentScope()
+		typedDefs = append(typedDefs, moduleDefs...)
+	}
+	if err := validateExterns(modules, scopes); err != nil {
+		return nil, err
+	}
 
-	typedPrgm := tast.NewPrgm(typedDefs)
+	typedPrgm := tast.NewPrgm(linkDefs(typedDefs))
 	return typedPrgm, nil
 }
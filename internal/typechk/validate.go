@@ -2,9 +2,11 @@ package typechk
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/aramyamal/javalette-to-llvm-compiler/gen/parser"
 	"github.com/aramyamal/javalette-to-llvm-compiler/internal/tast"
+	"github.com/aramyamal/javalette-to-llvm-compiler/pkg/env"
 )
 
 func (tc *TypeChecker) validateMainFunc(defs []parser.IDefContext) error {
@@ -22,8 +24,30 @@ func (tc *TypeChecker) validateMainFunc(defs []parser.IDefContext) error {
 		return fmt.Errorf("program has no entrypoint 'main'")
 	}
 
-	if len(mainFunc.AllArg()) != 0 {
-		return fmt.Errorf("entrypoint 'main' may not have input variables")
+	if mainFunc.TypeParams() != nil {
+		return fmt.Errorf("entrypoint 'main' may not be generic")
+	}
+
+	// main may take the command-line arguments as an array of strings
+	if args := mainFunc.AllArg(); len(args) != 0 {
+		_, params, err := tc.extractParams(args)
+		if err != nil {
+			return err
+		}
+		arg, ok := args[0].(*parser.ParamArgContext)
+		if !ok || len(args) != 1 {
+			return fmt.Errorf(
+				"entrypoint 'main' may only take a single parameter of type " +
+					"string[]",
+			)
+		}
+		arr, isArr := UnwrapTypedef(params[arg.Ident().GetText()]).(*tast.ArrayType)
+		if !isArr || UnwrapTypedef(arr.Elem) != tast.String {
+			return fmt.Errorf(
+				"entrypoint 'main' may only take a single parameter of type " +
+					"string[]",
+			)
+		}
 	}
 
 	if typ, err := tc.toTastType(mainFunc.Type_()); err != nil {
@@ -39,11 +63,41 @@ func (tc *TypeChecker) validateDefs(
 	defs []parser.IDefContext,
 ) error {
 
-	// first pass to register struct names
+	// first pass to register struct and enum names
 	for _, def := range defs {
 		switch d := def.(type) {
+		case *parser.EnumDefContext:
+			if err := tc.registerEnum(d); err != nil {
+				return err
+			}
 		case *parser.StructDefContext:
 			name := d.Ident().GetText()
+			if _, exists := tc.env.LookupEnum(name); exists {
+				return fmt.Errorf(
+					"redefinition of type '%s' at %d:%d",
+					name, d.GetStart().GetLine(), d.GetStart().GetColumn(),
+				)
+			}
+			if _, exists := tc.structTemplates[name]; exists {
+				return fmt.Errorf(
+					"redefinition of struct '%s' at %d:%d",
+					name, d.GetStart().GetLine(), d.GetStart().GetColumn(),
+				)
+			}
+			// generic structs are only registered as templates, and are
+			// instantiated when used with type arguments
+			if d.TypeParams() != nil && d.StructExtends() != nil {
+				return fmt.Errorf(
+					"generic struct '%s' cannot extend another struct at %d:%d",
+					name, d.GetStart().GetLine(), d.GetStart().GetColumn(),
+				)
+			}
+			if d.TypeParams() != nil {
+				if err := tc.registerStructTemplate(d); err != nil {
+					return err
+				}
+				continue
+			}
 			// register struct name with placeholder type
 			if ok := tc.env.ExtendStruct(name, tast.RegisterStruct(name)); !ok {
 				return fmt.Errorf(
@@ -54,7 +108,7 @@ func (tc *TypeChecker) validateDefs(
 		// report unhandled types
 		case *parser.TypedefDefContext:
 			continue
-		case *parser.FuncDefContext:
+		case *parser.FuncDefContext, *parser.ExternDefContext:
 			continue // handled in last pass
 		default:
 			return fmt.Errorf(
@@ -64,59 +118,123 @@ func (tc *TypeChecker) validateDefs(
 		}
 	}
 
-	// second pass to register typedefs aliasing structs and other types
+	// second pass to register typedefs aliasing any type, where typedefs
+	// aliasing another typedef of the module are registered after it
+	tc.pendingTypedefs = make(map[string]*parser.TypedefDefContext)
+	tc.resolvingTypedefs = make(map[string]struct{})
 	for _, def := range defs {
 		if d, ok := def.(*parser.TypedefDefContext); ok {
-			alias := d.Type_(1).GetText()
-			aliasType, err := tc.toTastType(d.Type_(0))
-			if err != nil {
-				return err
-			}
-			if ok := tc.env.ExtendTypedef(alias, tast.Pointer(aliasType)); !ok {
+			alias := d.Ident().GetText()
+			_, isPending := tc.pendingTypedefs[alias]
+			if _, exists := tc.env.LookupTypedef(alias); exists || isPending {
 				return fmt.Errorf(
 					"redefinition of typedef '%s' at %d:%d",
 					alias, d.GetStart().GetLine(), d.GetStart().GetColumn(),
 				)
 			}
+			tc.pendingTypedefs[alias] = d
 		}
 	}
+	for _, def := range defs {
+		if d, ok := def.(*parser.TypedefDefContext); ok {
+			if err := tc.resolveTypedef(d.Ident().GetText()); err != nil {
+				return err
+			}
+		}
+	}
+	tc.pendingTypedefs = nil
 
-	// third pass to register correct struct fields
+	// third pass to register correct struct fields, where structs extending
+	// another struct of the module are registered after it
+	structDefs := make(map[string]*parser.StructDefContext)
 	for _, def := range defs {
-		if d, ok := def.(*parser.StructDefContext); ok {
-			name := d.Ident().GetText()
-			fieldNames := make(map[string]struct{})
-			var fields []*tast.FieldCreator
-			for _, structField := range d.AllStructField() {
-
-				// check for duplicate field names
-				fieldName := structField.Ident().GetText()
-				if _, exists := fieldNames[fieldName]; exists {
-					return fmt.Errorf(
-						"duplicate field name '%s' in struct '%s'",
-						fieldName, name,
-					)
-				}
-				fieldNames[fieldName] = struct{}{}
-
-				fieldType, err := tc.toTastType(structField.Type_())
-				if err != nil {
-					return fmt.Errorf(
-						"error resolving type of field '%s' in struct '%s': %v",
-						structField.Ident().GetText(), name, err,
-					)
-				}
-				fields = append(fields, tast.Field(fieldType, structField.Ident().GetText()))
+		if d, ok := def.(*parser.StructDefContext); ok && d.TypeParams() == nil {
+			structDefs[d.Ident().GetText()] = d
+		}
+	}
+	registered := make(map[string]bool)
+	for _, def := range defs {
+		if d, ok := def.(*parser.StructDefContext); ok && d.TypeParams() == nil {
+			if err := tc.registerStructFields(d, structDefs, registered); err != nil {
+				return err
+			}
+		}
+	}
+	for _, def := range defs {
+		if d, ok := def.(*parser.StructDefContext); ok && d.TypeParams() == nil {
+			structType, _ := tc.env.LookupStruct(d.Ident().GetText())
+			if err := validateStructSize(
+				structType.(*tast.StructType), d,
+			); err != nil {
+				return err
 			}
-			tast.RegisterStruct(name, fields...)
 		}
 	}
 
-	// last pass to handle functions
+	// last pass to handle functions, where functions defined more than once
+	// are overloads that must differ in their parameter types. Extern functions
+	// keep their C name and cannot be overloaded.
+	definedFuncs := make(map[string]struct{})
+	externFuncs := make(map[string]struct{})
 	for _, def := range defs {
 		switch d := def.(type) {
+		case *parser.ExternDefContext:
+			name := d.Ident().GetText()
+			_, isTemplate := tc.funcTemplates[name]
+			_, isDefined := definedFuncs[name]
+			if isTemplate || isDefined {
+				return fmt.Errorf(
+					"redefinition of function '%s' at %d:%d",
+					name, d.GetStart().GetLine(), d.GetStart().GetColumn(),
+				)
+			}
+			paramNames, params, returnType, err := tc.validateExtern(d)
+			if err != nil {
+				return err
+			}
+			// the same extern function may also be declared by an imported
+			// module
+			declared := env.Signature[tast.Type]{
+				ParamNames: paramNames, Params: params, Returns: returnType,
+			}
+			_, isExtern := tc.externs[name]
+			if imported, exists := tc.env.LookupFunc(name); exists && isExtern &&
+				sameFuncType(signatureType(imported), signatureType(declared)) {
+				definedFuncs[name] = struct{}{}
+				externFuncs[name] = struct{}{}
+				continue
+			}
+			if ok := tc.env.ExtendFunc(
+				name, paramNames, params, returnType,
+			); !ok {
+				return fmt.Errorf(
+					"redefinition of function '%s' at %d:%d",
+					name, d.GetStart().GetLine(), d.GetStart().GetColumn(),
+				)
+			}
+			definedFuncs[name] = struct{}{}
+			externFuncs[name] = struct{}{}
+			tc.externs[name] = struct{}{}
 		case *parser.FuncDefContext:
 			name := d.Ident().GetText()
+			if _, exists := externFuncs[name]; exists {
+				return fmt.Errorf(
+					"extern function '%s' cannot be overloaded at %d:%d",
+					name, d.GetStart().GetLine(), d.GetStart().GetColumn(),
+				)
+			}
+			if _, exists := tc.funcTemplates[name]; exists {
+				return fmt.Errorf(
+					"redefinition of function '%s' at %d:%d",
+					name, d.GetStart().GetLine(), d.GetStart().GetColumn(),
+				)
+			}
+			if d.TypeParams() != nil {
+				if err := tc.registerFuncTemplate(d); err != nil {
+					return err
+				}
+				continue
+			}
 			returnType, err := tc.toTastType(d.Type_())
 			if err != nil {
 				return err
@@ -127,6 +245,17 @@ func (tc *TypeChecker) validateDefs(
 				return err
 			}
 
+			if _, defined := definedFuncs[name]; defined {
+				if err := tc.validateOverload(
+					d, name, paramNames, params,
+				); err != nil {
+					return err
+				}
+				tc.env.ExtendOverload(name, paramNames, params, returnType)
+				continue
+			}
+			definedFuncs[name] = struct{}{}
+
 			if ok := tc.env.ExtendFunc(
 				name, paramNames, params, returnType,
 			); !ok {
@@ -139,3 +268,215 @@ func (tc *TypeChecker) validateDefs(
 	}
 	return nil
 }
+
+// registerEnum registers the enum type defined by d, reporting duplicate
+// constants and names already taken by structs or other enums.
+func (tc *TypeChecker) registerEnum(d *parser.EnumDefContext) error {
+	idents := d.AllIdent()
+	name := idents[0].GetText()
+	if _, exists := tc.env.LookupStruct(name); exists {
+		return fmt.Errorf(
+			"redefinition of type '%s' at %d:%d",
+			name, d.GetStart().GetLine(), d.GetStart().GetColumn(),
+		)
+	}
+	if _, exists := tc.structTemplates[name]; exists {
+		return fmt.Errorf(
+			"redefinition of type '%s' at %d:%d",
+			name, d.GetStart().GetLine(), d.GetStart().GetColumn(),
+		)
+	}
+
+	var constants []string
+	seen := make(map[string]struct{})
+	for _, ident := range idents[1:] {
+		constant := ident.GetText()
+		if _, exists := seen[constant]; exists {
+			return fmt.Errorf(
+				"duplicate constant '%s' in enum '%s' at %d:%d",
+				constant, name,
+				ident.GetSymbol().GetLine(), ident.GetSymbol().GetColumn(),
+			)
+		}
+		seen[constant] = struct{}{}
+		constants = append(constants, constant)
+	}
+
+	if ok := tc.env.ExtendEnum(name, tast.Enum(name, constants...)); !ok {
+		return fmt.Errorf(
+			"redefinition of enum '%s' at %d:%d",
+			name, d.GetStart().GetLine(), d.GetStart().GetColumn(),
+		)
+	}
+	return nil
+}
+
+// validateStructSize reports a struct containing itself by value, directly or
+// through the struct values it contains, as it would have no finite size.
+// Pointers to the struct are allowed.
+func validateStructSize(
+	structType *tast.StructType, d *parser.StructDefContext,
+) error {
+	if containsStruct(structType, structType.Name, map[string]struct{}{}) {
+		return fmt.Errorf(
+			"struct '%s' contains itself by value at %d:%d",
+			d.Ident().GetText(), d.GetStart().GetLine(),
+			d.GetStart().GetColumn(),
+		)
+	}
+	return nil
+}
+
+// containsStruct reports whether a struct value of type structType contains a
+// struct value named name. The names of the structs already searched are kept
+// in seen.
+func containsStruct(
+	structType *tast.StructType, name string, seen map[string]struct{},
+) bool {
+	for _, field := range structType.Fields() {
+		fieldInfo, _ := structType.FieldInfo(field)
+		inner, ok := UnwrapTypedef(fieldInfo.Type).(*tast.StructType)
+		if !ok {
+			continue
+		}
+		if inner.Name == name {
+			return true
+		}
+		if _, exists := seen[inner.Name]; exists {
+			continue
+		}
+		seen[inner.Name] = struct{}{}
+		if containsStruct(inner, name, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// registerStructFields registers the fields of the struct definition d, which
+// start with the fields of the struct it extends. The structs of the module
+// are given by structDefs, and registered maps the names of the structs being
+// or already registered to whether they are done.
+func (tc *TypeChecker) registerStructFields(
+	d *parser.StructDefContext,
+	structDefs map[string]*parser.StructDefContext,
+	registered map[string]bool,
+) error {
+	name := d.Ident().GetText()
+	if done, visited := registered[name]; visited {
+		if !done {
+			return fmt.Errorf(
+				"struct '%s' extends itself at %d:%d",
+				name, d.GetStart().GetLine(), d.GetStart().GetColumn(),
+			)
+		}
+		return nil
+	}
+	registered[name] = false
+
+	var fields []*tast.FieldCreator
+	if extends := d.StructExtends(); extends != nil {
+		parentName := extends.Ident().GetText()
+		if parentDef, ok := structDefs[parentName]; ok {
+			if err := tc.registerStructFields(
+				parentDef, structDefs, registered,
+			); err != nil {
+				return err
+			}
+		}
+		envParent, _ := tc.env.LookupStruct(parentName)
+		parent, ok := envParent.(*tast.StructType)
+		if !ok {
+			return fmt.Errorf(
+				"struct '%s' extends undefined struct '%s' at %d:%d",
+				name, parentName,
+				extends.GetStart().GetLine(), extends.GetStart().GetColumn(),
+			)
+		}
+		for _, fieldName := range parent.Fields() {
+			fieldInfo, _ := parent.FieldInfo(fieldName)
+			fields = append(fields, tast.Field(fieldInfo.Type, fieldName))
+		}
+		tast.SetParent(name, parent)
+	}
+
+	ownFields, err := tc.resolveStructFields(d, name)
+	if err != nil {
+		return err
+	}
+	for _, field := range ownFields {
+		if slices.ContainsFunc(fields, func(f *tast.FieldCreator) bool {
+			return f.Name == field.Name
+		}) {
+			return fmt.Errorf(
+				"field '%s' of struct '%s' is already defined in the struct "+
+					"it extends", field.Name, name,
+			)
+		}
+	}
+	tast.RegisterStruct(name, append(fields, ownFields...)...)
+	registered[name] = true
+	return nil
+}
+
+// resolveStructFields resolves the field types of the struct definition d,
+// reporting errors under the struct name.
+func (tc *TypeChecker) resolveStructFields(
+	d *parser.StructDefContext, name string,
+) ([]*tast.FieldCreator, error) {
+	fieldNames := make(map[string]struct{})
+	var fields []*tast.FieldCreator
+	for _, structField := range d.AllStructField() {
+
+		// check for duplicate field names
+		fieldName := structField.Ident().GetText()
+		if _, exists := fieldNames[fieldName]; exists {
+			return nil, fmt.Errorf(
+				"duplicate field name '%s' in struct '%s'",
+				fieldName, name,
+			)
+		}
+		fieldNames[fieldName] = struct{}{}
+
+		fieldType, err := tc.toTastType(structField.Type_())
+		if err != nil {
+			return nil, fmt.Errorf(
+				"error resolving type of field '%s' in struct '%s': %v",
+				structField.Ident().GetText(), name, err,
+			)
+		}
+		fields = append(fields, tast.Field(fieldType, structField.Ident().GetText()))
+	}
+	return fields, nil
+}
+
+// resolveTypedef registers the pending typedef alias, first resolving the type
+// it aliases. An alias whose aliased type refers back to it is reported.
+func (tc *TypeChecker) resolveTypedef(alias string) error {
+	d, pending := tc.pendingTypedefs[alias]
+	if !pending {
+		return nil
+	}
+	line, col := d.GetStart().GetLine(), d.GetStart().GetColumn()
+	if _, resolving := tc.resolvingTypedefs[alias]; resolving {
+		return fmt.Errorf(
+			"typedef '%s' aliases itself at %d:%d", alias, line, col,
+		)
+	}
+
+	tc.resolvingTypedefs[alias] = struct{}{}
+	aliasedType, err := tc.toTastType(d.Type_())
+	delete(tc.resolvingTypedefs, alias)
+	if err != nil {
+		return err
+	}
+	if aliasedType == tast.Void {
+		return fmt.Errorf(
+			"typedef '%s' cannot alias void at %d:%d", alias, line, col,
+		)
+	}
+
+	delete(tc.pendingTypedefs, alias)
+	tc.env.ExtendTypedef(alias, tast.Typedef(alias, aliasedType))
+	return nil
+}
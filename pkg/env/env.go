@@ -15,10 +15,11 @@ type Signature[T any] struct {
 
 type Environment[T any] struct {
 	contexts      []Context[T]
-	signatures    map[string]Signature[T]
+	signatures    map[string][]Signature[T] // overloads per function name
 	currentReturn T
 	structs       map[string]T
 	typedefs      map[string]T
+	enums         map[string]T
 }
 
 func (e *Environment[T]) LookupVar(varName string) (T, bool) {
@@ -32,6 +33,24 @@ func (e *Environment[T]) LookupVar(varName string) (T, bool) {
 	return zeroValue, false
 }
 
+// LookupVarDepth looks up varName like LookupVar, but also returns the index of
+// the context the variable was found in, where 0 is the outermost context.
+func (e *Environment[T]) LookupVarDepth(varName string) (T, int, bool) {
+	for i := len(e.contexts) - 1; i >= 0; i-- {
+		if value, exists := e.contexts[i][varName]; exists {
+			return value, i, true
+		}
+	}
+
+	var zeroValue T
+	return zeroValue, -1, false
+}
+
+// Depth returns the number of contexts currently entered.
+func (e *Environment[T]) Depth() int {
+	return len(e.contexts)
+}
+
 func (e *Environment[T]) AssignVar(varName string, value T) bool {
 	for i := len(e.contexts) - 1; i >= 0; i-- {
 		if value, exists := e.contexts[i][varName]; exists {
@@ -117,23 +136,54 @@ func (e *Environment[T]) ExtendFunc(
 	if _, ok := e.signatures[funcName]; ok {
 		return false
 	}
-	e.signatures[funcName] = Signature[T]{
+	return e.ExtendOverload(funcName, paramNames, params, returns)
+}
+
+// ExtendOverload adds a signature for funcName even if the function already
+// has signatures. Checking that the overloads are distinguishable is left to
+// the caller.
+func (e *Environment[T]) ExtendOverload(
+	funcName string,
+	paramNames []string,
+	params map[string]T,
+	returns T,
+) bool {
+	e.signatures[funcName] = append(e.signatures[funcName], Signature[T]{
 		ParamNames: paramNames,
 		Params:     params,
 		Returns:    returns,
+	})
+	return true
+}
+
+// ImportFunc adds the signatures of funcName defined in another module, and
+// fails if the function is already defined.
+func (e *Environment[T]) ImportFunc(
+	funcName string,
+	signatures []Signature[T],
+) bool {
+	if _, ok := e.signatures[funcName]; ok {
+		return false
 	}
+	e.signatures[funcName] = signatures
 	return true
 }
 
+// LookupFunc returns the first signature of funcName.
 func (e *Environment[T]) LookupFunc(funcName string) (Signature[T], bool) {
-	signatue, exists := e.signatures[funcName]
+	signatures, exists := e.signatures[funcName]
 	if exists {
-		return signatue, true
+		return signatures[0], true
 	}
 	var zeroSignature Signature[T]
 	return zeroSignature, false
 }
 
+// LookupFuncs returns all overloaded signatures of funcName.
+func (e *Environment[T]) LookupFuncs(funcName string) []Signature[T] {
+	return e.signatures[funcName]
+}
+
 func (e *Environment[T]) ExtendStruct(name string, typ T) bool {
 	if _, exists := e.structs[name]; exists {
 		return false
@@ -150,6 +200,19 @@ func (e *Environment[T]) ExtendTypedef(name string, typ T) bool {
 	return true
 }
 
+func (e *Environment[T]) ExtendEnum(name string, typ T) bool {
+	if _, exists := e.enums[name]; exists {
+		return false
+	}
+	e.enums[name] = typ
+	return true
+}
+
+func (e *Environment[T]) LookupEnum(name string) (T, bool) {
+	typ, exists := e.enums[name]
+	return typ, exists
+}
+
 func (e *Environment[T]) LookupStruct(name string) (T, bool) {
 	typ, exists := e.structs[name]
 	return typ, exists
@@ -171,10 +234,11 @@ func NewEnvironment[T any]() *Environment[T] {
 	var zeroValue T
 	environment := Environment[T]{
 		contexts:      make([]Context[T], 0),
-		signatures:    make(map[string]Signature[T]),
+		signatures:    make(map[string][]Signature[T]),
 		currentReturn: zeroValue,
 		structs:       make(map[string]T),
 		typedefs:      make(map[string]T),
+		enums:         make(map[string]T),
 	}
 	return &environment
 }
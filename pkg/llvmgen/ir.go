@@ -0,0 +1,333 @@
+package llvmgen
+
+import "slices"
+
+// Module is an LLVM module held in memory, so that the generated code can be
+// inspected and transformed before it is printed.
+type Module struct {
+	Types   []*StructType
+	Globals []GlobalDef
+	Funcs   []*Function
+}
+
+// GlobalDef is a module level declaration, such as a global constant or the
+// declaration of an external function.
+type GlobalDef interface {
+	String() string
+}
+
+// FuncDecl declares an external function, with optional function attributes
+// such as returns_twice or noreturn.
+type FuncDecl struct {
+	Returns Type
+	Name    Global
+	Params  []Type
+	Attrs   []string
+}
+
+// GlobalConst defines an internal constant global.
+type GlobalConst struct {
+	Name  Global
+	Type  Type
+	Value Value
+}
+
+// Function is a function definition made up of basic blocks, whose registers
+// are linked to the instructions defining and using them. Attrs holds its
+// function attributes, such as noinline.
+type Function struct {
+	Returns Type
+	Name    Global
+	Params  []FuncParam
+	Attrs   []string
+	Blocks  []*BasicBlock
+
+	defs map[Reg]*Instruction
+	uses map[Reg][]*Instruction
+}
+
+// BasicBlock is a labeled sequence of instructions ending in a terminator.
+type BasicBlock struct {
+	Name   string
+	Instrs []*Instruction
+	Parent *Function
+}
+
+// Opcode identifies the operation of an Instruction.
+type Opcode int
+
+const (
+	OpAlloca Opcode = iota
+	OpLoad
+	OpStore
+	OpGetElementPtr
+	OpCall
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpRem
+	OpXor
+	OpCmp
+	OpBitcast
+	OpPtrToInt
+	OpZExt
+	OpSIToFP
+	OpInsertValue
+	OpExtractValue
+	OpPhi
+	OpBr
+	OpCondBr
+	OpSwitch
+	OpRet
+	OpUnreachable
+	OpComment
+)
+
+// CmpPred is the predicate of a comparison, which is signed for integers and
+// ordered for doubles.
+type CmpPred int
+
+const (
+	PredLt CmpPred = iota
+	PredLe
+	PredGt
+	PredGe
+	PredEq
+	PredNe
+)
+
+// Instruction is a single LLVM instruction. Operands holds the values it
+// uses, each of the type at the same index of Types. Type is the type of the
+// result, except for allocas and getelementptrs where it is the allocated or
+// indexed element type, and stores where it is void. For calls, the first
+// operand is the callee. For phis, Labels holds the block each operand comes
+// from, and for terminators the successor blocks, where the first label of a
+// switch is its default.
+type Instruction struct {
+	Op       Opcode
+	Des      Reg
+	Type     Type
+	Operands []Value
+	Types    []Type
+	Labels   []string
+	Pred     CmpPred
+	Index    int    // element index of insertvalue and extractvalue
+	Text     string // text of comments
+	Block    *BasicBlock
+}
+
+// IsTerminator reports whether inst ends a basic block.
+func (inst *Instruction) IsTerminator() bool {
+	switch inst.Op {
+	case OpBr, OpCondBr, OpSwitch, OpRet, OpUnreachable:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewFunction creates an empty function definition.
+func NewFunction(returns Type, name Global, params ...FuncParam) *Function {
+	return &Function{
+		Returns: returns,
+		Name:    name,
+		Params:  params,
+		defs:    make(map[Reg]*Instruction),
+		uses:    make(map[Reg][]*Instruction),
+	}
+}
+
+// AddBlock appends a new basic block with the given name to f.
+func (f *Function) AddBlock(name string) *BasicBlock {
+	block := &BasicBlock{Name: name, Parent: f}
+	f.Blocks = append(f.Blocks, block)
+	return block
+}
+
+// InsertBlockAfter inserts a new basic block with the given name into f right
+// after the block after.
+func (f *Function) InsertBlockAfter(after *BasicBlock, name string) *BasicBlock {
+	block := &BasicBlock{Name: name, Parent: f}
+	idx := slices.Index(f.Blocks, after) + 1
+	f.Blocks = slices.Insert(f.Blocks, idx, block)
+	return block
+}
+
+// Block returns the basic block of f with the given name, or nil.
+func (f *Function) Block(name string) *BasicBlock {
+	for _, block := range f.Blocks {
+		if block.Name == name {
+			return block
+		}
+	}
+	return nil
+}
+
+// RemoveBlock removes block and its instructions from f.
+func (f *Function) RemoveBlock(block *BasicBlock) {
+	for _, inst := range block.Instrs {
+		f.unlink(inst)
+	}
+	block.Instrs = nil
+	f.Blocks = slices.DeleteFunc(f.Blocks, func(b *BasicBlock) bool {
+		return b == block
+	})
+}
+
+// Def returns the instruction defining reg, or nil if reg is a parameter or
+// not defined in f.
+func (f *Function) Def(reg Reg) *Instruction {
+	return f.defs[reg]
+}
+
+// Uses returns the instructions using reg as an operand.
+func (f *Function) Uses(reg Reg) []*Instruction {
+	return f.uses[reg]
+}
+
+// ReplaceAllUses replaces every use of reg as an operand with value.
+func (f *Function) ReplaceAllUses(reg Reg, value Value) {
+	users := f.uses[reg]
+	delete(f.uses, reg)
+	for _, user := range users {
+		for i, operand := range user.Operands {
+			if r, ok := operand.(Reg); ok && r == reg {
+				user.Operands[i] = value
+			}
+		}
+		if r, ok := value.(Reg); ok && !slices.Contains(f.uses[r], user) {
+			f.uses[r] = append(f.uses[r], user)
+		}
+	}
+}
+
+// SetOperand replaces the operand of inst at idx with value.
+func (f *Function) SetOperand(inst *Instruction, idx int, value Value) {
+	f.dropUses(inst)
+	inst.Operands[idx] = value
+	f.addUses(inst)
+}
+
+// AddIncoming adds to phi the value it takes when reached from the block
+// named label.
+func (f *Function) AddIncoming(phi *Instruction, value Value, label string) {
+	phi.Operands = append(phi.Operands, value)
+	phi.Types = append(phi.Types, phi.Type)
+	phi.Labels = append(phi.Labels, label)
+	f.addUses(phi)
+}
+
+// Preds returns the blocks of f branching to block.
+func (f *Function) Preds(block *BasicBlock) []*BasicBlock {
+	var preds []*BasicBlock
+	for _, b := range f.Blocks {
+		if slices.Contains(b.Succs(), block.Name) {
+			preds = append(preds, b)
+		}
+	}
+	return preds
+}
+
+// HoistAllocas moves the allocas of f outside of its entry block to the start
+// of the entry block, so that they allocate once per call even inside loops.
+func (f *Function) HoistAllocas() {
+	if len(f.Blocks) == 0 {
+		return
+	}
+	entry := f.Blocks[0]
+	hoisted := 0
+	for _, block := range f.Blocks[1:] {
+		for _, inst := range slices.Clone(block.Instrs) {
+			if inst.Op == OpAlloca {
+				block.Remove(inst)
+				entry.InsertBefore(hoisted, inst)
+				hoisted++
+			}
+		}
+	}
+}
+
+// link records the register defined and the registers used by inst.
+func (f *Function) link(inst *Instruction) {
+	if inst.Des != "" {
+		f.defs[inst.Des] = inst
+	}
+	f.addUses(inst)
+}
+
+// unlink removes the register defined and the uses of inst from f.
+func (f *Function) unlink(inst *Instruction) {
+	if inst.Des != "" && f.defs[inst.Des] == inst {
+		delete(f.defs, inst.Des)
+	}
+	f.dropUses(inst)
+}
+
+func (f *Function) addUses(inst *Instruction) {
+	for _, operand := range inst.Operands {
+		if reg, ok := operand.(Reg); ok && !slices.Contains(f.uses[reg], inst) {
+			f.uses[reg] = append(f.uses[reg], inst)
+		}
+	}
+}
+
+func (f *Function) dropUses(inst *Instruction) {
+	for _, operand := range inst.Operands {
+		reg, ok := operand.(Reg)
+		if !ok {
+			continue
+		}
+		f.uses[reg] = slices.DeleteFunc(f.uses[reg], func(user *Instruction) bool {
+			return user == inst
+		})
+		if len(f.uses[reg]) == 0 {
+			delete(f.uses, reg)
+		}
+	}
+}
+
+// Append adds inst to the end of b.
+func (b *BasicBlock) Append(inst *Instruction) {
+	inst.Block = b
+	b.Instrs = append(b.Instrs, inst)
+	b.Parent.link(inst)
+}
+
+// InsertBefore adds inst to b before the instruction at idx.
+func (b *BasicBlock) InsertBefore(idx int, inst *Instruction) {
+	inst.Block = b
+	b.Instrs = slices.Insert(b.Instrs, idx, inst)
+	b.Parent.link(inst)
+}
+
+// Remove removes inst from b.
+func (b *BasicBlock) Remove(inst *Instruction) {
+	b.Instrs = slices.DeleteFunc(b.Instrs, func(i *Instruction) bool {
+		return i == inst
+	})
+	b.Parent.unlink(inst)
+	inst.Block = nil
+}
+
+// Terminator returns the instruction ending b, or nil if b is not yet
+// terminated.
+func (b *BasicBlock) Terminator() *Instruction {
+	if len(b.Instrs) == 0 {
+		return nil
+	}
+	last := b.Instrs[len(b.Instrs)-1]
+	if !last.IsTerminator() {
+		return nil
+	}
+	return last
+}
+
+// Succs returns the names of the blocks b branches to.
+func (b *BasicBlock) Succs() []string {
+	term := b.Terminator()
+	if term == nil {
+		return nil
+	}
+	return term.Labels
+}
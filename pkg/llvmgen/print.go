@@ -0,0 +1,264 @@
+package llvmgen
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteTo prints m as textual LLVM IR to w.
+func (m *Module) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+
+	// type definitions come first, so that global constants can refer to
+	// named struct types
+	for _, structType := range m.Types {
+		sb.WriteString(typeDefinition(structType))
+	}
+	sb.WriteString("\n")
+	for _, global := range m.Globals {
+		sb.WriteString(global.String())
+		sb.WriteString("\n")
+	}
+	for _, f := range m.Funcs {
+		sb.WriteString("\n")
+		sb.WriteString(f.String())
+	}
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+func (m *Module) String() string {
+	var sb strings.Builder
+	m.WriteTo(&sb)
+	return sb.String()
+}
+
+func typeDefinition(structType *StructType) string {
+	var fieldStrs []string
+	for _, f := range structType.Fields {
+		fieldStrs = append(fieldStrs, f.String())
+	}
+	return fmt.Sprintf(
+		"%%%s = type { %s }\n",
+		structType.Name,
+		strings.Join(fieldStrs, ", "),
+	)
+}
+
+func (d *FuncDecl) String() string {
+	var inputTypes []string
+	for _, param := range d.Params {
+		inputTypes = append(inputTypes, param.String())
+	}
+	decl := fmt.Sprintf(
+		"declare %s %s(%s)",
+		d.Returns.String(), d.Name.String(), strings.Join(inputTypes, ", "),
+	)
+	for _, attr := range d.Attrs {
+		decl += " " + attr
+	}
+	return decl
+}
+
+func (c *GlobalConst) String() string {
+	return fmt.Sprintf(
+		"%s = internal constant %s %s",
+		c.Name.String(), c.Type.String(), c.Value.String(),
+	)
+}
+
+func (f *Function) String() string {
+	var sb strings.Builder
+	var params []string
+	for _, param := range f.Params {
+		params = append(params, param.Type.String()+" "+param.Name.String())
+	}
+	var attrs string
+	for _, attr := range f.Attrs {
+		attrs += " " + attr
+	}
+	if attrs != "" {
+		attrs += " "
+	}
+	sb.WriteString(fmt.Sprintf(
+		"define %s %s(%s)%s{\n",
+		f.Returns.String(), f.Name.String(), strings.Join(params, ", "), attrs,
+	))
+	for _, block := range f.Blocks {
+		sb.WriteString(block.String())
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func (b *BasicBlock) String() string {
+	var sb strings.Builder
+	if b.Name != "" {
+		sb.WriteString(fmt.Sprintf("\n%s:\n", b.Name))
+	}
+	for _, inst := range b.Instrs {
+		sb.WriteString("\t" + inst.String() + "\n")
+	}
+	return sb.String()
+}
+
+func (inst *Instruction) String() string {
+	switch inst.Op {
+	case OpAlloca:
+		return fmt.Sprintf("%s = alloca %s", inst.Des, inst.Type)
+	case OpLoad:
+		return fmt.Sprintf(
+			"%s = load %s, %s %s, align %d",
+			inst.Des, inst.Type, inst.Types[0], inst.Operands[0],
+			inst.Type.alignment(),
+		)
+	case OpStore:
+		return fmt.Sprintf("store %s", inst.typedOperands())
+	case OpGetElementPtr:
+		var indices []string
+		for _, idx := range inst.Operands[1:] {
+			indices = append(indices, "i32 "+idx.String())
+		}
+		return fmt.Sprintf(
+			"%s = getelementptr %s, %s %s, %s",
+			inst.Des, inst.Type, inst.Types[0], inst.Operands[0],
+			strings.Join(indices, ", "),
+		)
+	case OpCall:
+		var args []string
+		for i, arg := range inst.Operands[1:] {
+			args = append(args, inst.Types[i+1].String()+" "+arg.String())
+		}
+		call := fmt.Sprintf(
+			"call %s %s(%s)",
+			inst.Type, inst.Operands[0], strings.Join(args, ", "),
+		)
+		if inst.Type == Void {
+			return call
+		}
+		return inst.Des.String() + " = " + call
+	case OpAdd, OpSub, OpMul, OpDiv, OpRem:
+		return fmt.Sprintf(
+			"%s = %s %s %s, %s",
+			inst.Des, arithName(inst.Op, inst.Type), inst.Type,
+			inst.Operands[0], inst.Operands[1],
+		)
+	case OpXor:
+		return fmt.Sprintf(
+			"%s = xor %s %s, %s",
+			inst.Des, inst.Type, inst.Operands[0], inst.Operands[1],
+		)
+	case OpCmp:
+		return fmt.Sprintf(
+			"%s = %s %s %s, %s",
+			inst.Des, cmpName(inst.Pred, inst.Types[0]), inst.Types[0],
+			inst.Operands[0], inst.Operands[1],
+		)
+	case OpBitcast, OpPtrToInt, OpZExt, OpSIToFP:
+		return fmt.Sprintf(
+			"%s = %s %s %s to %s",
+			inst.Des, castName(inst.Op), inst.Types[0], inst.Operands[0],
+			inst.Type,
+		)
+	case OpInsertValue:
+		return fmt.Sprintf(
+			"%s = insertvalue %s, %d", inst.Des, inst.typedOperands(), inst.Index,
+		)
+	case OpExtractValue:
+		return fmt.Sprintf(
+			"%s = extractvalue %s %s, %d",
+			inst.Des, inst.Types[0], inst.Operands[0], inst.Index,
+		)
+	case OpPhi:
+		var froms []string
+		for i, val := range inst.Operands {
+			froms = append(froms,
+				fmt.Sprintf("[ %s, %%%s ]", val.String(), inst.Labels[i]),
+			)
+		}
+		return fmt.Sprintf(
+			"%s = phi %s %s", inst.Des, inst.Type, strings.Join(froms, ", "),
+		)
+	case OpBr:
+		return fmt.Sprintf("br label %%%s", inst.Labels[0])
+	case OpCondBr:
+		return fmt.Sprintf(
+			"br i1 %s, label %%%s, label %%%s",
+			inst.Operands[0], inst.Labels[0], inst.Labels[1],
+		)
+	case OpSwitch:
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf(
+			"switch %s %s, label %%%s [\n",
+			inst.Types[0], inst.Operands[0], inst.Labels[0],
+		))
+		for i, val := range inst.Operands[1:] {
+			sb.WriteString(fmt.Sprintf(
+				"\t\t%s %s, label %%%s\n", inst.Types[i+1], val, inst.Labels[i+1],
+			))
+		}
+		sb.WriteString("\t]")
+		return sb.String()
+	case OpRet:
+		if len(inst.Operands) == 0 {
+			return "ret void"
+		}
+		return fmt.Sprintf("ret %s %s", inst.Type, inst.Operands[0])
+	case OpUnreachable:
+		return "unreachable"
+	case OpComment:
+		return "; " + inst.Text
+	default:
+		panic(fmt.Sprintf("unsupported opcode: %d", inst.Op))
+	}
+}
+
+// typedOperands formats the operands of inst each preceded by its type.
+func (inst *Instruction) typedOperands() string {
+	operands := make([]string, len(inst.Operands))
+	for i, operand := range inst.Operands {
+		operands[i] = inst.Types[i].String() + " " + operand.String()
+	}
+	return strings.Join(operands, ", ")
+}
+
+func arithName(op Opcode, typ Type) string {
+	float := typ == Double
+	switch op {
+	case OpAdd:
+		return map[bool]string{false: "add", true: "fadd"}[float]
+	case OpSub:
+		return map[bool]string{false: "sub", true: "fsub"}[float]
+	case OpMul:
+		return map[bool]string{false: "mul", true: "fmul"}[float]
+	case OpDiv:
+		return map[bool]string{false: "sdiv", true: "fdiv"}[float]
+	case OpRem:
+		return map[bool]string{false: "srem", true: "frem"}[float]
+	default:
+		panic(fmt.Sprintf("not an arithmetic opcode: %d", op))
+	}
+}
+
+func cmpName(pred CmpPred, typ Type) string {
+	if typ == Double {
+		return "fcmp " + [...]string{"olt", "ole", "ogt", "oge", "oeq", "one"}[pred]
+	}
+	return "icmp " + [...]string{"slt", "sle", "sgt", "sge", "eq", "ne"}[pred]
+}
+
+func castName(op Opcode) string {
+	switch op {
+	case OpBitcast:
+		return "bitcast"
+	case OpPtrToInt:
+		return "ptrtoint"
+	case OpZExt:
+		return "zext"
+	case OpSIToFP:
+		return "sitofp"
+	default:
+		panic(fmt.Sprintf("not a cast opcode: %d", op))
+	}
+}
@@ -0,0 +1,183 @@
+package llvmgen
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// buildModule writes a module using every kind of instruction, global and
+// type definition the printer supports.
+func buildModule(t *testing.T) *Module {
+	t.Helper()
+	var out bytes.Buffer
+	w := NewWriter(&out)
+	pair := StructDef("pair", I32, Double.Ptr())
+	check(t, w.Type(pair))
+	check(t, w.Declare(Void, "printInt", I32))
+	check(t, w.DeclareAttrs(Void, "abort", []string{"noreturn"}))
+	check(t, w.InternalConstant("s_0", Array(I8, 3), LitString("hi")))
+	check(t, w.InternalConstant(
+		"pair.zero", pair, Struct(pair, LitInt(0), Null()),
+	))
+
+	check(t, w.StartDefineAttrs(
+		I32, "f", []string{"noinline"}, Param(I32, "n"), Param(Double, "x"),
+	))
+	check(t, w.Label("entry"))
+	check(t, w.Alloca("p", pair))
+	check(t, w.GetElementPtr("len", pair, pair.Ptr(), Reg("p"), LitInt(0), LitInt(0)))
+	check(t, w.Store(I32, Reg("n"), I32.Ptr(), "len"))
+	check(t, w.Load("a", I32, I32.Ptr(), "len"))
+	check(t, w.Add("b", I32, Reg("a"), LitInt(1)))
+	check(t, w.Mul("c", Double, Reg("x"), LitDouble(2)))
+	check(t, w.Rem("d", I32, Reg("b"), LitInt(3)))
+	check(t, w.CmpLt("lt", I32, Reg("d"), LitInt(2)))
+	check(t, w.CmpGe("ge", Double, Reg("c"), LitDouble(0.5)))
+	check(t, w.Xor("not", I1, Reg("lt"), LitBool(true)))
+	check(t, w.SIToFP("fd", I32, Reg("d"), Double))
+	check(t, w.ZExt("wide", I1, Reg("ge"), I32))
+	check(t, w.Bitcast("raw", pair.Ptr(), Reg("p"), I8.Ptr()))
+	check(t, w.PtrToInt("addr", I8.Ptr(), I64, "raw"))
+	tuple := LiteralStruct(I32, Double)
+	check(t, w.InsertValue("t0", tuple, Undef(), I32, Reg("d"), 0))
+	check(t, w.InsertValue("t1", tuple, Reg("t0"), Double, Reg("fd"), 1))
+	check(t, w.ExtractValue("e", tuple, Reg("t1"), 0))
+	check(t, w.Call("", Void, Global("printInt"), Arg(I32, Reg("e"))))
+	check(t, w.Comment("choose"))
+	check(t, w.Switch(I32, Reg("e"), "other", Case(LitInt(0), "zero")))
+	check(t, w.Label("zero"))
+	check(t, w.BrIf(I1, Reg("not"), "other", "end"))
+	check(t, w.Label("other"))
+	check(t, w.Br("end"))
+	check(t, w.Label("end"))
+	check(t, w.Phi("r", I32, Phi(LitInt(0), "zero"), Phi(Reg("wide"), "other")))
+	check(t, w.Ret(I32, Reg("r")))
+	check(t, w.EndDefine())
+	return w.Module()
+}
+
+func check(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+const wantModule = `%pair = type { i32, double* }
+
+declare void @printInt(i32)
+declare void @abort() noreturn
+@s_0 = internal constant [3 x i8] c"hi\00"
+@pair.zero = internal constant %pair { i32 0, double* null }
+
+define i32 @f(i32 %n, double %x) noinline {
+
+entry:
+	%p = alloca %pair
+	%len = getelementptr %pair, %pair* %p, i32 0, i32 0
+	store i32 %n, i32* %len
+	%a = load i32, i32* %len, align 4
+	%b = add i32 %a, 1
+	%c = fmul double %x, 2.0
+	%d = srem i32 %b, 3
+	%lt = icmp slt i32 %d, 2
+	%ge = fcmp oge double %c, 0.5
+	%not = xor i1 %lt, true
+	%fd = sitofp i32 %d to double
+	%wide = zext i1 %ge to i32
+	%raw = bitcast %pair* %p to i8*
+	%addr = ptrtoint i8* %raw to i64
+	%t0 = insertvalue { i32, double } undef, i32 %d, 0
+	%t1 = insertvalue { i32, double } %t0, double %fd, 1
+	%e = extractvalue { i32, double } %t1, 0
+	call void @printInt(i32 %e)
+	; choose
+	switch i32 %e, label %other [
+		i32 0, label %zero
+	]
+
+zero:
+	br i1 %not, label %other, label %end
+
+other:
+	br label %end
+
+end:
+	%r = phi i32 [ 0, %zero ], [ %wide, %other ]
+	ret i32 %r
+}
+`
+
+func TestPrintModule(t *testing.T) {
+	m := buildModule(t)
+	if got := m.String(); got != wantModule {
+		t.Errorf("printed module differs\ngot:\n%s\nwant:\n%s", got, wantModule)
+	}
+	if err := m.Verify(); err != nil {
+		t.Errorf("built module does not verify: %v", err)
+	}
+}
+
+// TestPrintRoundTrip checks that LLVM reads back the printed module, and
+// that disassembling it gives the same functions.
+func TestPrintRoundTrip(t *testing.T) {
+	for _, tool := range []string{"llvm-as", "llvm-dis"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s not found", tool)
+		}
+	}
+	m := buildModule(t)
+	as := exec.Command("llvm-as", "-o", "-")
+	as.Stdin = strings.NewReader(m.String())
+	var stderr bytes.Buffer
+	as.Stderr = &stderr
+	bitcode, err := as.Output()
+	if err != nil {
+		t.Fatalf("llvm-as rejected the printed module: %v\n%s", err, stderr.String())
+	}
+	dis := exec.Command("llvm-dis", "-o", "-")
+	dis.Stdin = bytes.NewReader(bitcode)
+	text, err := dis.Output()
+	if err != nil {
+		t.Fatalf("llvm-dis failed: %v", err)
+	}
+	for _, want := range []string{
+		"%pair = type { i32, double* }",
+		"declare void @printInt(i32)",
+		"define i32 @f(i32 %n, double %x)",
+		"%r = phi i32 [ 0, %zero ], [ %wide, %other ]",
+	} {
+		if !strings.Contains(string(text), want) {
+			t.Errorf("disassembled module lacks %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestWriterDeadBlocks(t *testing.T) {
+	w := NewWriter(&bytes.Buffer{})
+	check(t, w.StartDefine(I32, "g"))
+	check(t, w.Ret(I32, LitInt(0)))
+	// code after a terminator goes to a block without predecessors, which is
+	// terminated when the next block starts
+	check(t, w.Call("", Void, Global("printInt"), Arg(I32, LitInt(1))))
+	check(t, w.Label("next"))
+	check(t, w.Ret(I32, LitInt(1)))
+	check(t, w.EndDefine())
+
+	f := w.Module().Funcs[0]
+	var names []string
+	for _, block := range f.Blocks {
+		names = append(names, block.Name)
+		if block.Terminator() == nil {
+			t.Errorf("block '%s' has no terminator", block.Name)
+		}
+	}
+	if got := strings.Join(names, ","); got != ",dead0,next" {
+		t.Errorf("got blocks %s, want ,dead0,next", got)
+	}
+	if got := f.Blocks[1].Terminator().Op; got != OpUnreachable {
+		t.Errorf("dead block ends in opcode %d, want unreachable", got)
+	}
+}
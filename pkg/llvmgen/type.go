@@ -2,6 +2,7 @@ package llvmgen
 
 import (
 	"fmt"
+	"strings"
 )
 
 type Type interface {
@@ -137,6 +138,9 @@ func (t ArrayType) Ptr() PtrType {
 type StructType struct {
 	Name   string
 	Fields []Type
+	// Array marks the structs holding the length and data pointer of arrays,
+	// whose fields are only stored to when the array is allocated.
+	Array bool
 }
 
 func StructDef(name string, fields ...Type) *StructType {
@@ -159,7 +163,7 @@ func (t *StructType) alignment() int {
 }
 
 func (t *StructType) ZeroValue() Value {
-	panic("zero value for struct type not yet implemented")
+	return ZeroInitializer()
 }
 
 func (t *StructType) Size() int {
@@ -170,6 +174,41 @@ func (t *StructType) Ptr() PtrType {
 	return ptr(t)
 }
 
+// LiteralStructType is an unnamed struct type, identified by its fields.
+type LiteralStructType struct {
+	Fields []Type
+}
+
+func LiteralStruct(fields ...Type) *LiteralStructType {
+	return &LiteralStructType{Fields: fields}
+}
+
+func (t *LiteralStructType) String() string {
+	fieldStrs := make([]string, len(t.Fields))
+	for i, field := range t.Fields {
+		fieldStrs[i] = field.String()
+	}
+	return "{ " + strings.Join(fieldStrs, ", ") + " }"
+}
+
+func (t *LiteralStructType) alignment() int {
+	maxAlign := 1
+	for _, f := range t.Fields {
+		if a := f.alignment(); a > maxAlign {
+			maxAlign = a
+		}
+	}
+	return maxAlign
+}
+
+func (t *LiteralStructType) ZeroValue() Value {
+	return ZeroInitializer()
+}
+
+func (t *LiteralStructType) Ptr() PtrType {
+	return ptr(t)
+}
+
 type PtrType struct {
 	Elem Type
 }
@@ -194,12 +233,63 @@ func (p PtrType) Ptr() PtrType {
 	return ptr(p)
 }
 
+type FuncType struct {
+	Returns Type
+	Params  []Type
+}
+
+func Func(returns Type, params ...Type) FuncType {
+	return FuncType{Returns: returns, Params: params}
+}
+
+func (t FuncType) String() string {
+	paramStrs := make([]string, len(t.Params))
+	for i, param := range t.Params {
+		paramStrs[i] = param.String()
+	}
+	return fmt.Sprintf(
+		"%s (%s)", t.Returns.String(), strings.Join(paramStrs, ", "),
+	)
+}
+
+func (t FuncType) alignment() int {
+	panic("function type does not have alignment")
+}
+
+func (t FuncType) ZeroValue() Value {
+	panic("function type does not have zero value")
+}
+
+func (t FuncType) Ptr() PtrType {
+	return ptr(t)
+}
+
 func ptr(elem Type) PtrType {
 	return PtrType{Elem: elem}
 }
 
+// elementType returns the type of the element at idx of the aggregate type
+// agg, or nil if agg is not an aggregate.
+func elementType(agg Type, idx int) Type {
+	switch t := agg.(type) {
+	case *StructType:
+		return t.Fields[idx]
+	case *LiteralStructType:
+		return t.Fields[idx]
+	case ArrayType:
+		if len(t.dimensions) == 1 {
+			return t.typ
+		}
+		return Array(t.typ, t.dimensions[1:]...)
+	default:
+		return nil
+	}
+}
+
 // compile time check for implementation
 var _ Type = PrimitiveType(0)
 var _ Type = ArrayType{}
 var _ Type = &StructType{}
 var _ Type = PtrType{}
+var _ Type = FuncType{}
+var _ Type = &LiteralStructType{}
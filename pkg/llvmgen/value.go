@@ -73,6 +73,68 @@ func (s StructValue) String() string {
 	return "{ " + strings.Join(parts, ", ") + " }"
 }
 
+// ConstBitcast is a constant bitcast expression, usable in global initializers.
+type ConstBitcast struct {
+	Value    Value
+	FromType Type
+	ToType   Type
+}
+
+func Bitcast(value Value, fromType Type, toType Type) ConstBitcast {
+	return ConstBitcast{Value: value, FromType: fromType, ToType: toType}
+}
+
+func (c ConstBitcast) String() string {
+	return fmt.Sprintf(
+		"bitcast (%s %s to %s)",
+		c.FromType.String(), c.Value.String(), c.ToType.String(),
+	)
+}
+
+type ArrayValue struct {
+	elemType Type
+	elems    []Value
+}
+
+// ArrayOf returns a constant array of the given elements, usable in global
+// initializers.
+func ArrayOf(elemType Type, elems ...Value) *ArrayValue {
+	return &ArrayValue{elemType: elemType, elems: elems}
+}
+
+func (a ArrayValue) String() string {
+	var parts []string
+	for _, v := range a.elems {
+		parts = append(parts, a.elemType.String()+" "+v.String())
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// ConstGEP is a constant getelementptr expression, usable in global
+// initializers.
+type ConstGEP struct {
+	ElemType Type
+	PtrType  Type
+	From     Value
+	Indices  []Value
+}
+
+func GEP(elemType Type, ptrType Type, from Value, idx ...Value) ConstGEP {
+	return ConstGEP{ElemType: elemType, PtrType: ptrType, From: from, Indices: idx}
+}
+
+func (c ConstGEP) String() string {
+	var indices []string
+	for _, i := range c.Indices {
+		indices = append(indices, "i32 "+i.String())
+	}
+	return fmt.Sprintf(
+		"getelementptr (%s, %s %s, %s)",
+		c.ElemType.String(), c.PtrType.String(), c.From.String(),
+		strings.Join(indices, ", "),
+	)
+}
+
 type NullValue struct{}
 
 func (n NullValue) String() string {
@@ -82,3 +144,23 @@ func (n NullValue) String() string {
 func Null() NullValue {
 	return NullValue{}
 }
+
+type UndefValue struct{}
+
+func (u UndefValue) String() string {
+	return "undef"
+}
+
+func Undef() UndefValue {
+	return UndefValue{}
+}
+
+type ZeroInitializerValue struct{}
+
+func (z ZeroInitializerValue) String() string {
+	return "zeroinitializer"
+}
+
+func ZeroInitializer() ZeroInitializerValue {
+	return ZeroInitializerValue{}
+}
@@ -0,0 +1,467 @@
+package llvmgen
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Verify checks that m is well formed, reporting the mistakes that would
+// otherwise only surface when llc or lli rejects the printed IR: every block
+// must end in exactly one terminator branching to blocks of its function,
+// phis must come first in their block and list exactly its predecessors,
+// every register must be defined once and dominate its uses, and the types
+// of operands must agree with the instructions using them.
+func (m *Module) Verify() error {
+	v := &verifier{globals: make(map[Global]Type)}
+	for _, global := range m.Globals {
+		switch g := global.(type) {
+		case *FuncDecl:
+			v.globals[g.Name] = Func(g.Returns, g.Params...).Ptr()
+		case *GlobalConst:
+			v.globals[g.Name] = g.Type.Ptr()
+		}
+	}
+	for _, f := range m.Funcs {
+		var params []Type
+		for _, param := range f.Params {
+			params = append(params, param.Type)
+		}
+		v.globals[f.Name] = Func(f.Returns, params...).Ptr()
+	}
+	for _, f := range m.Funcs {
+		v.function(f)
+	}
+	return errors.Join(v.errs...)
+}
+
+// verifier collects the mistakes found in a module. The types of globals and
+// of the registers of the function being verified are used to check the types
+// of operands.
+type verifier struct {
+	globals map[Global]Type
+	errs    []error
+
+	fn     *Function
+	blocks map[string]*BasicBlock
+	regs   map[Reg]Type
+	defs   map[Reg]*Instruction
+	dom    *dominators
+}
+
+func (v *verifier) errorf(
+	block *BasicBlock, inst *Instruction, format string, a ...any,
+) {
+	msg := fmt.Sprintf(format, a...)
+	where := fmt.Sprintf("function %s", v.fn.Name)
+	if block != nil {
+		where += fmt.Sprintf(", block '%s'", block.Name)
+	}
+	if inst != nil {
+		msg += fmt.Sprintf(" in '%s'", inst)
+	}
+	v.errs = append(v.errs, fmt.Errorf("verify: %s: %s", where, msg))
+}
+
+func (v *verifier) function(f *Function) {
+	v.fn = f
+	errs := len(v.errs)
+	v.blocks = make(map[string]*BasicBlock)
+	v.regs = make(map[Reg]Type)
+	v.defs = make(map[Reg]*Instruction)
+	if len(f.Blocks) == 0 {
+		v.errorf(nil, nil, "no basic blocks")
+		return
+	}
+	for _, param := range f.Params {
+		if _, ok := v.regs[param.Name]; ok {
+			v.errorf(nil, nil, "parameter %s defined twice", param.Name)
+		}
+		v.regs[param.Name] = param.Type
+	}
+	for i, block := range f.Blocks {
+		if block.Name == "" && i > 0 {
+			v.errorf(block, nil, "unnamed block after the entry block")
+		}
+		if _, ok := v.blocks[block.Name]; ok {
+			v.errorf(block, nil, "block defined twice")
+		}
+		v.blocks[block.Name] = block
+		for _, inst := range block.Instrs {
+			if inst.Des == "" {
+				continue
+			}
+			if _, ok := v.regs[inst.Des]; ok {
+				v.errorf(block, inst, "register %s defined twice", inst.Des)
+				continue
+			}
+			v.regs[inst.Des] = resultType(inst)
+			v.defs[inst.Des] = inst
+		}
+	}
+	if preds := f.Preds(f.Blocks[0]); len(preds) > 0 {
+		v.errorf(f.Blocks[0], nil, "entry block has predecessors")
+	}
+	for _, block := range f.Blocks {
+		v.terminator(block)
+	}
+	// dominance is only meaningful once every branch is known to be valid
+	if len(v.errs) == errs {
+		v.dom = newDominators(f, v.blocks)
+	}
+	for _, block := range f.Blocks {
+		v.phis(block)
+		for idx, inst := range block.Instrs {
+			v.operands(block, idx, inst)
+			v.types(block, inst)
+		}
+	}
+	v.dom = nil
+}
+
+// terminator checks that block ends in a terminator branching to blocks of
+// the function, with none before it. Comments are not instructions, and may
+// follow the terminator.
+func (v *verifier) terminator(block *BasicBlock) {
+	var term *Instruction
+	for _, inst := range block.Instrs {
+		if inst.Op == OpComment {
+			continue
+		}
+		if term != nil {
+			v.errorf(block, inst, "instruction after the terminator '%s'", term)
+			return
+		}
+		if inst.IsTerminator() {
+			term = inst
+		}
+	}
+	if term == nil {
+		v.errorf(block, nil, "block does not end in a terminator")
+		return
+	}
+	for _, label := range term.Labels {
+		if _, ok := v.blocks[label]; !ok || label == "" {
+			v.errorf(block, term, "branch to unknown block '%s'", label)
+		}
+	}
+}
+
+// phis checks that the phis of block come before its other instructions and
+// have one incoming value for each of its predecessors.
+func (v *verifier) phis(block *BasicBlock) {
+	var predNames []string
+	for _, pred := range v.fn.Preds(block) {
+		predNames = append(predNames, pred.Name)
+	}
+	seenOther := false
+	for _, inst := range block.Instrs {
+		if inst.Op != OpPhi {
+			if inst.Op != OpComment {
+				seenOther = true
+			}
+			continue
+		}
+		if seenOther {
+			v.errorf(block, inst, "phi after a non-phi instruction")
+		}
+		if len(inst.Labels) != len(inst.Operands) {
+			v.errorf(block, inst, "phi has %d values for %d blocks",
+				len(inst.Operands), len(inst.Labels))
+			continue
+		}
+		incoming := make(map[string]Value)
+		for i, label := range inst.Labels {
+			if !slices.Contains(predNames, label) {
+				v.errorf(block, inst, "phi lists '%s', which is no predecessor", label)
+			}
+			prev, ok := incoming[label]
+			if ok && prev.String() != inst.Operands[i].String() {
+				v.errorf(block, inst, "phi has different values for '%s'", label)
+			}
+			incoming[label] = inst.Operands[i]
+		}
+		for _, pred := range predNames {
+			if _, ok := incoming[pred]; !ok {
+				v.errorf(block, inst, "phi lacks a value for predecessor '%s'", pred)
+			}
+		}
+	}
+}
+
+// operands checks that the registers used by inst, at idx in block, are
+// defined before it on every path reaching it. The values of phis must be
+// defined at the end of the block they come from instead.
+func (v *verifier) operands(block *BasicBlock, idx int, inst *Instruction) {
+	for i, operand := range inst.Operands {
+		reg, ok := operand.(Reg)
+		if !ok {
+			continue
+		}
+		if _, ok := v.regs[reg]; !ok {
+			v.errorf(block, inst, "use of undefined register %s", reg)
+			continue
+		}
+		def := v.defs[reg]
+		if def == nil || v.dom == nil {
+			// parameters are defined on entry
+			continue
+		}
+		useBlock, useIdx := block, idx
+		if inst.Op == OpPhi && i < len(inst.Labels) {
+			useBlock = v.blocks[inst.Labels[i]]
+			if useBlock == nil {
+				continue
+			}
+			useIdx = len(useBlock.Instrs)
+		}
+		if !v.dom.reachable(useBlock) {
+			continue
+		}
+		if def.Block == useBlock {
+			if slices.Index(useBlock.Instrs, def) >= useIdx {
+				v.errorf(block, inst, "register %s used before its definition", reg)
+			}
+		} else if !v.dom.dominates(def.Block, useBlock) {
+			v.errorf(block, inst,
+				"register %s defined in '%s', which does not dominate its use",
+				reg, def.Block.Name)
+		}
+	}
+}
+
+// types checks that the operands of inst have the types inst gives them, and
+// that those types fit the operation.
+func (v *verifier) types(block *BasicBlock, inst *Instruction) {
+	if len(inst.Types) != len(inst.Operands) {
+		v.errorf(block, inst, "%d types for %d operands",
+			len(inst.Types), len(inst.Operands))
+		return
+	}
+	for i, operand := range inst.Operands {
+		typ := v.valueType(operand)
+		if typ != nil && !sameType(typ, inst.Types[i]) {
+			v.errorf(block, inst, "operand %s has type %s, but is used as %s",
+				operand, typ, inst.Types[i])
+		}
+	}
+	mismatch := func(what string, got, want Type) {
+		if !sameType(got, want) {
+			v.errorf(block, inst, "%s has type %s instead of %s", what, got, want)
+		}
+	}
+	switch inst.Op {
+	case OpLoad:
+		mismatch("loaded pointer", inst.Types[0], inst.Type.Ptr())
+	case OpStore:
+		mismatch("stored-to pointer", inst.Types[1], inst.Types[0].Ptr())
+	case OpGetElementPtr:
+		mismatch("indexed pointer", inst.Types[0], inst.Type.Ptr())
+		if resultType(inst) == nil {
+			v.errorf(block, inst, "invalid indices into %s", inst.Type)
+		}
+	case OpAdd, OpSub, OpMul, OpDiv, OpRem, OpXor:
+		mismatch("left operand", inst.Types[0], inst.Type)
+		mismatch("right operand", inst.Types[1], inst.Type)
+	case OpCmp:
+		mismatch("right operand", inst.Types[1], inst.Types[0])
+	case OpInsertValue:
+		if elem := aggregateElement(inst.Types[0], inst.Index); elem == nil {
+			v.errorf(block, inst, "invalid index into %s", inst.Types[0])
+		} else {
+			mismatch("inserted value", inst.Types[1], elem)
+		}
+	case OpExtractValue:
+		if aggregateElement(inst.Types[0], inst.Index) == nil {
+			v.errorf(block, inst, "invalid index into %s", inst.Types[0])
+		}
+	case OpPhi:
+		for i := range inst.Types {
+			mismatch("incoming value", inst.Types[i], inst.Type)
+		}
+	case OpCall:
+		mismatch("callee", inst.Types[0], Func(inst.Type, inst.Types[1:]...).Ptr())
+	case OpCondBr:
+		mismatch("condition", inst.Types[0], I1)
+	case OpRet:
+		if len(inst.Operands) == 0 {
+			mismatch("returned value", Void, v.fn.Returns)
+		} else {
+			mismatch("returned value", inst.Types[0], v.fn.Returns)
+		}
+	}
+}
+
+// valueType returns the type of value, or nil if it is a constant whose type
+// depends on where it is used.
+func (v *verifier) valueType(value Value) Type {
+	switch val := value.(type) {
+	case Reg:
+		return v.regs[val]
+	case Global:
+		return v.globals[val]
+	default:
+		return nil
+	}
+}
+
+// resultType returns the type of the register defined by inst, or nil if
+// that type cannot be determined.
+func resultType(inst *Instruction) Type {
+	switch inst.Op {
+	case OpAlloca:
+		return inst.Type.Ptr()
+	case OpGetElementPtr:
+		// the first index steps over the pointer, and the others into the
+		// aggregate it points to
+		typ := inst.Type
+		for _, idx := range inst.Operands[min(2, len(inst.Operands)):] {
+			if lit, ok := idx.(LitInt); ok {
+				typ = aggregateElement(typ, int(lit))
+			} else if _, ok := typ.(ArrayType); ok {
+				typ = aggregateElement(typ, 0)
+			} else {
+				return nil
+			}
+			if typ == nil {
+				return nil
+			}
+		}
+		return typ.Ptr()
+	case OpInsertValue:
+		return inst.Types[0]
+	case OpExtractValue:
+		return aggregateElement(inst.Types[0], inst.Index)
+	default:
+		return inst.Type
+	}
+}
+
+// aggregateElement returns the type of the element at idx of agg like
+// elementType, or nil if agg has no such element.
+func aggregateElement(agg Type, idx int) Type {
+	switch t := agg.(type) {
+	case *StructType:
+		if idx < 0 || idx >= len(t.Fields) {
+			return nil
+		}
+	case *LiteralStructType:
+		if idx < 0 || idx >= len(t.Fields) {
+			return nil
+		}
+	case ArrayType:
+		if idx < 0 || idx >= t.dimensions[0] {
+			return nil
+		}
+	default:
+		return nil
+	}
+	return elementType(agg, idx)
+}
+
+func sameType(t1, t2 Type) bool {
+	return t1.String() == t2.String()
+}
+
+// dominators holds the immediate dominator of each block reachable from the
+// entry of a function.
+type dominators struct {
+	idom  map[*BasicBlock]*BasicBlock
+	order map[*BasicBlock]int
+}
+
+// newDominators computes the dominators of the blocks of f with the
+// algorithm of Cooper, Harvey and Kennedy, iterating over the blocks in
+// reverse postorder until the immediate dominators no longer change.
+func newDominators(f *Function, blocks map[string]*BasicBlock) *dominators {
+	var postorder []*BasicBlock
+	visited := make(map[*BasicBlock]bool)
+	var visit func(*BasicBlock)
+	visit = func(block *BasicBlock) {
+		visited[block] = true
+		for _, succ := range block.Succs() {
+			if next := blocks[succ]; next != nil && !visited[next] {
+				visit(next)
+			}
+		}
+		postorder = append(postorder, block)
+	}
+	entry := f.Blocks[0]
+	visit(entry)
+
+	d := &dominators{
+		idom:  map[*BasicBlock]*BasicBlock{entry: entry},
+		order: make(map[*BasicBlock]int),
+	}
+	rpo := slices.Clone(postorder)
+	slices.Reverse(rpo)
+	for i, block := range rpo {
+		d.order[block] = i
+	}
+	preds := make(map[*BasicBlock][]*BasicBlock)
+	for _, block := range rpo {
+		for _, succ := range block.Succs() {
+			if next := blocks[succ]; next != nil {
+				preds[next] = append(preds[next], block)
+			}
+		}
+	}
+	intersect := func(b1, b2 *BasicBlock) *BasicBlock {
+		for b1 != b2 {
+			for d.order[b1] > d.order[b2] {
+				b1 = d.idom[b1]
+			}
+			for d.order[b2] > d.order[b1] {
+				b2 = d.idom[b2]
+			}
+		}
+		return b1
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, block := range rpo[1:] {
+			var idom *BasicBlock
+			for _, pred := range preds[block] {
+				if _, ok := d.idom[pred]; !ok {
+					continue
+				}
+				if idom == nil {
+					idom = pred
+				} else {
+					idom = intersect(pred, idom)
+				}
+			}
+			if d.idom[block] != idom {
+				d.idom[block] = idom
+				changed = true
+			}
+		}
+	}
+	return d
+}
+
+func (d *dominators) reachable(block *BasicBlock) bool {
+	_, ok := d.order[block]
+	return ok
+}
+
+// dominates reports whether every path from the entry to b2 passes through
+// b1. Blocks that cannot be reached are dominated by every block.
+func (d *dominators) dominates(b1, b2 *BasicBlock) bool {
+	if !d.reachable(b2) {
+		return true
+	}
+	if !d.reachable(b1) {
+		return false
+	}
+	for {
+		if b1 == b2 {
+			return true
+		}
+		next := d.idom[b2]
+		if next == b2 {
+			return false
+		}
+		b2 = next
+	}
+}
@@ -1,45 +1,72 @@
 package llvmgen
 
 import (
-	"bytes"
 	"fmt"
 	"io"
-	"strings"
 )
 
+// Writer builds an in-memory Module instruction by instruction, appending to
+// the function and block last started. The module is printed by WriteAll,
+// which lets passes inspect and transform it in between.
 type Writer struct {
-	writer    io.Writer
-	globalBuf *bytes.Buffer
-	typeBuf   *bytes.Buffer
-	funcBuf   *bytes.Buffer
+	writer io.Writer
+	module *Module
+	fn     *Function
+	block  *BasicBlock
+
+	// deadBlocks counts the blocks started for code following a terminator,
+	// which the current block is one of if dead is set
+	deadBlocks int
+	dead       bool
 }
 
 func NewWriter(w io.Writer) *Writer {
 	return &Writer{
-		writer:    w,
-		globalBuf: &bytes.Buffer{},
-		typeBuf:   &bytes.Buffer{},
-		funcBuf:   &bytes.Buffer{},
+		writer: w,
+		module: &Module{},
 	}
 }
 
+// Module returns the module built so far.
+func (w *Writer) Module() *Module {
+	return w.module
+}
+
 func (w *Writer) WriteAll() error {
-	// Write global variables/constants and function declarations
-	w.globalBuf.Write([]byte("\n"))
-	if _, err := w.globalBuf.WriteTo(w.writer); err != nil {
-		return err
+	_, err := w.module.WriteTo(w.writer)
+	return err
+}
+
+// emit appends inst to the current block, starting an unnamed entry block if
+// the current function has none yet. Instructions following a terminator, such
+// as the statements after a return, cannot run, and go to a new block without
+// predecessors so that every block still ends in its terminator.
+func (w *Writer) emit(inst *Instruction) error {
+	if w.fn == nil {
+		return fmt.Errorf("instruction outside of a function definition")
 	}
-	// Write type definitions
-	if _, err := w.typeBuf.WriteTo(w.writer); err != nil {
-		return err
+	if w.block == nil {
+		w.block = w.fn.AddBlock("")
 	}
-	// Write function definitions
-	if _, err := w.funcBuf.WriteTo(w.writer); err != nil {
-		return err
+	if w.block.Terminator() != nil && inst.Op != OpComment {
+		w.block = w.fn.AddBlock(fmt.Sprintf("dead%d", w.deadBlocks))
+		w.deadBlocks++
+		w.dead = true
 	}
+	w.block.Append(inst)
 	return nil
 }
 
+// endDeadBlock terminates the current block if it was started for code
+// following a terminator and does not end in one, since control never falls
+// through it.
+func (w *Writer) endDeadBlock() {
+	if w.dead && w.block.Terminator() == nil {
+		w.block.Append(&Instruction{Op: OpUnreachable, Type: Void})
+	}
+	w.dead = false
+}
+
 type FuncParam struct {
 	Type Type
 	Name Reg
@@ -67,9 +94,13 @@ func Phi(val Value, lab string) PhiPair {
 	return PhiPair{Val: val, Label: lab}
 }
 
-func (w *Writer) Newline() error {
-	_, err := w.funcBuf.Write([]byte("\n"))
-	return err
+type SwitchCase struct {
+	Val   Value
+	Label string
+}
+
+func Case(val Value, lab string) SwitchCase {
+	return SwitchCase{Val: val, Label: lab}
 }
 
 func (w *Writer) Declare(
@@ -77,21 +108,23 @@ func (w *Writer) Declare(
 	funcName Global,
 	inputs ...Type,
 ) error {
-	var inputTypes []string
-	for _, input := range inputs {
-		inputTypes = append(inputTypes, input.String())
-	}
-	llvmInputs := strings.Join(inputTypes, ", ")
-
-	llvmInstr := fmt.Sprintf(
-		"declare %s %s(%s)\n",
-		returns.String(),
-		funcName.String(),
-		llvmInputs,
-	)
-	if _, err := w.globalBuf.Write([]byte(llvmInstr)); err != nil {
-		return err
-	}
+	return w.DeclareAttrs(returns, funcName, nil, inputs...)
+}
+
+// DeclareAttrs declares an external function like Declare, with the given
+// function attributes such as returns_twice or noreturn.
+func (w *Writer) DeclareAttrs(
+	returns Type,
+	funcName Global,
+	attrs []string,
+	inputs ...Type,
+) error {
+	w.module.Globals = append(w.module.Globals, &FuncDecl{
+		Returns: returns,
+		Name:    funcName,
+		Params:  inputs,
+		Attrs:   attrs,
+	})
 	return nil
 }
 
@@ -100,30 +133,56 @@ func (w *Writer) StartDefine(
 	funcName Global,
 	inputs ...FuncParam,
 ) error {
-	var llvmParams []string
-	for _, param := range inputs {
-		llvmParams = append(
-			llvmParams,
-			param.Type.String()+" "+param.Name.String(),
+	return w.StartDefineAttrs(returns, funcName, nil, inputs...)
+}
+
+// StartDefineAttrs starts a function definition like StartDefine, with the
+// given function attributes such as alwaysinline or noinline.
+func (w *Writer) StartDefineAttrs(
+	returns Type,
+	funcName Global,
+	attrs []string,
+	inputs ...FuncParam,
+) error {
+	if w.fn != nil {
+		return fmt.Errorf(
+			"StartDefine: '%s' started inside of '%s'", funcName, w.fn.Name,
 		)
 	}
-	llvmInstr := fmt.Sprintf(
-		"define %s %s(%s){\n",
-		returns.String(), funcName.String(), strings.Join(llvmParams, ", "),
-	)
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	w.fn = NewFunction(returns, funcName, inputs...)
+	w.fn.Attrs = attrs
+	w.block = nil
+	w.module.Funcs = append(w.module.Funcs, w.fn)
+	return nil
 }
 
 func (w *Writer) EndDefine() error {
-	_, err := w.funcBuf.Write([]byte("}\n"))
-	return err
+	if w.fn == nil {
+		return fmt.Errorf("EndDefine: no function definition started")
+	}
+	w.endDeadBlock()
+	w.fn = nil
+	w.block = nil
+	return nil
+}
+
+// HoistAllocas moves the allocas of the current function to the start of its
+// entry block.
+func (w *Writer) HoistAllocas() error {
+	if w.fn == nil {
+		return fmt.Errorf("HoistAllocas: no function definition started")
+	}
+	w.fn.HoistAllocas()
+	return nil
 }
 
 func (w *Writer) Block(name string) error {
-	llvmInstr := fmt.Sprintf("\n%s:\n", name)
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	if w.fn == nil {
+		return fmt.Errorf("Block: '%s' outside of a function definition", name)
+	}
+	w.endDeadBlock()
+	w.block = w.fn.AddBlock(name)
+	return nil
 }
 
 func (w *Writer) Label(name string) error {
@@ -131,9 +190,7 @@ func (w *Writer) Label(name string) error {
 }
 
 func (w *Writer) Br(label string) error {
-	llvmInstr := fmt.Sprintf("\tbr label %%%s\n", label)
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.emit(&Instruction{Op: OpBr, Type: Void, Labels: []string{label}})
 }
 
 func (w *Writer) BrIf(
@@ -145,11 +202,38 @@ func (w *Writer) BrIf(
 	if typ != I1 {
 		return fmt.Errorf("Br: cannot branch on non-boolean values")
 	}
-	llvmInstr := fmt.Sprintf(
-		"\tbr i1 %s, label %%%s, label %%%s\n", cond.String(), iftrue, iffalse,
-	)
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.emit(&Instruction{
+		Op:       OpCondBr,
+		Type:     Void,
+		Operands: []Value{cond},
+		Types:    []Type{I1},
+		Labels:   []string{iftrue, iffalse},
+	})
+}
+
+func (w *Writer) Switch(
+	typ Type,
+	value Value,
+	defaultLabel string,
+	cases ...SwitchCase,
+) error {
+	inst := &Instruction{
+		Op:       OpSwitch,
+		Type:     Void,
+		Operands: []Value{value},
+		Types:    []Type{typ},
+		Labels:   []string{defaultLabel},
+	}
+	for _, c := range cases {
+		inst.Operands = append(inst.Operands, c.Val)
+		inst.Types = append(inst.Types, typ)
+		inst.Labels = append(inst.Labels, c.Label)
+	}
+	return w.emit(inst)
+}
+
+func (w *Writer) Unreachable() error {
+	return w.emit(&Instruction{Op: OpUnreachable, Type: Void})
 }
 
 func (w *Writer) Phi(
@@ -160,41 +244,28 @@ func (w *Writer) Phi(
 	if len(phiPairs) == 0 {
 		return fmt.Errorf("Phi: must have at least one incoming value")
 	}
-	var froms []string
+	inst := &Instruction{Op: OpPhi, Des: des, Type: typ}
 	for _, phiPair := range phiPairs {
-		froms = append(froms,
-			fmt.Sprintf("[ %s, %%%s ]", phiPair.Val.String(), phiPair.Label),
-		)
+		inst.Operands = append(inst.Operands, phiPair.Val)
+		inst.Types = append(inst.Types, typ)
+		inst.Labels = append(inst.Labels, phiPair.Label)
 	}
-	llvmInstr := fmt.Sprintf(
-		"\t%s = phi %s %s\n",
-		des.String(), typ.String(), strings.Join(froms, ", "),
-	)
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.emit(inst)
 }
 
 func (w *Writer) Ret(typ Type, val ...Value) error {
-	var llvmInstr string
 	if typ == Void {
-		llvmInstr = "\tret void\n"
-	} else {
-		if len(val) == 0 {
-			return fmt.Errorf("Ret: non-void return type requires a value")
-		}
-		llvmInstr = fmt.Sprintf("\tret %s %s\n", typ.String(), val[0].String())
+		return w.emit(&Instruction{Op: OpRet, Type: Void})
 	}
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
-}
-
-func (w *Writer) Constant(des Reg, typ Type, lit Value) error {
-	llvmInstr := fmt.Sprintf(
-		"\t%s = %s %s\n",
-		des.String(), typ.String(), lit.String(),
-	)
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	if len(val) == 0 {
+		return fmt.Errorf("Ret: non-void return type requires a value")
+	}
+	return w.emit(&Instruction{
+		Op:       OpRet,
+		Type:     typ,
+		Operands: []Value{val[0]},
+		Types:    []Type{typ},
+	})
 }
 
 func (w *Writer) GetElementPtr(
@@ -204,414 +275,259 @@ func (w *Writer) GetElementPtr(
 	from Value,
 	idx ...Value,
 ) error {
-	var indices []string
+	inst := &Instruction{
+		Op:       OpGetElementPtr,
+		Des:      des,
+		Type:     elemType,
+		Operands: []Value{from},
+		Types:    []Type{ptrType},
+	}
 	for _, i := range idx {
-		indices = append(indices, fmt.Sprintf("i32 %s", i.String()))
+		inst.Operands = append(inst.Operands, i)
+		inst.Types = append(inst.Types, I32)
 	}
-	llvmInstr := fmt.Sprintf(
-		"\t%s = getelementptr %s, %s %s, %s\n",
-		des.String(), elemType.String(), ptrType.String(), from.String(),
-		strings.Join(indices, ", "),
-	)
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.emit(inst)
 }
 
 func (w *Writer) InternalConstant(name Global, typ Type, val Value) error {
-	llvmInstr := fmt.Sprintf(
-		"%s = internal constant %s %s\n",
-		name.String(), typ.String(), val.String(),
-	)
-	_, err := w.globalBuf.Write([]byte(llvmInstr))
-	return err
+	w.module.Globals = append(w.module.Globals, &GlobalConst{
+		Name:  name,
+		Type:  typ,
+		Value: val,
+	})
+	return nil
 }
 
 func (w *Writer) Alloca(des Reg, typ Type) error {
-	llvmInstr := fmt.Sprintf("\t%s = alloca %s\n", des.String(), typ.String())
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.emit(&Instruction{Op: OpAlloca, Des: des, Type: typ})
 }
 
 func (w *Writer) Store(elemType Type, value Value, ptrType Type, ptr Reg) error {
-	llvmInstr := fmt.Sprintf(
-		"\tstore %s %s, %s %s\n",
-		elemType.String(), value.String(), ptrType.String(), ptr.String(),
-	)
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.emit(&Instruction{
+		Op:       OpStore,
+		Type:     Void,
+		Operands: []Value{value, ptr},
+		Types:    []Type{elemType, ptrType},
+	})
 }
 
 func (w *Writer) Load(des Reg, elemType Type, ptrType Type, ptr Reg) error {
-	llvmInstr := fmt.Sprintf(
-		"\t%s = load %s, %s %s, align %d\n",
-		des.String(), elemType.String(), ptrType.String(), ptr.String(),
-		elemType.alignment(),
-	)
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.emit(&Instruction{
+		Op:       OpLoad,
+		Des:      des,
+		Type:     elemType,
+		Operands: []Value{ptr},
+		Types:    []Type{ptrType},
+	})
 }
 
 // Call emits a function call. If typ is llvm.Void, des is ignored
 func (w *Writer) Call(
 	des Reg,
 	typ Type,
-	funcName Global,
+	callee Value,
 	args ...FuncArg,
 ) error {
-	var argsStrs []string
-	for _, arg := range args {
-		argsStrs = append(argsStrs, arg.Type.String()+" "+arg.Value.String())
-	}
-	fmtArgs := strings.Join(argsStrs, ", ")
-	var llvmInstr string
 	if typ == Void {
-		llvmInstr = fmt.Sprintf(
-			"\tcall void %s(%s)\n",
-			funcName.String(), fmtArgs,
-		)
-	} else {
-		llvmInstr = fmt.Sprintf(
-			"\t%s = call %s %s(%s)\n",
-			des.String(), typ.String(), funcName.String(), fmtArgs,
-		)
+		des = ""
 	}
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	argTypes := make([]Type, len(args))
+	for i, arg := range args {
+		argTypes[i] = arg.Type
+	}
+	inst := &Instruction{
+		Op:       OpCall,
+		Des:      des,
+		Type:     typ,
+		Operands: []Value{callee},
+		Types:    []Type{Func(typ, argTypes...).Ptr()},
+	}
+	for _, arg := range args {
+		inst.Operands = append(inst.Operands, arg.Value)
+		inst.Types = append(inst.Types, arg.Type)
+	}
+	return w.emit(inst)
 }
 
-func (w *Writer) Sub(des Reg, typ Type, lhs, rhs Value) error {
-	var llvmInstr string
-
-	switch typ {
-	case I32:
-		llvmInstr = fmt.Sprintf(
-			"\t%s = sub i32 %s, %s\n",
-			des.String(), lhs.String(), rhs.String(),
-		)
-	case Double:
-		llvmInstr = fmt.Sprintf(
-			"\t%s = fsub double %s, %s\n",
-			des.String(), lhs.String(), rhs.String(),
-		)
-	default:
+// binary emits the arithmetic instruction op, which is only defined on i32
+// and double operands.
+func (w *Writer) binary(
+	op Opcode,
+	name string,
+	des Reg,
+	typ Type,
+	lhs, rhs Value,
+) error {
+	if typ != I32 && typ != Double {
 		return fmt.Errorf(
-			"unsupported type '%s' for LLVM instruction 'sub'",
-			typ.String(),
+			"unsupported type '%s' for LLVM instruction '%s'",
+			typ.String(), name,
 		)
 	}
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.emit(&Instruction{
+		Op:       op,
+		Des:      des,
+		Type:     typ,
+		Operands: []Value{lhs, rhs},
+		Types:    []Type{typ, typ},
+	})
 }
 
-func (w *Writer) Add(des Reg, typ Type, lhs, rhs Value) error {
-	var llvmInstr string
+func (w *Writer) Sub(des Reg, typ Type, lhs, rhs Value) error {
+	return w.binary(OpSub, "sub", des, typ, lhs, rhs)
+}
 
-	switch typ {
-	case I32:
-		llvmInstr = fmt.Sprintf(
-			"\t%s = add i32 %s, %s\n",
-			des.String(), lhs.String(), rhs.String(),
-		)
-	case Double:
-		llvmInstr = fmt.Sprintf(
-			"\t%s = fadd double %s, %s\n",
-			des.String(), lhs.String(), rhs.String(),
-		)
-	default:
-		return fmt.Errorf(
-			"unsupported type '%s' for LLVM instruction 'add'",
-			typ.String(),
-		)
-	}
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+func (w *Writer) Add(des Reg, typ Type, lhs, rhs Value) error {
+	return w.binary(OpAdd, "add", des, typ, lhs, rhs)
 }
 
 func (w *Writer) Mul(des Reg, typ Type, lhs, rhs Value) error {
-	var llvmInstr string
-
-	switch typ {
-	case I32:
-		llvmInstr = fmt.Sprintf(
-			"\t%s = mul i32 %s, %s\n",
-			des.String(), lhs.String(), rhs.String(),
-		)
-	case Double:
-		llvmInstr = fmt.Sprintf(
-			"\t%s = fmul double %s, %s\n",
-			des.String(), lhs.String(), rhs.String(),
-		)
-	default:
-		return fmt.Errorf(
-			"unsupported type '%s' for LLVM instruction 'mul'",
-			typ.String(),
-		)
-	}
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.binary(OpMul, "mul", des, typ, lhs, rhs)
 }
 
 func (w *Writer) Div(des Reg, typ Type, lhs, rhs Value) error {
-	var llvmInstr string
-
-	switch typ {
-	case I32:
-		llvmInstr = fmt.Sprintf(
-			"\t%s = sdiv i32 %s, %s\n",
-			des.String(), lhs.String(), rhs.String(),
-		)
-	case Double:
-		llvmInstr = fmt.Sprintf(
-			"\t%s = fdiv double %s, %s\n",
-			des.String(), lhs.String(), rhs.String(),
-		)
-	default:
-		return fmt.Errorf(
-			"unsupported type '%s' for LLVM instruction 'div'",
-			typ.String(),
-		)
-	}
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.binary(OpDiv, "div", des, typ, lhs, rhs)
 }
 
 func (w *Writer) Rem(des Reg, typ Type, lhs, rhs Value) error {
-	var llvmInstr string
+	return w.binary(OpRem, "div", des, typ, lhs, rhs)
+}
 
-	switch typ {
-	case I32:
-		llvmInstr = fmt.Sprintf(
-			"\t%s = srem i32 %s, %s\n",
-			des.String(), lhs.String(), rhs.String(),
-		)
-	case Double:
-		llvmInstr = fmt.Sprintf(
-			"\t%s = frem double %s, %s\n",
-			des.String(), lhs.String(), rhs.String(),
-		)
-	default:
+func (w *Writer) Xor(des Reg, typ Type, lhs, rhs Value) error {
+	return w.emit(&Instruction{
+		Op:       OpXor,
+		Des:      des,
+		Type:     typ,
+		Operands: []Value{lhs, rhs},
+		Types:    []Type{typ, typ},
+	})
+}
+
+// cmp emits a comparison with the predicate pred. Orderings are only defined
+// on i32 and double operands, while equality is also defined on i1 and
+// pointers.
+func (w *Writer) cmp(
+	pred CmpPred,
+	name string,
+	des Reg,
+	typ Type,
+	lhs, rhs Value,
+) error {
+	supported := typ == I32 || typ == Double
+	if pred == PredEq || pred == PredNe {
+		_, isPtr := typ.(PtrType)
+		supported = supported || typ == I1 || isPtr
+	}
+	if !supported {
 		return fmt.Errorf(
-			"unsupported type '%s' for LLVM instruction 'div'",
-			typ.String(),
+			"unsupported type '%s' for LLVM instruction '%s'",
+			typ.String(), name,
 		)
 	}
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
-}
-
-func (w *Writer) Xor(des Reg, typ Type, lhs, rhs Value) error {
-	llvmInstr := fmt.Sprintf(
-		"\t%s = xor %s %s, %s\n",
-		des.String(), typ.String(), lhs.String(), rhs.String(),
-	)
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.emit(&Instruction{
+		Op:       OpCmp,
+		Des:      des,
+		Type:     I1,
+		Operands: []Value{lhs, rhs},
+		Types:    []Type{typ, typ},
+		Pred:     pred,
+	})
 }
 
 func (w *Writer) CmpLt(des Reg, typ Type, lhs, rhs Value) error {
-	var llvmInstr string
-	switch typ {
-	case I32:
-		llvmInstr = fmt.Sprintf(
-			"\t%s = icmp slt i32 %s, %s\n",
-			des.String(), lhs.String(), rhs.String(),
-		)
-	case Double:
-		llvmInstr = fmt.Sprintf(
-			"\t%s = fcmp olt double %s, %s\n",
-			des.String(), lhs.String(), rhs.String(),
-		)
-	default:
-		return fmt.Errorf(
-			"unsupported type '%s' for LLVM instruction 'cmp lt'",
-			typ.String(),
-		)
-	}
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.cmp(PredLt, "cmp lt", des, typ, lhs, rhs)
 }
 
 func (w *Writer) CmpLe(des Reg, typ Type, lhs, rhs Value) error {
-	var llvmInstr string
-	switch typ {
-	case I32:
-		llvmInstr = fmt.Sprintf(
-			"\t%s = icmp sle i32 %s, %s\n",
-			des.String(), lhs.String(), rhs.String(),
-		)
-	case Double:
-		llvmInstr = fmt.Sprintf(
-			"\t%s = fcmp ole double %s, %s\n",
-			des.String(), lhs.String(), rhs.String(),
-		)
-	default:
-		return fmt.Errorf(
-			"unsupported type '%s' for LLVM instruction 'cmp le'",
-			typ.String(),
-		)
-	}
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.cmp(PredLe, "cmp le", des, typ, lhs, rhs)
 }
 
 func (w *Writer) CmpGt(des Reg, typ Type, lhs, rhs Value) error {
-	var llvmInstr string
-	switch typ {
-	case I32:
-		llvmInstr = fmt.Sprintf(
-			"\t%s = icmp sgt i32 %s, %s\n",
-			des.String(), lhs.String(), rhs.String(),
-		)
-	case Double:
-		llvmInstr = fmt.Sprintf(
-			"\t%s = fcmp ogt double %s, %s\n",
-			des.String(), lhs.String(), rhs.String(),
-		)
-	default:
-		return fmt.Errorf(
-			"unsupported type '%s' for LLVM instruction 'cmp gt'",
-			typ.String(),
-		)
-	}
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.cmp(PredGt, "cmp gt", des, typ, lhs, rhs)
 }
 
 func (w *Writer) CmpGe(des Reg, typ Type, lhs, rhs Value) error {
-	var llvmInstr string
-	switch typ {
-	case I32:
-		llvmInstr = fmt.Sprintf(
-			"\t%s = icmp sge i32 %s, %s\n",
-			des.String(), lhs.String(), rhs.String(),
-		)
-	case Double:
-		llvmInstr = fmt.Sprintf(
-			"\t%s = fcmp oge double %s, %s\n",
-			des.String(), lhs.String(), rhs.String(),
-		)
-	default:
-		return fmt.Errorf(
-			"unsupported type '%s' for LLVM instruction 'cmp ge'",
-			typ.String(),
-		)
-	}
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.cmp(PredGe, "cmp ge", des, typ, lhs, rhs)
 }
 
 func (w *Writer) CmpEq(des Reg, typ Type, lhs, rhs Value) error {
-	var llvmInstr string
-	switch t := typ.(type) {
-	case PrimitiveType:
-		switch t {
-		case I1:
-			llvmInstr = fmt.Sprintf(
-				"\t%s = icmp eq i1 %s, %s\n",
-				des.String(), lhs.String(), rhs.String(),
-			)
-		case I32:
-			llvmInstr = fmt.Sprintf(
-				"\t%s = icmp eq i32 %s, %s\n",
-				des.String(), lhs.String(), rhs.String(),
-			)
-		case Double:
-			llvmInstr = fmt.Sprintf(
-				"\t%s = fcmp oeq double %s, %s\n",
-				des.String(), lhs.String(), rhs.String(),
-			)
-		default:
-			return fmt.Errorf(
-				"unsupported primitive type '%s' for LLVM instruction 'cmp eq'",
-				typ.String(),
-			)
-		}
-	case PtrType:
-		llvmInstr = fmt.Sprintf(
-			"\t%s = icmp eq %s %s, %s\n",
-			des.String(), typ.String(), lhs.String(), rhs.String(),
-		)
-	default:
-		return fmt.Errorf(
-			"unsupported type '%s' for LLVM instruction 'cmp eq'",
-			typ.String(),
-		)
-	}
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.cmp(PredEq, "cmp eq", des, typ, lhs, rhs)
 }
 
 func (w *Writer) CmpNe(des Reg, typ Type, lhs, rhs Value) error {
-	var llvmInstr string
-	switch t := typ.(type) {
-	case PrimitiveType:
-		switch t {
-		case I1:
-			llvmInstr = fmt.Sprintf(
-				"\t%s = icmp ne i1 %s, %s\n",
-				des.String(), lhs.String(), rhs.String(),
-			)
-		case I32:
-			llvmInstr = fmt.Sprintf(
-				"\t%s = icmp ne i32 %s, %s\n",
-				des.String(), lhs.String(), rhs.String(),
-			)
-		case Double:
-			llvmInstr = fmt.Sprintf(
-				"\t%s = fcmp one double %s, %s\n",
-				des.String(), lhs.String(), rhs.String(),
-			)
-		default:
-			return fmt.Errorf(
-				"unsupported primitive type '%s' for LLVM instruction 'cmp ne'",
-				typ.String(),
-			)
-		}
-	case PtrType:
-		llvmInstr = fmt.Sprintf(
-			"\t%s = icmp ne %s %s, %s\n",
-			des.String(), typ.String(), lhs.String(), rhs.String(),
-		)
-	default:
-		return fmt.Errorf(
-			"unsupported type '%s' for LLVM instruction 'cmp ne'",
-			typ.String(),
-		)
-	}
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.cmp(PredNe, "cmp ne", des, typ, lhs, rhs)
 }
 
 func (w *Writer) Type(structType *StructType) error {
-	var fieldStrs []string
-	for _, f := range structType.Fields {
-		fieldStrs = append(fieldStrs, f.String())
-	}
-	definition := fmt.Sprintf(
-		"%%%s = type { %s }\n",
-		structType.Name,
-		strings.Join(fieldStrs, ", "),
-	)
-	_, err := w.typeBuf.WriteString(definition)
-	return err
+	w.module.Types = append(w.module.Types, structType)
+	return nil
 }
 
 func (w Writer) StructType(structType *StructType) error {
 	return w.Type(structType)
 }
 
+// cast emits the conversion op of value from fromType to toType.
+func (w *Writer) cast(
+	op Opcode,
+	des Reg,
+	fromType Type,
+	value Value,
+	toType Type,
+) error {
+	return w.emit(&Instruction{
+		Op:       op,
+		Des:      des,
+		Type:     toType,
+		Operands: []Value{value},
+		Types:    []Type{fromType},
+	})
+}
+
 func (w *Writer) Bitcast(
 	des Reg,
 	fromType Type,
 	value Value,
 	toType Type,
 ) error {
-	llvmInstr := fmt.Sprintf(
-		"\t%s = bitcast %s %s to %s\n",
-		des.String(), fromType.String(), value.String(), toType.String(),
-	)
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.cast(OpBitcast, des, fromType, value, toType)
+}
+
+// InsertValue writes to des the aggregate agg with the element at idx replaced
+// by elem.
+func (w *Writer) InsertValue(
+	des Reg,
+	aggType Type,
+	agg Value,
+	elemType Type,
+	elem Value,
+	idx int,
+) error {
+	return w.emit(&Instruction{
+		Op:       OpInsertValue,
+		Des:      des,
+		Type:     aggType,
+		Operands: []Value{agg, elem},
+		Types:    []Type{aggType, elemType},
+		Index:    idx,
+	})
+}
+
+// ExtractValue writes to des the element at idx of the aggregate agg.
+func (w *Writer) ExtractValue(
+	des Reg,
+	aggType Type,
+	agg Value,
+	idx int,
+) error {
+	return w.emit(&Instruction{
+		Op:       OpExtractValue,
+		Des:      des,
+		Type:     elementType(aggType, idx),
+		Operands: []Value{agg},
+		Types:    []Type{aggType},
+		Index:    idx,
+	})
 }
 
 func (w *Writer) PtrToInt(
@@ -620,12 +536,7 @@ func (w *Writer) PtrToInt(
 	toType Type,
 	value Reg,
 ) error {
-	llvmInstr := fmt.Sprintf(
-		"\t%s = ptrtoint %s %s to %s\n",
-		des.String(), fromType.String(), value.String(), toType.String(),
-	)
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.cast(OpPtrToInt, des, fromType, value, toType)
 }
 
 func (w *Writer) ZExt(
@@ -634,16 +545,18 @@ func (w *Writer) ZExt(
 	value Value,
 	toType Type,
 ) error {
-	llvmInstr := fmt.Sprintf(
-		"\t%s = zext %s %s to %s\n",
-		dest.String(), fromType.String(), value.String(), toType.String(),
-	)
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.cast(OpZExt, dest, fromType, value, toType)
+}
+
+func (w *Writer) SIToFP(
+	des Reg,
+	fromType Type,
+	value Value,
+	toType Type,
+) error {
+	return w.cast(OpSIToFP, des, fromType, value, toType)
 }
 
 func (w *Writer) Comment(comment string) error {
-	llvmInstr := fmt.Sprintf("\t; %s\n", comment)
-	_, err := w.funcBuf.Write([]byte(llvmInstr))
-	return err
+	return w.emit(&Instruction{Op: OpComment, Type: Void, Text: comment})
 }
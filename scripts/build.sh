@@ -9,10 +9,11 @@ cd "$SCRIPT_DIR/.."
 
 mkdir -p build
 
+# extra arguments are passed on to go build, such as -tags debug
 echo "Building jlc executable..."
-go build -o build/jlc cmd/jlc/main.go
+go build "$@" -o build/jlc ./cmd/jlc
 echo "Building typecheck executable..."
-go build -o build/typecheck cmd/typecheck/main.go
+go build "$@" -o build/typecheck ./cmd/typecheck
 
 echo "Finished building"
 
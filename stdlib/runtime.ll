@@ -39,3 +39,87 @@ entry:	%res = alloca double
 	%t2 = load double, double* %res
 	ret double %t2
 }
+
+; exceptions are implemented with setjmp/longjmp. A try statement allocates a
+; frame holding a jmp_buf followed by the enclosing frame, calls setjmp on it
+; and enters it. A throw longjmps to the innermost entered frame, which exits it
+; before dispatching on the kind of the thrown Error.
+
+%jl.frame = type { [64 x i64], i8* }
+%jl.error = type { i32, i8* }
+
+@jl.handler = internal global %jl.frame* null
+@jl.exception = internal global i8* null
+@uncaught = internal constant [24 x i8] c"uncaught exception: %s\0A\00"
+@nullthrown = internal constant [5 x i8] c"null\00"
+
+@stderr = external global i8*
+declare i32 @fprintf(i8*, i8*, ...)
+declare i32 @fflush(i8*)
+declare void @longjmp(i8*, i32) noreturn
+declare void @exit(i32) noreturn
+
+define void @jl.enterTry(%jl.frame* %frame) {
+entry:	%prev = load %jl.frame*, %jl.frame** @jl.handler
+	%prevRaw = bitcast %jl.frame* %prev to i8*
+	%prevPtr = getelementptr %jl.frame, %jl.frame* %frame, i32 0, i32 1
+	store i8* %prevRaw, i8** %prevPtr
+	store %jl.frame* %frame, %jl.frame** @jl.handler
+	ret void
+}
+
+define void @jl.exitTry(%jl.frame* %frame) {
+entry:	%prevPtr = getelementptr %jl.frame, %jl.frame* %frame, i32 0, i32 1
+	%prevRaw = load i8*, i8** %prevPtr
+	%prev = bitcast i8* %prevRaw to %jl.frame*
+	store %jl.frame* %prev, %jl.frame** @jl.handler
+	ret void
+}
+
+define i8* @jl.caught() {
+entry:	%exception = load i8*, i8** @jl.exception
+	ret i8* %exception
+}
+
+define void @jl.throw(i8* %exception) noreturn {
+entry:	store i8* %exception, i8** @jl.exception
+	%frame = load %jl.frame*, %jl.frame** @jl.handler
+	%isNull = icmp eq i8* %exception, null
+	%noFrame = icmp eq %jl.frame* %frame, null
+	%uncaught = or i1 %isNull, %noFrame
+	br i1 %uncaught, label %abort, label %unwind
+unwind:	%buf = bitcast %jl.frame* %frame to i8*
+	call void @longjmp(i8* %buf, i32 1)
+	unreachable
+abort:	%error = bitcast i8* %exception to %jl.error*
+	%msgPtr = getelementptr %jl.error, %jl.error* %error, i32 0, i32 1
+	%null = getelementptr [5 x i8], [5 x i8]* @nullthrown, i32 0, i32 0
+	br i1 %isNull, label %report, label %message
+message:	%msg = load i8*, i8** %msgPtr
+	br label %report
+report:	%text = phi i8* [ %null, %abort ], [ %msg, %message ]
+	%fmt = getelementptr [24 x i8], [24 x i8]* @uncaught, i32 0, i32 0
+	call i32 @fflush(i8* null)
+	%out = load i8*, i8** @stderr
+	call i32 (i8*, i8*, ...) @fprintf(i8* %out, i8* %fmt, i8* %text)
+	call void @exit(i32 1)
+	unreachable
+}
+
+@assertfail = internal constant [32 x i8] c"%s:%d:%d: assertion failed: %s\0A\00"
+@assertfailmsg = internal constant [36 x i8] c"%s:%d:%d: assertion failed: %s: %s\0A\00"
+
+define void @jl.assertFail(i8* %file, i32 %line, i32 %col, i8* %exp, i8* %msg) noreturn {
+entry:	call i32 @fflush(i8* null)
+	%out = load i8*, i8** @stderr
+	%hasMsg = icmp ne i8* %msg, null
+	br i1 %hasMsg, label %withMsg, label %withoutMsg
+withMsg:	%fmtMsg = getelementptr [36 x i8], [36 x i8]* @assertfailmsg, i32 0, i32 0
+	call i32 (i8*, i8*, ...) @fprintf(i8* %out, i8* %fmtMsg, i8* %file, i32 %line, i32 %col, i8* %exp, i8* %msg)
+	call void @exit(i32 1)
+	unreachable
+withoutMsg:	%fmt = getelementptr [32 x i8], [32 x i8]* @assertfail, i32 0, i32 0
+	call i32 (i8*, i8*, ...) @fprintf(i8* %out, i8* %fmt, i8* %file, i32 %line, i32 %col, i8* %exp)
+	call void @exit(i32 1)
+	unreachable
+}